@@ -1,12 +1,20 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"generic-integration-platform/internal/application/services"
 	"generic-integration-platform/internal/infra/config"
-	"generic-integration-platform/internal/infra/db"
-	"generic-integration-platform/internal/infra/eventstore"
+	"generic-integration-platform/internal/infra/encryption"
+	"generic-integration-platform/internal/infra/extender"
+	"generic-integration-platform/internal/infra/extender/plugin"
+	"generic-integration-platform/internal/infra/extender/rest"
 	"generic-integration-platform/internal/infra/http"
 	"generic-integration-platform/internal/infra/http/routes"
 	"generic-integration-platform/internal/infra/monitoring"
+	"generic-integration-platform/internal/infra/script"
+	"generic-integration-platform/internal/infra/secrets"
+	"generic-integration-platform/internal/infra/storage"
 	"log"
 
 	"github.com/gin-gonic/gin"
@@ -36,14 +44,22 @@ import (
 
 // @security ApiKeyAuth
 func main() {
-	cfg, err := config.LoadConfig("config.toml")
+	path := flag.String("config", "config.toml", "path of the configuration file")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration, with secrets masked, and exit")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*path)
 	if err != nil {
-		log.Fatal("Failed to load config")
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if *printConfig {
+		fmt.Print(cfg)
+		return
 	}
 
-	iCfg, err := config.LoadIntegrationConfig("payments.toml")
+	iCfg, err := config.LoadIntegrationConfig(cfg.Integrations.Path)
 	if err != nil {
-		log.Fatal("Failed to load config")
+		log.Fatalf("Failed to load integrations from %s: %v", cfg.Integrations.Path, err)
 	}
 
 	fx.New(
@@ -53,9 +69,20 @@ func main() {
 		monitoring.Module,
 		http.Module,
 		routes.Module,
-		eventstore.Module,
-		db.Module,
+		encryption.Module,
+		storage.Module,
+		secrets.Module,
+		extender.Module,
+		script.Module,
+		services.Module,
+		// Integration types: register additional extenders here.
+		extender.Register(rest.Type, rest.New),
+		plugin.Module,
 		fx.Invoke(
+			// Declared integrations are synced once every type is registered,
+			// then the flows referencing them.
+			services.RegisterIntegrationSync,
+			services.RegisterFlowSync,
 			routes.Routes.Load,
 			func(r *gin.Engine) {},
 		),
@@ -0,0 +1,169 @@
+// Command bundle exports and imports integrations and flows as versioned
+// bundles, to promote configuration between environments.
+//
+//	go run ./cmd/bundle -config config.toml export -format yaml -o bundle.yaml
+//	go run ./cmd/bundle -config config.toml import -conflict overwrite bundle.yaml
+//
+// Imports are applied in one transaction, fully or not at all, except on a
+// MongoDB server outside a replica set, which cannot run transactions: the
+// items already written are then deleted or restored if a write fails, which
+// may fail as well and is reported. Integrations overwritten here keep
+// running with their previous settings in an API instance until it restarts;
+// use POST /import to update a running instance.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"generic-integration-platform/internal/application/bundle"
+	"generic-integration-platform/internal/application/services"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/encryption"
+	"generic-integration-platform/internal/infra/eventstore"
+	"generic-integration-platform/internal/infra/extender"
+	"generic-integration-platform/internal/infra/extender/plugin"
+	"generic-integration-platform/internal/infra/extender/rest"
+	"generic-integration-platform/internal/infra/storage"
+	"log"
+	"os"
+)
+
+func main() {
+	path := flag.String("config", "config.toml", "path of the configuration file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-config file] export|import [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig(*path)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	switch command, args := flag.Arg(0), flag.Args()[1:]; command {
+	case "export":
+		err = export(cfg, args)
+	case "import":
+		err = importBundle(cfg, args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// export writes every integration and flow as a bundle.
+func export(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", config.FormatYAML, "bundle format, yaml or toml")
+	output := flags.String("o", "", "output file, standard output if empty")
+	_ = flags.Parse(args)
+
+	service, closeService, err := newBundleService(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeService()
+
+	b, err := service.Export(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	content, err := b.Encode(*format)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	if err := os.WriteFile(*output, content, 0o644); err != nil {
+		return err
+	}
+	log.Printf("Exported %d integrations and %d flows to %s", len(b.Integrations), len(b.Flows), *output)
+	return nil
+}
+
+// importBundle imports the bundle file given as argument.
+func importBundle(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	conflict := flags.String("conflict", services.ConflictFail, "strategy for existing items: fail, skip or overwrite")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: import [-conflict fail|skip|overwrite] <bundle file>")
+	}
+
+	file := flags.Arg(0)
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	format, err := config.FormatOf(file)
+	if err != nil {
+		return err
+	}
+	b, err := bundle.Decode(content, format)
+	if err != nil {
+		return err
+	}
+
+	service, closeService, err := newBundleService(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeService()
+
+	result, err := service.Import(context.Background(), b, *conflict)
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", file, err)
+	}
+
+	log.Printf("Imported %s: integrations %d created %v, %d updated %v, %d skipped %v; flows %d created %v, %d updated %v, %d skipped %v", file,
+		len(result.Integrations.Created), result.Integrations.Created, len(result.Integrations.Updated), result.Integrations.Updated, len(result.Integrations.Skipped), result.Integrations.Skipped,
+		len(result.Flows.Created), result.Flows.Created, len(result.Flows.Updated), result.Flows.Updated, len(result.Flows.Skipped), result.Flows.Skipped)
+	return nil
+}
+
+// newBundleService connects to the stores the API uses. Extenders are only
+// registered to check the integration types of imports.
+func newBundleService(cfg *config.Config) (*services.BundleService, func(), error) {
+	if cfg.Storage.Backend == config.StorageMemory {
+		return nil, nil, errors.New("the memory storage backend only lives in the API process, use GET /export and POST /import instead")
+	}
+
+	keyring, err := encryption.LoadKeyring(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load master keys: %w", err)
+	}
+
+	stores, err := storage.Open(cfg, keyring)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open the %s storage: %w", cfg.Storage.Backend, err)
+	}
+
+	extenders := extender.NewRegistry(nil)
+	extenders.Register(rest.Type, rest.New)
+	plugin.RegisterPlugins(cfg, extenders)
+
+	service := services.NewBundleService(
+		stores.Integrations,
+		stores.Flows,
+		eventstore.NewIntegrationEventStore(stores.Events),
+		eventstore.NewFlowEventStore(stores.Events),
+		extenders,
+		stores.Transactor,
+	)
+	return service, func() {
+		_ = stores.Close(context.Background())
+	}, nil
+}
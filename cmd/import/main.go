@@ -0,0 +1,140 @@
+// Command import generates integration declarations from the API descriptions
+// published by providers, ready to be added to payments.toml or to the
+// integration directory:
+//
+//	go run ./cmd/import openapi -name stripe -operations createPaymentIntent,capturePaymentIntent spec.yaml >> payments.toml
+//	go run ./cmd/import postman -name acme collection.json
+//	go run ./cmd/import har -base-url https://sandbox.acme.com/v1 sandbox.har
+//
+// Credentials are written as secret://<name>/<field> references, to be stored
+// with PUT /secrets/<name>/<field> before the integration is used.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"generic-integration-platform/internal/application/importer"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/config"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s openapi|postman|har [flags] <file>\n", os.Args[0])
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch command, args := flag.Arg(0), flag.Args()[1:]; command {
+	case "openapi":
+		err = importOpenAPI(args)
+	case "postman":
+		err = importCapture(command, importer.FromPostman, args)
+	case "har":
+		err = importCapture(command, importer.FromHAR, args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// importOpenAPI generates an integration from an OpenAPI document.
+func importOpenAPI(args []string) error {
+	flags := flag.NewFlagSet("openapi", flag.ExitOnError)
+	var opts importer.OpenAPIOptions
+	flags.StringVar(&opts.Name, "name", "", "integration name, the title of the document by default")
+	flags.StringVar(&opts.BaseURL, "base-url", "", "base URL, the first server of the document by default")
+	flags.StringVar(&opts.Currency, "currency", "", "currency of the integration, USD by default")
+	flags.StringVar(&opts.Scheme, "scheme", "", "security scheme to authenticate with, the first one required by default")
+	flags.StringVar(&opts.Username, "username", "", "basic username or OAuth client ID, for the schemes requiring one")
+	operations := flags.String("operations", "", "comma-separated operations to import, all by default")
+	output := newOutputFlags(flags)
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: openapi [flags] <document>")
+	}
+	if *operations != "" {
+		opts.Operations = strings.Split(*operations, ",")
+	}
+
+	content, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	i, err := importer.FromOpenAPI(content, opts)
+	if err != nil {
+		return err
+	}
+
+	return output.write(i)
+}
+
+// importCapture generates an integration from captured requests with the
+// given importer.
+func importCapture(command string, fromCapture func([]byte, importer.CaptureOptions) (*integration.Integration, error), args []string) error {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	var opts importer.CaptureOptions
+	flags.StringVar(&opts.Name, "name", "", "integration name, the collection name or the host by default")
+	flags.StringVar(&opts.BaseURL, "base-url", "", "base URL, the scheme and host of the first request by default")
+	flags.StringVar(&opts.Currency, "currency", "", "currency of the integration, USD by default")
+	output := newOutputFlags(flags)
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: %s [flags] <file>", command)
+	}
+
+	content, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	i, err := fromCapture(content, opts)
+	if err != nil {
+		return err
+	}
+
+	return output.write(i)
+}
+
+// outputFlags selects where and how generated integrations are written.
+type outputFlags struct {
+	format *string
+	file   *string
+}
+
+// newOutputFlags defines the output flags of a command.
+func newOutputFlags(flags *flag.FlagSet) outputFlags {
+	return outputFlags{
+		format: flags.String("format", config.FormatTOML, "output format, toml or yaml"),
+		file:   flags.String("o", "", "output file, standard output if empty"),
+	}
+}
+
+// write writes the declaration of i.
+func (o outputFlags) write(i *integration.Integration) error {
+	content, err := config.Encode(config.IntegrationConfig{
+		Integrations: []config.PaymentProvider{config.FromIntegration(i)},
+	}, *o.format)
+	if err != nil {
+		return err
+	}
+
+	if *o.file == "" {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	if err := os.WriteFile(*o.file, content, 0o644); err != nil {
+		return err
+	}
+	log.Printf("Generated integration %s with %d endpoints in %s", i.Name, len(i.Endpoints), *o.file)
+	return nil
+}
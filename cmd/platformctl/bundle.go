@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"generic-integration-platform/internal/infra/config"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// bundleContentTypes maps bundle formats to their content type.
+var bundleContentTypes = map[string]string{
+	config.FormatYAML: "application/yaml",
+	config.FormatTOML: "application/toml",
+}
+
+func exportCommand(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", config.FormatYAML, "bundle format: yaml or toml")
+	output := flags.String("out", "", "file to write the bundle to, standard output by default")
+	if err := parseFlags(flags, args, 0, "export [-format yaml|toml] [-out file]"); err != nil {
+		return err
+	}
+
+	content, err := a.client.do(ctx, request{method: http.MethodGet, path: "/export", query: url.Values{"format": {*format}}})
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = a.out.w.Write(content)
+		return err
+	}
+	return os.WriteFile(*output, content, 0o600)
+}
+
+func importCommand(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	conflict := flags.String("conflict", "fail", "what to do with existing items: fail, skip or overwrite")
+	if err := parseFlags(flags, args, 1, "import [-conflict fail|skip|overwrite] <bundle.yaml|bundle.toml>"); err != nil {
+		return err
+	}
+
+	path := flags.Arg(0)
+	format, err := config.FormatOf(path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Integrations importChanges `json:"integrations"`
+		Flows        importChanges `json:"flows"`
+	}
+	err = a.client.doJSON(ctx, request{
+		method:      http.MethodPost,
+		path:        "/import",
+		query:       url.Values{"format": {format}, "conflict": {*conflict}},
+		contentType: bundleContentTypes[format],
+		body:        content,
+	}, &result)
+	if err != nil {
+		return err
+	}
+
+	if a.out.format == outputJSON {
+		return a.out.print(result, nil)
+	}
+	rows := []map[string]interface{}{
+		result.Integrations.row("integrations"),
+		result.Flows.row("flows"),
+	}
+	return a.out.print(rows, []column{
+		field("KIND", "kind"),
+		field("CREATED", "created"),
+		field("UPDATED", "updated"),
+		field("SKIPPED", "skipped"),
+	})
+}
+
+// importChanges lists, by name, what an import did to a kind of item.
+type importChanges struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Skipped []string `json:"skipped"`
+}
+
+// row returns the changes as a table row.
+func (c importChanges) row(kind string) map[string]interface{} {
+	return map[string]interface{}{
+		"kind":    kind,
+		"created": fmt.Sprint(len(c.Created)),
+		"updated": fmt.Sprint(len(c.Updated)),
+		"skipped": fmt.Sprint(len(c.Skipped)),
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// client calls the platform API.
+type client struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// newClient creates a client of the API at baseURL authenticated with apiKey.
+func newClient(baseURL, apiKey string) *client {
+	return &client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// apiError is an error answered by the API.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.Status)
+}
+
+// request describes a call to the API.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	contentType string
+	body        []byte
+}
+
+// getJSON calls GET path and decodes the JSON response into out.
+func (c *client) getJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	return c.doJSON(ctx, request{method: http.MethodGet, path: path, query: query}, out)
+}
+
+// sendJSON sends in as the JSON body of the request and decodes the response into out.
+func (c *client) sendJSON(ctx context.Context, method, path string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return c.doJSON(ctx, request{method: method, path: path, contentType: "application/json", body: body}, out)
+}
+
+// doJSON calls the API and decodes the JSON response into out, if not nil.
+func (c *client) doJSON(ctx context.Context, r request, out interface{}) error {
+	content, err := c.do(ctx, r)
+	if err != nil || out == nil || len(content) == 0 {
+		return err
+	}
+	if err := json.Unmarshal(content, out); err != nil {
+		return fmt.Errorf("invalid response from %s %s: %w", r.method, r.path, err)
+	}
+	return nil
+}
+
+// do calls the API and returns the response body, or the error it answered.
+func (c *client) do(ctx context.Context, r request) ([]byte, error) {
+	u := c.baseURL + r.path
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, u, bytes.NewReader(r.body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", c.apiKey)
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, &apiError{Status: resp.StatusCode, Message: errorMessage(content, resp.Status)}
+	}
+	return content, nil
+}
+
+// errorMessage extracts the message of an error response. Handlers answer
+// either {"error": "..."} or {"message": "..."}.
+func errorMessage(content []byte, status string) string {
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(content, &body) == nil {
+		if body.Error != "" {
+			return body.Error
+		}
+		if body.Message != "" {
+			return body.Message
+		}
+	}
+	if text := strings.TrimSpace(string(content)); text != "" {
+		return text
+	}
+	return status
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// pollInterval is the delay between two reads of the events of a flow being executed.
+const pollInterval = 500 * time.Millisecond
+
+// eventsPage is the number of flow events requested at once.
+const eventsPage = 1000
+
+// flowColumns are the columns of flow tables.
+var flowColumns = []column{
+	field("ID", "id"),
+	field("NAME", "name"),
+	count("STEPS", "steps"),
+}
+
+// flowsCommand manages and executes flows.
+func flowsCommand(ctx context.Context, a *app, args []string) error {
+	return subcommand(ctx, a, "flows", args, map[string]func(context.Context, *app, []string) error{
+		"list":    listFlows,
+		"get":     getFlow,
+		"create":  createFlow,
+		"delete":  deleteFlow,
+		"execute": executeFlow,
+		"events":  flowEvents,
+	})
+}
+
+func listFlows(ctx context.Context, a *app, args []string) error {
+	if err := parseFlags(flag.NewFlagSet("list", flag.ContinueOnError), args, 0, "flows list"); err != nil {
+		return err
+	}
+
+	var flows []map[string]interface{}
+	if err := a.client.getJSON(ctx, "/flows/", nil, &flows); err != nil {
+		return err
+	}
+	return a.out.print(flows, flowColumns)
+}
+
+func getFlow(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 1, "flows get <id>"); err != nil {
+		return err
+	}
+
+	var flow map[string]interface{}
+	if err := a.client.getJSON(ctx, "/flows/"+url.PathEscape(flags.Arg(0)), nil, &flow); err != nil {
+		return err
+	}
+	return a.out.print(flow, flowColumns)
+}
+
+func createFlow(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 1, "flows create <file.json|file.yaml>\n\nThe file holds the body of POST /flows."); err != nil {
+		return err
+	}
+
+	body, err := readBody(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var flow map[string]interface{}
+	if err := a.client.sendJSON(ctx, http.MethodPost, "/flows/", body, &flow); err != nil {
+		return err
+	}
+	return a.out.print(flow, flowColumns)
+}
+
+func deleteFlow(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("delete", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 1, "flows delete <id>"); err != nil {
+		return err
+	}
+
+	if err := a.client.doJSON(ctx, request{method: http.MethodDelete, path: "/flows/" + url.PathEscape(flags.Arg(0))}, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out.w, "Flow %s deleted\n", flags.Arg(0))
+	return nil
+}
+
+// flowEvent is an event of a flow, as returned by GET /flows/{id}/events.
+type flowEvent struct {
+	Version   uint64          `json:"version"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// eventColumns are the columns of flow event tables.
+var eventColumns = []column{
+	field("VERSION", "version"),
+	field("TYPE", "type"),
+	column{header: "STEP", value: func(row map[string]interface{}) string {
+		data, _ := row["data"].(map[string]interface{})
+		if name, ok := data["step_name"].(string); ok {
+			return name
+		}
+		return ""
+	}},
+	column{header: "DETAIL", value: func(row map[string]interface{}) string {
+		data, _ := row["data"].(map[string]interface{})
+		for _, key := range []string{"error", "condition", "environment"} {
+			if value, ok := data[key].(string); ok && value != "" {
+				return value
+			}
+		}
+		return ""
+	}},
+}
+
+func flowEvents(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("events", flag.ContinueOnError)
+	from := flags.Uint64("from", 0, "version of the first event to list")
+	if err := parseFlags(flags, args, 1, "flows events [-from N] <id>"); err != nil {
+		return err
+	}
+
+	events, err := readFlowEvents(ctx, a.client, flags.Arg(0), *from)
+	if err != nil {
+		return err
+	}
+	return a.out.print(events, eventColumns)
+}
+
+func executeFlow(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("execute", flag.ContinueOnError)
+	inputFile := flags.String("input", "", "JSON or YAML file holding the input of the flow")
+	env := flags.String("env", "", "environment of the integrations, the environment of the platform by default")
+	follow := flags.Bool("follow", true, "print the steps as they are executed")
+	if err := parseFlags(flags, args, 1, "flows execute [-input file] [-env name] [-follow=false] <id>"); err != nil {
+		return err
+	}
+	id := flags.Arg(0)
+
+	execution := map[string]interface{}{"environment": *env}
+	if *inputFile != "" {
+		input, err := readBody(*inputFile)
+		if err != nil {
+			return err
+		}
+		execution["input"] = input
+	}
+
+	// Events already recorded belong to previous executions.
+	var next uint64
+	if *follow {
+		events, err := readFlowEvents(ctx, a.client, id, 0)
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			next = events[len(events)-1].Version + 1
+		}
+	}
+
+	type result struct {
+		flow map[string]interface{}
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var flow map[string]interface{}
+		err := a.client.sendJSON(ctx, http.MethodPost, "/flows/"+url.PathEscape(id)+"/execute", execution, &flow)
+		done <- result{flow, err}
+	}()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case r := <-done:
+			if *follow {
+				// Print the events recorded since the last poll.
+				if _, err := a.printEvents(ctx, id, next); err != nil {
+					return err
+				}
+			}
+			if r.err != nil {
+				return r.err
+			}
+			if a.out.format == outputJSON {
+				return a.out.print(r.flow, nil)
+			}
+			fmt.Fprintf(a.out.w, "Flow %s executed\n", id)
+			return nil
+		case <-ticker.C:
+			if !*follow {
+				continue
+			}
+			n, err := a.printEvents(ctx, id, next)
+			if err != nil {
+				return err
+			}
+			next = n
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// printEvents prints the events of a flow starting at version from and
+// returns the version following the last one printed.
+func (a *app) printEvents(ctx context.Context, id string, from uint64) (uint64, error) {
+	events, err := readFlowEvents(ctx, a.client, id, from)
+	if err != nil {
+		return from, err
+	}
+
+	for _, e := range events {
+		if a.out.format == outputJSON {
+			if err := a.out.print(e, nil); err != nil {
+				return from, err
+			}
+			continue
+		}
+
+		var data struct {
+			StepName    string `json:"step_name"`
+			Error       string `json:"error"`
+			Environment string `json:"environment"`
+		}
+		_ = json.Unmarshal(e.Data, &data)
+		switch e.Type {
+		case "FlowStepCompletedEvent":
+			fmt.Fprintf(a.out.w, "✓ %s\n", data.StepName)
+		case "FlowStepSkippedEvent":
+			fmt.Fprintf(a.out.w, "- %s (skipped)\n", data.StepName)
+		case "FlowStepFailedEvent":
+			fmt.Fprintf(a.out.w, "✗ %s: %s\n", data.StepName, data.Error)
+		case "FlowExecutedEvent":
+			if data.Environment != "" {
+				fmt.Fprintf(a.out.w, "Completed in %s\n", data.Environment)
+			}
+		}
+		from = e.Version + 1
+	}
+	return from, nil
+}
+
+// readFlowEvents reads every event of a flow starting at version from.
+func readFlowEvents(ctx context.Context, c *client, id string, from uint64) ([]flowEvent, error) {
+	var events []flowEvent
+	for {
+		var page []flowEvent
+		query := url.Values{"from": {strconv.FormatUint(from, 10)}, "limit": {strconv.Itoa(eventsPage)}}
+		if err := c.getJSON(ctx, "/flows/"+url.PathEscape(id)+"/events", query, &page); err != nil {
+			return nil, err
+		}
+		events = append(events, page...)
+		if len(page) < eventsPage {
+			return events, nil
+		}
+		from = page[len(page)-1].Version + 1
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// integrationColumns are the columns of integration tables.
+var integrationColumns = []column{
+	field("ID", "id"),
+	field("NAME", "name"),
+	field("TYPE", "type"),
+	field("BASE URL", "base_url"),
+	field("AUTH", "auth_type"),
+	field("CURRENCY", "currency"),
+	count("ENDPOINTS", "endpoints"),
+}
+
+// integrationsCommand manages integrations.
+func integrationsCommand(ctx context.Context, a *app, args []string) error {
+	return subcommand(ctx, a, "integrations", args, map[string]func(context.Context, *app, []string) error{
+		"list":   listIntegrations,
+		"get":    getIntegration,
+		"create": createIntegration,
+		"delete": deleteIntegration,
+	})
+}
+
+func listIntegrations(ctx context.Context, a *app, args []string) error {
+	if err := parseFlags(flag.NewFlagSet("list", flag.ContinueOnError), args, 0, "integrations list"); err != nil {
+		return err
+	}
+
+	var integrations []map[string]interface{}
+	if err := a.client.getJSON(ctx, "/integrations/", nil, &integrations); err != nil {
+		return err
+	}
+	return a.out.print(integrations, integrationColumns)
+}
+
+func getIntegration(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 1, "integrations get <id>"); err != nil {
+		return err
+	}
+
+	var integration map[string]interface{}
+	if err := a.client.getJSON(ctx, "/integrations/"+url.PathEscape(flags.Arg(0)), nil, &integration); err != nil {
+		return err
+	}
+	return a.out.print(integration, integrationColumns)
+}
+
+func createIntegration(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 1, "integrations create <file.json|file.yaml>\n\nThe file holds the body of POST /integrations."); err != nil {
+		return err
+	}
+
+	body, err := readBody(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var integration map[string]interface{}
+	if err := a.client.sendJSON(ctx, http.MethodPost, "/integrations/", body, &integration); err != nil {
+		return err
+	}
+	return a.out.print(integration, integrationColumns)
+}
+
+func deleteIntegration(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("delete", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 1, "integrations delete <id>"); err != nil {
+		return err
+	}
+
+	if err := a.client.doJSON(ctx, request{method: http.MethodDelete, path: "/integrations/" + url.PathEscape(flags.Arg(0))}, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out.w, "Integration %s deleted\n", flags.Arg(0))
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"generic-integration-platform/internal/application/lint"
+)
+
+func lintCommand(_ context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	strict := flags.Bool("strict", false, "fail on warnings too")
+	if err := parseFlags(flags, args, -1, "lint [-strict] <file or directory>...\n\nChecks integration declarations, such as payments.toml, and flow files without contacting the platform.\nLint flows together with the integrations they call, so that their actions can be checked."); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	files, err := lint.Files(flags.Args())
+	if err != nil {
+		return err
+	}
+	diagnostics := lint.Lint(files)
+
+	if a.out.format == outputJSON {
+		if diagnostics == nil {
+			diagnostics = []lint.Diagnostic{}
+		}
+		if err := a.out.print(diagnostics, nil); err != nil {
+			return err
+		}
+	} else {
+		for _, d := range diagnostics {
+			fmt.Fprintln(a.out.w, d)
+		}
+	}
+
+	var errs, warnings int
+	for _, d := range diagnostics {
+		if d.Severity == lint.SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+	if errs > 0 || (*strict && warnings > 0) {
+		return fmt.Errorf("%d error(s) and %d warning(s) found in %d file(s)", errs, warnings, len(files))
+	}
+	return nil
+}
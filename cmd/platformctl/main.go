@@ -0,0 +1,133 @@
+// Command platformctl manages a running platform through its REST API:
+//
+//	platformctl integrations list
+//	platformctl integrations create stripe.json
+//	platformctl flows execute -input payment.json 6650f1c2a9e8b1d4c3f2e1a0
+//	platformctl export -format toml -o bundle.toml
+//	platformctl import -conflict overwrite bundle.toml
+//	platformctl lint payments.toml flows/
+//
+// The API is reached at -url, or PLATFORM_URL, with the key of -api-key, or
+// API_KEY, sent in the x-api-key header. Results are printed as tables, or as
+// JSON with -o json. Lint works offline, on the files only.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// defaultURL is the address of a platform started with the default settings.
+const defaultURL = "http://localhost:8080"
+
+// errUsage reports invalid arguments, once the usage has been printed.
+var errUsage = errors.New("invalid arguments")
+
+// commands maps the name of each command to its implementation.
+var commands = map[string]func(ctx context.Context, app *app, args []string) error{
+	"integrations": integrationsCommand,
+	"flows":        flowsCommand,
+	"export":       exportCommand,
+	"import":       importCommand,
+	"lint":         lintCommand,
+}
+
+// app holds the settings shared by the commands.
+type app struct {
+	client *client
+	out    printer
+}
+
+func main() {
+	baseURL := flag.String("url", envOr("PLATFORM_URL", defaultURL), "address of the platform API (PLATFORM_URL)")
+	apiKey := flag.String("api-key", os.Getenv("API_KEY"), "API key sent in the x-api-key header (API_KEY)")
+	output := flag.String("o", outputTable, "output format: table or json")
+	flag.Usage = usage
+	flag.Parse()
+
+	command, ok := commands[flag.Arg(0)]
+	if !ok || (*output != outputTable && *output != outputJSON) {
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	a := &app{
+		client: newClient(*baseURL, *apiKey),
+		out:    printer{format: *output, w: os.Stdout},
+	}
+	if err := command(ctx, a, flag.Args()[1:]); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// usage prints the commands and the global flags.
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, `Usage: %s [flags] <command> [arguments]
+
+Commands:
+  integrations list|get|create|delete   manage integrations
+  flows list|get|create|delete|execute  manage and execute flows
+  export                                export integrations and flows as a bundle
+  import                                import a bundle
+  lint                                  check integration and flow files offline
+
+Run a command with -h for its arguments.
+
+Flags:
+`, os.Args[0])
+	flag.PrintDefaults()
+}
+
+// subcommand dispatches args to the action they name.
+func subcommand(ctx context.Context, a *app, name string, args []string, actions map[string]func(context.Context, *app, []string) error) error {
+	if len(args) > 0 {
+		if action, ok := actions[args[0]]; ok {
+			return action(ctx, a, args[1:])
+		}
+	}
+
+	names := make([]string, 0, len(actions))
+	for _, n := range []string{"list", "get", "create", "delete", "execute", "events"} {
+		if _, ok := actions[n]; ok {
+			names = append(names, n)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Usage: %s %s %s\n", os.Args[0], name, strings.Join(names, "|"))
+	return errUsage
+}
+
+// parseFlags parses the flags of an action expecting nargs arguments.
+func parseFlags(flags *flag.FlagSet, args []string, nargs int, usage string) error {
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s\n", os.Args[0], usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if nargs >= 0 && flags.NArg() != nargs {
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// envOr returns the environment variable name, or fallback when it is unset.
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
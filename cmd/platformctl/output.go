@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// column is a column of a table, read from the JSON fields of a row.
+type column struct {
+	header string
+	value  func(row map[string]interface{}) string
+}
+
+// field is a column showing a field of the rows.
+func field(header, name string) column {
+	return column{header: header, value: func(row map[string]interface{}) string {
+		if value, ok := row[name]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+		return ""
+	}}
+}
+
+// count is a column showing the number of items of a list field of the rows.
+func count(header, name string) column {
+	return column{header: header, value: func(row map[string]interface{}) string {
+		items, _ := row[name].([]interface{})
+		return fmt.Sprint(len(items))
+	}}
+}
+
+// printer writes results as tables or JSON.
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+// print writes v, a value or a list of values, as JSON or as a table with the
+// given columns.
+func (p printer) print(v interface{}, columns []column) error {
+	if p.format == outputJSON {
+		return p.json(v)
+	}
+
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(content, &rows); err != nil {
+		var row map[string]interface{}
+		if err := json.Unmarshal(content, &row); err != nil {
+			return err
+		}
+		rows = []map[string]interface{}{row}
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, c := range columns {
+			values[i] = c.value(row)
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+// json writes v as indented JSON.
+func (p printer) json(v interface{}) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// readBody reads a JSON or YAML file holding a request body and returns it
+// decoded, ready to be sent as JSON.
+func readBody(path string) (interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var body interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &body)
+	default:
+		err = json.Unmarshal(content, &body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return body, nil
+}
@@ -0,0 +1,63 @@
+// Command rotate-keys re-wraps the data keys of the stored credentials with
+// the active encryption master key.
+//
+// To rotate the master key, put the new key first in ENCRYPTION_MASTER_KEY (or
+// in the key file), keeping the previous key after it, restart the API, and run
+//
+//	go run ./cmd/rotate-keys -config config.toml
+//
+// Once it completes, the previous key can be removed. Credentials stored in
+// plaintext before encryption was enabled are encrypted as well.
+package main
+
+import (
+	"context"
+	"flag"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/encryption"
+	"generic-integration-platform/internal/infra/secrets"
+	"generic-integration-platform/internal/infra/storage"
+	"log"
+)
+
+func main() {
+	path := flag.String("config", "config.toml", "path of the configuration file")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*path)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	keyring, err := encryption.LoadKeyring(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load master keys: %v", err)
+	}
+	if keyring == nil {
+		log.Fatal("No encryption master key is configured")
+	}
+
+	if cfg.Storage.Backend == config.StorageMemory {
+		log.Fatal("The memory storage backend stores nothing to rotate")
+	}
+
+	stores, err := storage.Open(cfg, keyring)
+	if err != nil {
+		log.Fatalf("Failed to open the %s storage: %v", cfg.Storage.Backend, err)
+	}
+	ctx := context.Background()
+	defer stores.Close(ctx)
+
+	integrations, err := stores.Integrations.RotateKeys(ctx)
+	if err != nil {
+		log.Fatalf("Failed to rotate integration credentials after %d updates: %v", integrations, err)
+	}
+
+	store := secrets.NewStore(cfg, stores.Secrets, keyring)
+	stored, err := store.RotateKeys(ctx)
+	if err != nil {
+		log.Fatalf("Failed to rotate secrets after %d updates: %v", stored, err)
+	}
+
+	log.Printf("Rotated to master key %s: %d integrations and %d secrets updated", keyring.ActiveKeyID(), integrations, stored)
+}
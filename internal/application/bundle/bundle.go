@@ -0,0 +1,131 @@
+// Package bundle serializes integrations and flows into portable, versioned
+// TOML or YAML bundles used to promote configuration between environments.
+//
+// Bundles use the same layout as the declaration files: integrations as in
+// payments.toml and flows as in the flow directory, with steps referencing
+// their integration by name. Credentials are never exported: values that are
+// not secret references are replaced by a secret://<integration>/<field>
+// reference, to be provisioned in the target environment.
+package bundle
+
+import (
+	"fmt"
+	"generic-integration-platform/internal/domain/flow"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/secret"
+	"generic-integration-platform/internal/infra/config"
+)
+
+// Version is the version of the bundle format written by this release.
+const Version = 1
+
+// Bundle holds integrations and flows.
+type Bundle struct {
+	Version      int                      `mapstructure:"version" toml:"version" yaml:"version"`
+	Integrations []config.PaymentProvider `mapstructure:"integrations" toml:"integrations,omitempty" yaml:"integrations,omitempty"`
+	Flows        []config.FlowDefinition  `mapstructure:"flows" toml:"flows,omitempty" yaml:"flows,omitempty"`
+}
+
+// New creates a bundle of integrations and flows. Flows may only reference
+// integrations of the bundle.
+func New(integrations []*integration.Integration, flows []*flow.Flow) (*Bundle, error) {
+	b := &Bundle{Version: Version}
+
+	names := make(map[string]string, len(integrations))
+	for _, i := range integrations {
+		names[i.ID] = i.Name
+		b.Integrations = append(b.Integrations, withReferences(config.FromIntegration(i)))
+	}
+
+	for _, f := range flows {
+		definition, err := config.FromFlow(f, names)
+		if err != nil {
+			return nil, err
+		}
+		b.Flows = append(b.Flows, definition)
+	}
+
+	return b, nil
+}
+
+// Decode reads a bundle in the given format.
+func Decode(content []byte, format string) (*Bundle, error) {
+	var b Bundle
+	if err := config.Decode(content, format, &b); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if b.Version != Version {
+		return nil, fmt.Errorf("unsupported bundle version %d, expected %d", b.Version, Version)
+	}
+
+	return &b, nil
+}
+
+// Encode writes the bundle in the given format.
+func (b *Bundle) Encode(format string) ([]byte, error) {
+	return config.Encode(b, format)
+}
+
+// ToDomain maps the bundle to domain models. Flow steps reference their
+// integration by name.
+func (b *Bundle) ToDomain() ([]*integration.Integration, []*flow.Flow) {
+	integrations := make([]*integration.Integration, len(b.Integrations))
+	for i, p := range b.Integrations {
+		integrations[i] = p.ToDomain()
+	}
+
+	flows := make([]*flow.Flow, len(b.Flows))
+	for i, f := range b.Flows {
+		flows[i] = f.ToDomain()
+	}
+
+	return integrations, flows
+}
+
+// withReferences replaces the credentials of p that are not secret references.
+// Credentials of an environment are expected under secret://<integration>/<environment>_<field>.
+func withReferences(p config.PaymentProvider) config.PaymentProvider {
+	p.AuthToken = reference(p.Name, "auth_token", p.AuthToken)
+	p.Basic, p.OAuth, p.HMAC = credentialReferences(p.Name, "", p.Basic, p.OAuth, p.HMAC)
+
+	if p.Environments != nil {
+		environments := make(map[string]config.EnvironmentConfig, len(p.Environments))
+		for name, env := range p.Environments {
+			env.AuthToken = reference(p.Name, name+"_auth_token", env.AuthToken)
+			env.Basic, env.OAuth, env.HMAC = credentialReferences(p.Name, name+"_", env.Basic, env.OAuth, env.HMAC)
+			environments[name] = env
+		}
+		p.Environments = environments
+	}
+	return p
+}
+
+// credentialReferences returns copies of the credentials with their secrets
+// replaced by references, their fields prefixed with prefix.
+func credentialReferences(integrationName, prefix string, basic *config.BasicConfig, oauth *config.OAuthConfig, hmac *config.HMACConfig) (*config.BasicConfig, *config.OAuthConfig, *config.HMACConfig) {
+	if basic != nil {
+		b := *basic
+		b.Password = reference(integrationName, prefix+"basic_password", b.Password)
+		basic = &b
+	}
+	if oauth != nil {
+		o := *oauth
+		o.ClientSecret = reference(integrationName, prefix+"oauth_client_secret", o.ClientSecret)
+		oauth = &o
+	}
+	if hmac != nil {
+		h := *hmac
+		h.Secret = reference(integrationName, prefix+"hmac_secret", h.Secret)
+		hmac = &h
+	}
+	return basic, oauth, hmac
+}
+
+// reference returns value if it is empty or a secret reference, and the
+// reference of the secret store entry expected to hold it otherwise.
+func reference(integrationName, field, value string) string {
+	if value == "" || secret.IsReference(value) {
+		return value
+	}
+	return secret.SchemeSecret + "://" + integrationName + "/" + field
+}
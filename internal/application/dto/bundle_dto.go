@@ -0,0 +1,14 @@
+package dto
+
+// ImportResultDTO represents the outcome of a bundle import.
+type ImportResultDTO struct {
+	Integrations ImportChangesDTO `json:"integrations"` // Integrations changed by the import
+	Flows        ImportChangesDTO `json:"flows"`        // Flows changed by the import
+}
+
+// ImportChangesDTO lists, by name, what an import did.
+type ImportChangesDTO struct {
+	Created []string `json:"created"` // Names of the created items
+	Updated []string `json:"updated"` // Names of the overwritten items
+	Skipped []string `json:"skipped"` // Names of the existing items left untouched
+}
@@ -1,6 +1,10 @@
 package dto
 
-import "generic-integration-platform/internal/domain/flow"
+import (
+	"encoding/json"
+	"generic-integration-platform/internal/domain/flow"
+	"time"
+)
 
 // FlowDTO represents the Data Transfer Object for a Flow.
 type FlowDTO struct {
@@ -11,9 +15,34 @@ type FlowDTO struct {
 
 // StepDTO represents the Data Transfer Object for a step in a flow.
 type StepDTO struct {
-	Action        string            `json:"action"`         // Action to be performed in the step
-	IntegrationID string            `json:"integration_id"` // ID of the associated integration
-	Params        map[string]string `json:"params"`         // Parameters for the step
+	Name          string                 `json:"name,omitempty"`              // Name of the step, used to reference its outputs
+	Type          string                 `json:"type,omitempty"`              // Type of the step ("integration", "script" or "transform")
+	Action        string                 `json:"action"`                      // Action to be performed in the step
+	IntegrationID string                 `json:"integration_id"`              // ID of the associated integration
+	Integration   string                 `json:"integration,omitempty"`       // Name of the associated integration, when integration_id is empty
+	Params        map[string]interface{} `json:"params"`                      // Parameters for the step, strings being rendered as templates
+	ParamExprs    map[string]string      `json:"param_expressions,omitempty"` // Parameters computed by expressions, keyed by name
+	Script        string                 `json:"script,omitempty"`            // Lua source run by script steps
+	Transforms    map[string]string      `json:"transforms,omitempty"`        // jq expressions run by transform steps, keyed by output
+	Condition     string                 `json:"condition,omitempty"`         // Expression that must be true for the step to run
+	Success       string                 `json:"success,omitempty"`           // Expression over the step output that must be true for the step to succeed
+	NextStepID    string                 `json:"next,omitempty"`              // ID or name of the next step
+	Retries       int                    `json:"retries,omitempty"`           // Times the step is retried when it fails
+	RetryIf       string                 `json:"retry_if,omitempty"`          // Expression over the failure that must be true for the step to be retried
+}
+
+// ExecuteFlowDTO represents the request body for executing a flow.
+type ExecuteFlowDTO struct {
+	Input       map[string]interface{} `json:"input"`       // Input available to the steps as {{input.*}}
+	Environment string                 `json:"environment"` // Environment of the integrations, e.g. sandbox; defaults to the platform ENV
+}
+
+// FlowEventDTO represents an event of a flow, such as a completed step.
+type FlowEventDTO struct {
+	Version   uint64          `json:"version"`    // Position of the event in the flow stream, starting at 0
+	Type      string          `json:"type"`       // Type of the event, e.g. FlowStepCompletedEvent
+	Data      json.RawMessage `json:"data"`       // The event
+	CreatedAt time.Time       `json:"created_at"` // When the event was recorded
 }
 
 // ToDomain converts a FlowDTO to a Flow (domain).
@@ -32,18 +61,22 @@ func (f FlowDTO) ToDomain() *flow.Flow {
 
 // ToDomain converts a StepDTO to a Step (domain).
 func (s StepDTO) ToDomain() *flow.Step {
-	params := make(map[string]interface{}, len(s.Params))
-	for key, value := range s.Params {
-		params[key] = value
-	}
-
 	return &flow.Step{
 		ID:            "", // The ID can be generated by the domain or the database.
-		Name:          "", // This can be optional based on the domain.
+		Name:          s.Name,
+		Type:          s.Type,
 		IntegrationID: s.IntegrationID,
+		Integration:   s.Integration,
 		Action:        s.Action,
-		Params:        params,
-		NextStepID:    "", // Can be assigned based on the domain logic.
+		Params:        s.Params,
+		ParamExprs:    s.ParamExprs,
+		Script:        s.Script,
+		Transforms:    s.Transforms,
+		Condition:     s.Condition,
+		Success:       s.Success,
+		NextStepID:    s.NextStepID,
+		Retries:       s.Retries,
+		RetryIf:       s.RetryIf,
 	}
 }
 
@@ -63,15 +96,20 @@ func FromFlowDomain(flow *flow.Flow) FlowDTO {
 
 // FromStepDomain converts a Step (domain) to a StepDTO.
 func FromStepDomain(step *flow.Step) StepDTO {
-	params := make(map[string]string, len(step.Params))
-	for key, value := range step.Params {
-		// Convert the parameters to strings for the DTO.
-		params[key] = value.(string) // Ensure proper type conversion.
-	}
-
 	return StepDTO{
+		Name:          step.Name,
+		Type:          step.Type,
 		Action:        step.Action,
 		IntegrationID: step.IntegrationID,
-		Params:        params,
+		Integration:   step.Integration,
+		Params:        step.Params,
+		ParamExprs:    step.ParamExprs,
+		Script:        step.Script,
+		Transforms:    step.Transforms,
+		Condition:     step.Condition,
+		Success:       step.Success,
+		NextStepID:    step.NextStepID,
+		Retries:       step.Retries,
+		RetryIf:       step.RetryIf,
 	}
 }
@@ -3,7 +3,9 @@ package dto
 import (
 	"generic-integration-platform/internal/domain/endpoint"
 	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/secret"
 	"strings"
+	"time"
 )
 
 // IntegrationDTO represents the Data Transfer Object for an Integration.
@@ -28,13 +30,328 @@ type EndpointDTO struct {
 
 // IntegrationRequestDTO represents the request body for creating a new integration.
 type IntegrationRequestDTO struct {
-	Name      string                `json:"name" binding:"required"`         // Name of the integration
-	Type      string                `json:"type" binding:"required"`         // Type of integration (e.g., REST, gRPC)
-	BaseURL   string                `json:"base_url" binding:"required,url"` // Base URL for API requests
-	AuthType  string                `json:"auth_type"`                       // Type of authentication (e.g., Bearer, Basic)
-	AuthToken string                `json:"auth_token,omitempty"`            // The authentication token (optional)
-	Currency  string                `json:"currency" binding:"required"`     // Currency for transactions
-	Endpoints []*EndpointRequestDTO `json:"endpoints" binding:"required"`    // List of endpoints associated with this integration
+	Name         string                     `json:"name" binding:"required"`         // Name of the integration
+	Type         string                     `json:"type" binding:"required"`         // Type of integration (e.g., REST, gRPC)
+	BaseURL      string                     `json:"base_url" binding:"required,url"` // Base URL for API requests
+	AuthType     string                     `json:"auth_type"`                       // Type of authentication (e.g., Bearer, Basic)
+	AuthHeader   string                     `json:"auth_header,omitempty"`           // Header carrying the credentials (optional)
+	AuthToken    string                     `json:"auth_token,omitempty"`            // The token or API key, preferably a secret reference such as "env://STRIPE_KEY" (optional)
+	AuthParam    string                     `json:"auth_param,omitempty"`            // Query parameter carrying the API key (optional)
+	Basic        *BasicDTO                  `json:"basic,omitempty"`                 // HTTP Basic credentials (optional)
+	OAuth        *OAuthDTO                  `json:"oauth,omitempty"`                 // OAuth2 client credentials (optional)
+	HMAC         *HMACDTO                   `json:"hmac,omitempty"`                  // Request signing settings (optional)
+	TLS          *TLSDTO                    `json:"tls,omitempty"`                   // Client certificate and trusted CAs (optional)
+	Credentials  *CredentialsDTO            `json:"credentials,omitempty"`           // Primary and secondary credentials while a key is rotated (optional)
+	Headers      map[string]string          `json:"headers,omitempty"`               // Headers sent with every request (optional)
+	Environments map[string]*EnvironmentDTO `json:"environments,omitempty"`          // Settings overridden per environment, e.g. sandbox and live (optional)
+	Currency     string                     `json:"currency" binding:"required"`     // Currency for transactions
+	Endpoints    []*EndpointRequestDTO      `json:"endpoints" binding:"required"`    // List of endpoints associated with this integration
+}
+
+// EnvironmentDTO represents the settings an integration overrides in an
+// environment. Empty fields keep the settings of the integration.
+type EnvironmentDTO struct {
+	BaseURL   string            `json:"base_url,omitempty"`   // Base URL in this environment
+	AuthToken string            `json:"auth_token,omitempty"` // Token or API key; only secret references are returned in responses
+	Basic     *BasicDTO         `json:"basic,omitempty"`      // HTTP Basic credentials in this environment
+	OAuth     *OAuthDTO         `json:"oauth,omitempty"`      // OAuth2 client credentials in this environment
+	HMAC      *HMACDTO          `json:"hmac,omitempty"`       // Request signing settings in this environment
+	Headers   map[string]string `json:"headers,omitempty"`    // Headers added to, or replacing, the headers of the integration
+	TLS       *TLSDTO           `json:"tls,omitempty"`        // Client certificate and trusted CAs in this environment
+}
+
+// ToDomain maps EnvironmentDTO to the Environment domain model.
+func (dto *EnvironmentDTO) ToDomain() *integration.Environment {
+	if dto == nil {
+		return nil
+	}
+
+	return &integration.Environment{
+		BaseURL:   dto.BaseURL,
+		AuthToken: dto.AuthToken,
+		Basic:     dto.Basic.ToDomain(),
+		OAuth:     dto.OAuth.ToDomain(),
+		HMAC:      dto.HMAC.ToDomain(),
+		Headers:   dto.Headers,
+		TLS:       dto.TLS.ToDomain(),
+	}
+}
+
+// toEnvironmentsDomain maps the environments of a request to the domain model.
+func toEnvironmentsDomain(environments map[string]*EnvironmentDTO) map[string]*integration.Environment {
+	if environments == nil {
+		return nil
+	}
+
+	result := make(map[string]*integration.Environment, len(environments))
+	for name, env := range environments {
+		result[name] = env.ToDomain()
+	}
+	return result
+}
+
+// FromEnvironmentsDomain maps the Environment domain models to EnvironmentDTOs,
+// keeping the secrets only if they are secret references.
+func FromEnvironmentsDomain(environments map[string]*integration.Environment) map[string]*EnvironmentDTO {
+	if environments == nil {
+		return nil
+	}
+
+	result := make(map[string]*EnvironmentDTO, len(environments))
+	for name, env := range environments {
+		if env == nil {
+			continue
+		}
+		result[name] = &EnvironmentDTO{
+			BaseURL:   env.BaseURL,
+			AuthToken: secret.Redact(env.AuthToken),
+			Basic:     FromBasicDomain(env.Basic),
+			OAuth:     FromOAuthDomain(env.OAuth),
+			HMAC:      FromHMACDomain(env.HMAC),
+			Headers:   env.Headers,
+			TLS:       FromTLSDomain(env.TLS),
+		}
+	}
+	return result
+}
+
+// BasicDTO represents the HTTP Basic credentials of an integration.
+type BasicDTO struct {
+	Username string `json:"username"`           // The username
+	Password string `json:"password,omitempty"` // The password; only secret references are returned in responses
+}
+
+// ToDomain maps BasicDTO to the Basic domain model.
+func (dto *BasicDTO) ToDomain() *integration.Basic {
+	if dto == nil {
+		return nil
+	}
+
+	return &integration.Basic{Username: dto.Username, Password: dto.Password}
+}
+
+// FromBasicDomain maps the Basic domain model to a BasicDTO, keeping the
+// password only if it is a secret reference.
+func FromBasicDomain(b *integration.Basic) *BasicDTO {
+	if b == nil {
+		return nil
+	}
+
+	return &BasicDTO{Username: b.Username, Password: secret.Redact(b.Password)}
+}
+
+// CredentialsDTO represents the primary and secondary credentials of an
+// integration while a key is rotated.
+type CredentialsDTO struct {
+	Primary   *CredentialDTO `json:"primary,omitempty"`   // The current credential
+	Secondary *CredentialDTO `json:"secondary,omitempty"` // The previous credential
+}
+
+// CredentialDTO represents a credential and its validity window.
+type CredentialDTO struct {
+	Value       string     `json:"value,omitempty"`        // The credential; only secret references are returned in responses
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`  // When the provider starts accepting it
+	ActiveUntil *time.Time `json:"active_until,omitempty"` // When the provider stops accepting it
+}
+
+// RotateCredentialsDTO represents the request body for rotating the credential of an integration.
+type RotateCredentialsDTO struct {
+	Value               string     `json:"value" binding:"required"`        // The new credential, preferably a secret reference
+	ActiveFrom          *time.Time `json:"active_from,omitempty"`           // When the new credential becomes active (default now)
+	PreviousActiveUntil *time.Time `json:"previous_active_until,omitempty"` // Until when the previous credential is still used (default 24 hours after active_from)
+}
+
+// ToDomain maps CredentialsDTO to the Credentials domain model.
+func (dto *CredentialsDTO) ToDomain() *integration.Credentials {
+	if dto == nil {
+		return nil
+	}
+
+	return &integration.Credentials{
+		Primary:   dto.Primary.ToDomain(),
+		Secondary: dto.Secondary.ToDomain(),
+	}
+}
+
+// ToDomain maps CredentialDTO to the Credential domain model.
+func (dto *CredentialDTO) ToDomain() *integration.Credential {
+	if dto == nil {
+		return nil
+	}
+
+	c := &integration.Credential{Value: dto.Value}
+	if dto.ActiveFrom != nil {
+		c.ActiveFrom = *dto.ActiveFrom
+	}
+	if dto.ActiveUntil != nil {
+		c.ActiveUntil = *dto.ActiveUntil
+	}
+	return c
+}
+
+// FromCredentialsDomain maps the Credentials domain model to a CredentialsDTO,
+// keeping the values only if they are secret references.
+func FromCredentialsDomain(c *integration.Credentials) *CredentialsDTO {
+	if c == nil {
+		return nil
+	}
+
+	return &CredentialsDTO{
+		Primary:   fromCredentialDomain(c.Primary),
+		Secondary: fromCredentialDomain(c.Secondary),
+	}
+}
+
+// fromCredentialDomain maps the Credential domain model to a CredentialDTO.
+func fromCredentialDomain(c *integration.Credential) *CredentialDTO {
+	if c == nil {
+		return nil
+	}
+
+	dto := &CredentialDTO{Value: secret.Redact(c.Value)}
+	if !c.ActiveFrom.IsZero() {
+		activeFrom := c.ActiveFrom
+		dto.ActiveFrom = &activeFrom
+	}
+	if !c.ActiveUntil.IsZero() {
+		activeUntil := c.ActiveUntil
+		dto.ActiveUntil = &activeUntil
+	}
+	return dto
+}
+
+// OAuthDTO represents the OAuth2 client credentials of an integration.
+type OAuthDTO struct {
+	ClientID     string   `json:"client_id"`               // Client identifier issued by the provider
+	ClientSecret string   `json:"client_secret,omitempty"` // Client secret; only secret references are returned in responses
+	TokenURL     string   `json:"token_url"`               // Endpoint issuing access tokens
+	Scopes       []string `json:"scopes,omitempty"`        // Scopes requested with the token
+	AuthStyle    string   `json:"auth_style,omitempty"`    // "header" (HTTP Basic, default) or "params"
+}
+
+// HMACDTO represents the request signing settings of an integration.
+type HMACDTO struct {
+	Secret          string `json:"secret,omitempty"`           // Signing key; only secret references are returned in responses
+	KeyID           string `json:"key_id,omitempty"`           // Identifier of the key
+	Algorithm       string `json:"algorithm,omitempty"`        // "sha256" (default), "sha512" or "sha1"
+	Encoding        string `json:"encoding,omitempty"`         // "hex" (default) or "base64"
+	Canonical       string `json:"canonical,omitempty"`        // Template of the signed string
+	Header          string `json:"header,omitempty"`           // Header carrying the signature
+	HeaderFormat    string `json:"header_format,omitempty"`    // Template of the signature header value
+	TimestampHeader string `json:"timestamp_header,omitempty"` // Header carrying the timestamp
+	TimestampFormat string `json:"timestamp_format,omitempty"` // "unix" (default), "unix_ms" or "rfc3339"
+	NonceHeader     string `json:"nonce_header,omitempty"`     // Header carrying the nonce
+}
+
+// TLSDTO represents the transport security settings of an integration.
+type TLSDTO struct {
+	CertFile   string   `json:"cert_file,omitempty"`   // Path of the PEM client certificate
+	KeyFile    string   `json:"key_file,omitempty"`    // Path of the PEM private key
+	CAFiles    []string `json:"ca_files,omitempty"`    // Paths of the PEM root CAs to trust
+	MinVersion string   `json:"min_version,omitempty"` // "1.2" (default) or "1.3"
+	ServerName string   `json:"server_name,omitempty"` // Server name override
+}
+
+// ToDomain maps TLSDTO to the TLS domain model.
+func (dto *TLSDTO) ToDomain() *integration.TLS {
+	if dto == nil {
+		return nil
+	}
+
+	return &integration.TLS{
+		CertFile:   dto.CertFile,
+		KeyFile:    dto.KeyFile,
+		CAFiles:    dto.CAFiles,
+		MinVersion: dto.MinVersion,
+		ServerName: dto.ServerName,
+	}
+}
+
+// FromTLSDomain maps the TLS domain model to a TLSDTO.
+func FromTLSDomain(t *integration.TLS) *TLSDTO {
+	if t == nil {
+		return nil
+	}
+
+	return &TLSDTO{
+		CertFile:   t.CertFile,
+		KeyFile:    t.KeyFile,
+		CAFiles:    t.CAFiles,
+		MinVersion: t.MinVersion,
+		ServerName: t.ServerName,
+	}
+}
+
+// ToDomain maps HMACDTO to the HMAC domain model.
+func (dto *HMACDTO) ToDomain() *integration.HMAC {
+	if dto == nil {
+		return nil
+	}
+
+	return &integration.HMAC{
+		Secret:          dto.Secret,
+		KeyID:           dto.KeyID,
+		Algorithm:       dto.Algorithm,
+		Encoding:        dto.Encoding,
+		Canonical:       dto.Canonical,
+		Header:          dto.Header,
+		HeaderFormat:    dto.HeaderFormat,
+		TimestampHeader: dto.TimestampHeader,
+		TimestampFormat: dto.TimestampFormat,
+		NonceHeader:     dto.NonceHeader,
+	}
+}
+
+// FromHMACDomain maps the HMAC domain model to an HMACDTO, keeping the secret
+// only if it is a secret reference.
+func FromHMACDomain(h *integration.HMAC) *HMACDTO {
+	if h == nil {
+		return nil
+	}
+
+	return &HMACDTO{
+		Secret:          secret.Redact(h.Secret),
+		KeyID:           h.KeyID,
+		Algorithm:       h.Algorithm,
+		Encoding:        h.Encoding,
+		Canonical:       h.Canonical,
+		Header:          h.Header,
+		HeaderFormat:    h.HeaderFormat,
+		TimestampHeader: h.TimestampHeader,
+		TimestampFormat: h.TimestampFormat,
+		NonceHeader:     h.NonceHeader,
+	}
+}
+
+// ToDomain maps OAuthDTO to the OAuth domain model.
+func (dto *OAuthDTO) ToDomain() *integration.OAuth {
+	if dto == nil {
+		return nil
+	}
+
+	return &integration.OAuth{
+		ClientID:     dto.ClientID,
+		ClientSecret: dto.ClientSecret,
+		TokenURL:     dto.TokenURL,
+		Scopes:       dto.Scopes,
+		AuthStyle:    dto.AuthStyle,
+	}
+}
+
+// FromOAuthDomain maps the OAuth domain model to an OAuthDTO, keeping the
+// client secret only if it is a secret reference.
+func FromOAuthDomain(oauth *integration.OAuth) *OAuthDTO {
+	if oauth == nil {
+		return nil
+	}
+
+	return &OAuthDTO{
+		ClientID:     oauth.ClientID,
+		ClientSecret: secret.Redact(oauth.ClientSecret),
+		TokenURL:     oauth.TokenURL,
+		Scopes:       oauth.Scopes,
+		AuthStyle:    oauth.AuthStyle,
+	}
 }
 
 // EndpointRequestDTO represents the request body for defining an endpoint in an integration.
@@ -58,13 +375,22 @@ func (dto IntegrationRequestDTO) ToDomain() integration.Integration {
 	}
 
 	return integration.Integration{
-		Name:      dto.Name,
-		Type:      dto.Type,
-		BaseURL:   dto.BaseURL,
-		AuthType:  dto.AuthType,
-		AuthToken: dto.AuthToken,
-		Currency:  dto.Currency,
-		Endpoints: endpoints,
+		Name:         dto.Name,
+		Type:         dto.Type,
+		BaseURL:      dto.BaseURL,
+		AuthType:     dto.AuthType,
+		AuthHeader:   dto.AuthHeader,
+		AuthToken:    dto.AuthToken,
+		AuthParam:    dto.AuthParam,
+		Basic:        dto.Basic.ToDomain(),
+		OAuth:        dto.OAuth.ToDomain(),
+		HMAC:         dto.HMAC.ToDomain(),
+		TLS:          dto.TLS.ToDomain(),
+		Credentials:  dto.Credentials.ToDomain(),
+		Headers:      dto.Headers,
+		Environments: toEnvironmentsDomain(dto.Environments),
+		Currency:     dto.Currency,
+		Endpoints:    endpoints,
 	}
 }
 
@@ -80,13 +406,22 @@ func ToResponseDTO(integration integration.Integration) IntegrationResponseDTO {
 	}
 
 	return IntegrationResponseDTO{
-		ID:        integration.ID,
-		Name:      integration.Name,
-		Type:      integration.Type,
-		BaseURL:   integration.BaseURL,
-		AuthType:  integration.AuthType,
-		Currency:  integration.Currency,
-		Endpoints: endpoints,
+		ID:           integration.ID,
+		Name:         integration.Name,
+		Type:         integration.Type,
+		BaseURL:      integration.BaseURL,
+		AuthType:     integration.AuthType,
+		AuthToken:    secret.Redact(integration.AuthToken),
+		AuthParam:    integration.AuthParam,
+		Basic:        FromBasicDomain(integration.Basic),
+		OAuth:        FromOAuthDomain(integration.OAuth),
+		HMAC:         FromHMACDomain(integration.HMAC),
+		TLS:          FromTLSDomain(integration.TLS),
+		Credentials:  FromCredentialsDomain(integration.Credentials),
+		Headers:      integration.Headers,
+		Environments: FromEnvironmentsDomain(integration.Environments),
+		Currency:     integration.Currency,
+		Endpoints:    endpoints,
 	}
 }
 
@@ -124,13 +459,22 @@ func parseHeaders(headers string) map[string]string {
 
 // IntegrationResponseDTO represents the response body for an integration.
 type IntegrationResponseDTO struct {
-	ID        string                 `json:"id"`        // Unique identifier for the integration
-	Name      string                 `json:"name"`      // Name of the integration
-	Type      string                 `json:"type"`      // Type of integration (e.g., REST, gRPC)
-	BaseURL   string                 `json:"base_url"`  // Base URL for API requests
-	AuthType  string                 `json:"auth_type"` // Type of authentication (e.g., Bearer, Basic)
-	Currency  string                 `json:"currency"`  // Currency for transactions
-	Endpoints []*EndpointResponseDTO `json:"endpoints"` // List of endpoints associated with this integration
+	ID           string                     `json:"id"`                     // Unique identifier for the integration
+	Name         string                     `json:"name"`                   // Name of the integration
+	Type         string                     `json:"type"`                   // Type of integration (e.g., REST, gRPC)
+	BaseURL      string                     `json:"base_url"`               // Base URL for API requests
+	AuthType     string                     `json:"auth_type"`              // Type of authentication (e.g., Bearer, Basic)
+	AuthToken    string                     `json:"auth_token,omitempty"`   // Secret reference of the token, never its value
+	AuthParam    string                     `json:"auth_param,omitempty"`   // Query parameter carrying the API key
+	Basic        *BasicDTO                  `json:"basic,omitempty"`        // HTTP Basic username, without the password
+	OAuth        *OAuthDTO                  `json:"oauth,omitempty"`        // OAuth2 client configuration, without the secret
+	HMAC         *HMACDTO                   `json:"hmac,omitempty"`         // Request signing settings, without the secret
+	TLS          *TLSDTO                    `json:"tls,omitempty"`          // Client certificate and trusted CAs
+	Credentials  *CredentialsDTO            `json:"credentials,omitempty"`  // Rotated credentials, without their values
+	Headers      map[string]string          `json:"headers,omitempty"`      // Headers sent with every request
+	Environments map[string]*EnvironmentDTO `json:"environments,omitempty"` // Settings overridden per environment, without secrets
+	Currency     string                     `json:"currency"`               // Currency for transactions
+	Endpoints    []*EndpointResponseDTO     `json:"endpoints"`              // List of endpoints associated with this integration
 }
 
 // EndpointResponseDTO represents the response body for an endpoint in an integration.
@@ -177,13 +521,22 @@ func FromDomain(integration integration.Integration) IntegrationResponseDTO {
 	}
 
 	return IntegrationResponseDTO{
-		ID:        integration.ID,
-		Name:      integration.Name,
-		Type:      integration.Type,
-		BaseURL:   integration.BaseURL,
-		AuthType:  integration.AuthType,
-		Currency:  integration.Currency,
-		Endpoints: endpoints,
+		ID:           integration.ID,
+		Name:         integration.Name,
+		Type:         integration.Type,
+		BaseURL:      integration.BaseURL,
+		AuthType:     integration.AuthType,
+		AuthToken:    secret.Redact(integration.AuthToken),
+		AuthParam:    integration.AuthParam,
+		Basic:        FromBasicDomain(integration.Basic),
+		OAuth:        FromOAuthDomain(integration.OAuth),
+		HMAC:         FromHMACDomain(integration.HMAC),
+		TLS:          FromTLSDomain(integration.TLS),
+		Credentials:  FromCredentialsDomain(integration.Credentials),
+		Headers:      integration.Headers,
+		Environments: FromEnvironmentsDomain(integration.Environments),
+		Currency:     integration.Currency,
+		Endpoints:    endpoints,
 	}
 }
 
@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+// SecretRequestDTO represents the request body for storing a secret.
+type SecretRequestDTO struct {
+	Value string `json:"value" binding:"required"` // The secret value, encrypted before it is stored
+}
+
+// SecretResponseDTO describes a stored secret. The value is never returned.
+type SecretResponseDTO struct {
+	Name      string    `json:"name"`       // Name of the secret
+	Reference string    `json:"reference"`  // Reference to use in integrations, e.g. "secret://stripe/live-key"
+	UpdatedAt time.Time `json:"updated_at"` // When the value was last written
+}
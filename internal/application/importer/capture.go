@@ -0,0 +1,410 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"generic-integration-platform/internal/domain/endpoint"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/template"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CaptureOptions selects what is imported from captured requests, such as a
+// Postman collection or a HAR file.
+type CaptureOptions struct {
+	Name     string // Integration name, the collection name or the host by default
+	BaseURL  string // Base URL of the provider, the scheme and host of the first request by default
+	Currency string // Currency of the integration, "USD" by default
+}
+
+// capturedRequest is a request sent to the provider, as recorded by a tool.
+type capturedRequest struct {
+	Name     string          // Name given to the request, if any
+	Method   string          // HTTP method
+	URL      *url.URL        // Absolute URL, with its query string
+	Headers  []capturedField // Headers in the recorded order
+	Body     string          // Raw request body
+	Response string          // Raw response body, if recorded
+}
+
+// capturedField is a header or query parameter of a captured request.
+type capturedField struct {
+	Name  string
+	Value string
+}
+
+// credentialPattern matches the names of headers and query parameters
+// carrying credentials.
+var credentialPattern = regexp.MustCompile(`(?i)auth|token|secret|passw|api[-_]?key|session|cookie|signature`)
+
+// transportHeaders are set by HTTP clients and browsers, never imported.
+var transportHeaders = map[string]bool{
+	"Host":            true,
+	"Connection":      true,
+	"Content-Length":  true,
+	"Accept-Encoding": true,
+	"Accept-Language": true,
+	"User-Agent":      true,
+	"Origin":          true,
+	"Referer":         true,
+	"Cache-Control":   true,
+	"Pragma":          true,
+	"Postman-Token":   true,
+}
+
+// perRequestHeaderPattern matches the names of headers whose value changes
+// with every request, such as idempotency keys, request IDs and dates.
+var perRequestHeaderPattern = regexp.MustCompile(`(?i)^(date|x-date)$|idempotency|request-?id|correlation-?id|trace|nonce|timestamp`)
+
+// uuidPattern matches UUIDs, which header values only hold when they identify
+// a single request.
+var uuidPattern = regexp.MustCompile(`(?i)^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// identifierPattern matches path segments holding identifiers rather than
+// resource names: numbers, and UUIDs or provider IDs such as pi_3MtwBwLkdIwHu7ix
+// of at least 8 characters including a digit, unlike versions such as v1.
+var identifierPattern = regexp.MustCompile(`^(\d+|[\w-]*\d[\w-]*)$`)
+
+// minIdentifierLength is the minimum length of identifiers that are not numbers.
+const minIdentifierLength = 8
+
+// placeholderSegmentPattern matches the placeholder segments of a path.
+var placeholderSegmentPattern = regexp.MustCompile(`/{{[^{}]*}}`)
+
+// fromCaptures creates a REST integration from captured requests. Requests
+// to another host than the base URL are skipped, and repeated requests to
+// the same endpoint are imported once. The literal values of the query
+// string, of the JSON or form body, the identifiers of the path and the
+// headers of a single request, such as Idempotency-Key, become {{input.*}}
+// placeholders, and the keys of the JSON response become response mappings.
+// Credential headers and query parameters are stripped; the first one found,
+// unless auth is set, selects the authentication of the integration, its
+// secret referenced as secret://<name>/<field>.
+func fromCaptures(requests []capturedRequest, defaultName string, auth *integration.Integration, opts CaptureOptions) (*integration.Integration, error) {
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%w: no request to import", ErrInvalidDocument)
+	}
+
+	base, err := captureBase(requests, opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &integration.Integration{
+		Name:     opts.Name,
+		Type:     "rest",
+		BaseURL:  strings.TrimRight(base.String(), "/"),
+		Currency: opts.Currency,
+	}
+	if i.Name == "" {
+		i.Name = defaultName
+	}
+	if i.Name == "" {
+		i.Name = base.Hostname()
+	}
+	if i.Currency == "" {
+		i.Currency = defaultCurrency
+	}
+	if auth != nil {
+		i.AuthType = auth.AuthType
+		i.AuthHeader = auth.AuthHeader
+		i.AuthParam = auth.AuthParam
+		i.Basic = auth.Basic
+		i.OAuth = auth.OAuth
+	}
+
+	for _, r := range requests {
+		if !strings.EqualFold(r.URL.Host, base.Host) || !strings.HasPrefix(r.URL.Path, base.Path) {
+			continue
+		}
+
+		ep := &endpoint.Endpoint{
+			Method: strings.ToUpper(r.Method),
+			Path:   templatePath(strings.TrimPrefix(r.URL.Path, strings.TrimRight(base.Path, "/"))),
+			Params: make(map[string]string),
+		}
+
+		// Only GET requests carry their params in the query string.
+		var query []string
+		for _, q := range queryFields(r.URL) {
+			if credentialPattern.MatchString(q.Name) {
+				if i.AuthType == "" {
+					i.AuthType = integration.AuthTypeQueryKey
+					i.AuthParam = q.Name
+				}
+				continue
+			}
+			if ep.Method == http.MethodGet {
+				ep.Params[q.Name] = placeholder(q.Name)
+			} else {
+				query = append(query, url.QueryEscape(q.Name)+"="+placeholder(q.Name))
+			}
+		}
+		if len(query) > 0 {
+			ep.Path += "?" + strings.Join(query, "&")
+		}
+
+		for _, h := range r.Headers {
+			name := http.CanonicalHeaderKey(h.Name)
+			if strings.HasPrefix(h.Name, ":") || strings.HasPrefix(name, "Sec-") || transportHeaders[name] || reservedHeaders[name] && name != "Authorization" {
+				continue
+			}
+			if name == "Authorization" || credentialPattern.MatchString(name) {
+				if i.AuthType == "" {
+					detectHeaderAuth(i, name, h.Value)
+				}
+				continue
+			}
+			// Header values are usually constants such as API versions, but
+			// those of a single request must be sent by every step.
+			if ep.Headers == nil {
+				ep.Headers = make(map[string]string)
+			}
+			ep.Headers[h.Name] = h.Value
+			if perRequestHeaderPattern.MatchString(name) || uuidPattern.MatchString(strings.TrimSpace(h.Value)) {
+				ep.Headers[h.Name] = placeholder(actionName(name))
+			}
+		}
+
+		for _, field := range bodyFields(r.Body) {
+			ep.Params[field] = placeholder(field)
+		}
+		for _, field := range jsonFields(r.Response) {
+			if ep.ResponseMappings == nil {
+				ep.ResponseMappings = make(map[string]string)
+			}
+			ep.ResponseMappings[field] = "{{response." + field + "}}"
+		}
+
+		addCapturedEndpoint(i, ep, r.Name)
+	}
+
+	if len(i.Endpoints) == 0 {
+		return nil, fmt.Errorf("%w: no request to %s", ErrInvalidDocument, i.BaseURL)
+	}
+
+	switch i.AuthType {
+	case "":
+		i.AuthType = integration.AuthTypeNone
+	case integration.AuthTypeToken, integration.AuthTypeQueryKey:
+		i.AuthToken = secretReference(i.Name, "auth_token")
+	case integration.AuthTypeBasic:
+		i.Basic.Password = secretReference(i.Name, "basic_password")
+	case integration.AuthTypeOAuth:
+		i.OAuth.ClientSecret = secretReference(i.Name, "oauth_client_secret")
+	}
+
+	if err := i.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	return i, nil
+}
+
+// captureBase returns the base URL of the integration. By default, it is the
+// scheme and host of the first request, followed by the path segments that
+// precede the last segment of every request to that host, such as /v1.
+func captureBase(requests []capturedRequest, baseURL string) (*url.URL, error) {
+	if baseURL == "" {
+		first := requests[0].URL
+		var prefix []string
+		for n, r := range requests {
+			if !strings.EqualFold(r.URL.Host, first.Host) {
+				continue
+			}
+			segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+			segments = segments[:len(segments)-1]
+			if n == 0 {
+				prefix = segments
+				continue
+			}
+			common := 0
+			for common < len(prefix) && common < len(segments) && prefix[common] == segments[common] && !isIdentifier(segments[common]) {
+				common++
+			}
+			prefix = prefix[:common]
+		}
+		for n, segment := range prefix {
+			if isIdentifier(segment) || strings.HasPrefix(segment, "{{") {
+				prefix = prefix[:n]
+				break
+			}
+		}
+
+		base := &url.URL{Scheme: first.Scheme, Host: first.Host}
+		if len(prefix) > 0 {
+			base.Path = "/" + strings.Join(prefix, "/")
+		}
+		return base, nil
+	}
+
+	base, err := url.Parse(baseURL)
+	if err != nil || !base.IsAbs() {
+		return nil, fmt.Errorf("%w: base URL %q is not absolute", ErrInvalidDocument, baseURL)
+	}
+	return base, nil
+}
+
+// detectHeaderAuth sets the authentication of i from a credential header.
+// The username of basic credentials is kept, their password is not.
+func detectHeaderAuth(i *integration.Integration, name, value string) {
+	scheme, credential, _ := strings.Cut(value, " ")
+	switch {
+	case name == "Authorization" && strings.EqualFold(scheme, "basic"):
+		username := ""
+		if decoded, err := base64.StdEncoding.DecodeString(credential); err == nil {
+			username, _, _ = strings.Cut(string(decoded), ":")
+		}
+		i.AuthType = integration.AuthTypeBasic
+		i.Basic = &integration.Basic{Username: username}
+	case name == "Cookie":
+		// Browser sessions cannot be replayed by the platform.
+	default:
+		i.AuthType = integration.AuthTypeToken
+		i.AuthHeader = name
+	}
+}
+
+// addCapturedEndpoint adds ep to i, named after the request or its method
+// and path. Repeated requests to the same endpoint are merged, so that the
+// endpoint has every field sent or received.
+func addCapturedEndpoint(i *integration.Integration, ep *endpoint.Endpoint, name string) {
+	action := actionName(name)
+	if action == "" {
+		action = operationAction(ep.Method, placeholderSegmentPattern.ReplaceAllString(strings.SplitN(ep.Path, "?", 2)[0], ""))
+	}
+
+	ep.Action = action
+	for n := 2; ; n++ {
+		existing := findEndpoint(i, ep.Action)
+		if existing == nil {
+			break
+		}
+		if existing.Method == ep.Method && existing.Path == ep.Path {
+			existing.Params = merge(existing.Params, ep.Params)
+			existing.Headers = merge(existing.Headers, ep.Headers)
+			existing.ResponseMappings = merge(existing.ResponseMappings, ep.ResponseMappings)
+			return
+		}
+		ep.Action = action + "_" + strconv.Itoa(n)
+	}
+	i.AddEndpoint(ep)
+}
+
+// merge adds the entries of src missing from dst.
+func merge(dst, src map[string]string) map[string]string {
+	for key, value := range src {
+		if dst == nil {
+			dst = make(map[string]string, len(src))
+		}
+		if _, ok := dst[key]; !ok {
+			dst[key] = value
+		}
+	}
+	return dst
+}
+
+// findEndpoint returns the endpoint of i for action, or nil.
+func findEndpoint(i *integration.Integration, action string) *endpoint.Endpoint {
+	for _, ep := range i.Endpoints {
+		if ep.Action == action {
+			return ep
+		}
+	}
+	return nil
+}
+
+// actionName turns a request name such as "Create payment" into an action
+// name such as create_payment.
+func actionName(name string) string {
+	return strings.Trim(strings.ToLower(invalidPlaceholderChars.ReplaceAllString(strings.ReplaceAll(name, "-", " "), "_")), "_")
+}
+
+// templatePath replaces the identifiers of a path with placeholders named
+// after the segment before them, e.g. /payments/pi_123/capture becomes
+// /payments/{{input.payments_id}}/capture. Variables such as {{id}} become
+// {{input.id}}.
+func templatePath(path string) string {
+	segments := strings.Split(path, "/")
+	for n, segment := range segments {
+		if m := template.PlaceholderPattern.FindStringSubmatch(segment); m != nil && m[0] == segment {
+			segments[n] = placeholder(m[1])
+			continue
+		}
+		if !isIdentifier(segment) {
+			continue
+		}
+		name := "id"
+		if n > 0 && segments[n-1] != "" && !strings.HasPrefix(segments[n-1], "{{") {
+			name = placeholderName(segments[n-1]) + "_id"
+		}
+		segments[n] = placeholder(name)
+	}
+	return strings.Join(segments, "/")
+}
+
+// isIdentifier reports whether a path segment holds an identifier.
+func isIdentifier(segment string) bool {
+	if !identifierPattern.MatchString(segment) {
+		return false
+	}
+	_, err := strconv.Atoi(segment)
+	return err == nil || len(segment) >= minIdentifierLength
+}
+
+// queryFields returns the query parameters of u in their recorded order.
+func queryFields(u *url.URL) []capturedField {
+	var fields []capturedField
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		key, value, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil || name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		value, _ = url.QueryUnescape(value)
+		fields = append(fields, capturedField{Name: name, Value: value})
+	}
+	return fields
+}
+
+// bodyFields returns the sorted top-level fields of a JSON object or form
+// encoded body.
+func bodyFields(body string) []string {
+	if fields := jsonFields(body); fields != nil {
+		return fields
+	}
+
+	values, err := url.ParseQuery(strings.TrimSpace(body))
+	if err != nil || strings.HasPrefix(strings.TrimSpace(body), "{") {
+		return nil
+	}
+	fields := make([]string, 0, len(values))
+	for field := range values {
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// jsonFields returns the sorted top-level fields of a JSON object.
+func jsonFields(body string) []string {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &object); err != nil {
+		return nil
+	}
+	fields := make([]string, 0, len(object))
+	for field := range object {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
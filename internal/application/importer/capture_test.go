@@ -0,0 +1,237 @@
+package importer
+
+import (
+	"errors"
+	"generic-integration-platform/internal/domain/endpoint"
+	"generic-integration-platform/internal/domain/integration"
+	"reflect"
+	"testing"
+)
+
+const harFixture = `{
+  "log": {
+    "entries": [
+      {
+        "request": {"method": "GET", "url": "https://acme.com/", "headers": []},
+        "response": {"content": {"mimeType": "text/html", "text": "<html></html>"}}
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "https://api.acme.com/v1/payments?expand=customer",
+          "headers": [
+            {"name": "Authorization", "value": "Bearer sk_test_123"},
+            {"name": "Content-Type", "value": "application/json"},
+            {"name": "Acme-Version", "value": "2024-01-01"},
+            {"name": "Idempotency-Key", "value": "key-1"},
+            {"name": "X-Client", "value": "123e4567-e89b-12d3-a456-426614174000"}
+          ],
+          "postData": {"mimeType": "application/json", "text": "{\"amount\": 100, \"currency\": \"usd\"}"}
+        },
+        "response": {"content": {"mimeType": "application/json", "text": "{\"id\": \"pay_123\", \"status\": \"pending\"}"}}
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "https://api.acme.com/v1/payments/pay_12345678/capture",
+          "headers": [{"name": "Authorization", "value": "Bearer sk_test_123"}],
+          "postData": {"mimeType": "application/x-www-form-urlencoded", "text": "amount=100"}
+        },
+        "response": {"content": {"mimeType": "application/json", "text": "{\"status\": \"captured\"}", "encoding": ""}}
+      },
+      {
+        "request": {"method": "POST", "url": "https://events.tracker.com/collect", "headers": []},
+        "response": {"content": {"mimeType": "application/json", "text": "{}"}}
+      }
+    ]
+  }
+}`
+
+const postmanFixture = `{
+  "info": {
+    "name": "Acme Payments",
+    "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+  },
+  "auth": {
+    "type": "basic",
+    "basic": [{"key": "username", "value": "merchant"}, {"key": "password", "value": "hunter2"}]
+  },
+  "variable": [{"key": "host", "value": "https://api.acme.com"}],
+  "item": [
+    {
+      "name": "Payments",
+      "item": [
+        {
+          "name": "Create payment",
+          "request": {
+            "method": "POST",
+            "url": "{{host}}/v1/payments",
+            "header": [
+              {"key": "X-Request-Id", "value": "req-1"},
+              {"key": "X-Debug", "value": "1", "disabled": true}
+            ],
+            "body": {"mode": "raw", "raw": "{\"amount\": {{amount}}, \"currency\": \"usd\"}"}
+          },
+          "response": [{"body": "{\"id\": \"pay_123\"}"}]
+        },
+        {
+          "name": "Get payment",
+          "request": {
+            "method": "GET",
+            "url": {"raw": "{{host}}/v1/payments/{{paymentId}}"}
+          }
+        }
+      ]
+    }
+  ]
+}`
+
+func TestFromHAR(t *testing.T) {
+	i, err := FromHAR([]byte(harFixture), CaptureOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if i.Name != "api.acme.com" || i.BaseURL != "https://api.acme.com/v1" || i.Currency != defaultCurrency {
+		t.Errorf("FromHAR() = %s %s %s, want api.acme.com https://api.acme.com/v1 %s", i.Name, i.BaseURL, i.Currency, defaultCurrency)
+	}
+	if i.AuthType != integration.AuthTypeToken || i.AuthHeader != "Authorization" || i.AuthToken != "secret://api.acme.com/auth_token" {
+		t.Errorf("FromHAR() auth = %s %s %s, want token Authorization secret://api.acme.com/auth_token", i.AuthType, i.AuthHeader, i.AuthToken)
+	}
+
+	want := []*endpoint.Endpoint{
+		{
+			Action: "post_payments",
+			Method: "POST",
+			Path:   "/payments?expand={{input.expand}}",
+			Params: map[string]string{"amount": "{{input.amount}}", "currency": "{{input.currency}}"},
+			Headers: map[string]string{
+				"Acme-Version":    "2024-01-01",
+				"Idempotency-Key": "{{input.idempotency_key}}",
+				"X-Client":        "{{input.x_client}}",
+			},
+			ResponseMappings: map[string]string{"id": "{{response.id}}", "status": "{{response.status}}"},
+		},
+		{
+			Action:           "post_payments_capture",
+			Method:           "POST",
+			Path:             "/payments/{{input.payments_id}}/capture",
+			Params:           map[string]string{"amount": "{{input.amount}}"},
+			ResponseMappings: map[string]string{"status": "{{response.status}}"},
+		},
+	}
+	assertEndpoints(t, i.Endpoints, want)
+}
+
+func TestFromPostman(t *testing.T) {
+	i, err := FromPostman([]byte(postmanFixture), CaptureOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if i.Name != "acme_payments" || i.BaseURL != "https://api.acme.com/v1" {
+		t.Errorf("FromPostman() = %s %s, want acme_payments https://api.acme.com/v1", i.Name, i.BaseURL)
+	}
+	wantBasic := &integration.Basic{Username: "merchant", Password: "secret://acme_payments/basic_password"}
+	if i.AuthType != integration.AuthTypeBasic || !reflect.DeepEqual(i.Basic, wantBasic) {
+		t.Errorf("FromPostman() auth = %s %+v, want basic %+v", i.AuthType, i.Basic, wantBasic)
+	}
+
+	want := []*endpoint.Endpoint{
+		{
+			Action:           "create_payment",
+			Method:           "POST",
+			Path:             "/payments",
+			Params:           map[string]string{"amount": "{{input.amount}}", "currency": "{{input.currency}}"},
+			Headers:          map[string]string{"X-Request-Id": "{{input.x_request_id}}"},
+			ResponseMappings: map[string]string{"id": "{{response.id}}"},
+		},
+		{
+			Action: "get_payment",
+			Method: "GET",
+			Path:   "/payments/{{input.paymentId}}",
+			Params: map[string]string{},
+		},
+	}
+	assertEndpoints(t, i.Endpoints, want)
+}
+
+func TestFromPostmanOptions(t *testing.T) {
+	const unresolved = `{
+  "info": {"name": "acme"},
+  "auth": {"type": "apikey", "apikey": [{"key": "key", "value": "api_key"}, {"key": "in", "value": "query"}]},
+  "item": [{"name": "List payments", "request": {"method": "GET", "url": "{{baseUrl}}/payments?limit=10"}}]
+}`
+
+	tests := []struct {
+		name    string
+		content string
+		opts    CaptureOptions
+		check   func(t *testing.T, i *integration.Integration)
+		wantErr bool
+	}{
+		{
+			name:    "options",
+			content: postmanFixture,
+			opts:    CaptureOptions{Name: "acme", BaseURL: "https://api.acme.com", Currency: "EUR"},
+			check: func(t *testing.T, i *integration.Integration) {
+				if i.Name != "acme" || i.BaseURL != "https://api.acme.com" || i.Currency != "EUR" {
+					t.Errorf("got %s %s %s", i.Name, i.BaseURL, i.Currency)
+				}
+				if i.Endpoints[0].Path != "/v1/payments" {
+					t.Errorf("Path = %s, want /v1/payments", i.Endpoints[0].Path)
+				}
+			},
+		},
+		{
+			name:    "leading variable replaced by the base URL",
+			content: unresolved,
+			opts:    CaptureOptions{BaseURL: "https://api.acme.com"},
+			check: func(t *testing.T, i *integration.Integration) {
+				if i.AuthType != integration.AuthTypeQueryKey || i.AuthParam != "api_key" || i.AuthToken != "secret://acme/auth_token" {
+					t.Errorf("auth = %s %s %s", i.AuthType, i.AuthParam, i.AuthToken)
+				}
+				want := &endpoint.Endpoint{
+					Action: "list_payments",
+					Method: "GET",
+					Path:   "/payments",
+					Params: map[string]string{"limit": "{{input.limit}}"},
+				}
+				assertEndpoints(t, i.Endpoints, []*endpoint.Endpoint{want})
+			},
+		},
+		{name: "leading variable without a base URL", content: unresolved, wantErr: true},
+		{name: "collection v1", content: `{"info": {"name": "acme", "schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}}`, wantErr: true},
+		{name: "no request", content: `{"info": {"name": "acme"}, "item": []}`, wantErr: true},
+		{name: "not json", content: `{`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := FromPostman([]byte(tt.content), tt.opts)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDocument) {
+					t.Fatalf("FromPostman() error = %v, want %v", err, ErrInvalidDocument)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, i)
+		})
+	}
+}
+
+// assertEndpoints checks that got has the endpoints of want, in order.
+func assertEndpoints(t *testing.T, got, want []*endpoint.Endpoint) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d endpoints, want %d", len(got), len(want))
+	}
+	for n, ep := range got {
+		if !reflect.DeepEqual(ep, want[n]) {
+			t.Errorf("endpoint %d = %+v, want %+v", n, ep, want[n])
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"net/url"
+	"strings"
+)
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Entries []harEntry `json:"entries"`
+}
+
+type harEntry struct {
+	Request  harRequest  `json:"request"`
+	Response harResponse `json:"response"`
+}
+
+type harRequest struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Headers  []harHeader `json:"headers"`
+	PostData *harContent `json:"postData"`
+}
+
+type harResponse struct {
+	Content harContent `json:"content"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harContent struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding"`
+}
+
+// body returns the text of the content, decoded if needed.
+func (c harContent) body() string {
+	if c.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(c.Text)
+		if err != nil {
+			return ""
+		}
+		return string(decoded)
+	}
+	return c.Text
+}
+
+// isAPICall reports whether the entry is an API call rather than the page,
+// its assets or tracking requests: its request or response body is JSON, or
+// it sends a form.
+func (e harEntry) isAPICall() bool {
+	types := []string{e.Response.Content.MimeType}
+	if e.Request.PostData != nil {
+		types = append(types, e.Request.PostData.MimeType)
+	}
+	for _, t := range types {
+		if strings.Contains(t, "json") || strings.HasPrefix(t, "application/x-www-form-urlencoded") {
+			return true
+		}
+	}
+	return false
+}
+
+// FromHAR creates a REST integration from the API calls recorded in a HAR
+// file, such as the network log of a browser on the sandbox of a provider.
+// Only requests to the host of the base URL, by default the host of the first
+// API call, with a JSON or form body or a JSON response are imported. Each
+// endpoint is named after its method and path, e.g. post_payments_id_capture.
+func FromHAR(content []byte, opts CaptureOptions) (*integration.Integration, error) {
+	var file harFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	var requests []capturedRequest
+	for _, entry := range file.Log.Entries {
+		if !entry.isAPICall() {
+			continue
+		}
+
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || !u.IsAbs() {
+			return nil, fmt.Errorf("%w: URL %q is not absolute", ErrInvalidDocument, entry.Request.URL)
+		}
+
+		r := capturedRequest{
+			Method:   entry.Request.Method,
+			URL:      u,
+			Response: entry.Response.Content.body(),
+		}
+		for _, h := range entry.Request.Headers {
+			r.Headers = append(r.Headers, capturedField{Name: h.Name, Value: h.Value})
+		}
+		if entry.Request.PostData != nil {
+			r.Body = entry.Request.PostData.body()
+		}
+		requests = append(requests, r)
+	}
+
+	return fromCaptures(requests, "", nil, opts)
+}
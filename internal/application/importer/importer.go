@@ -0,0 +1,63 @@
+// Package importer creates integrations from the API descriptions published
+// by providers, so that endpoints do not have to be written by hand.
+//
+// Generated integrations use the REST extender. Their credentials are secret
+// references to provision before the integration is used, and every request
+// field is an {{input.*}} placeholder of the same name, rendered from the step
+// params; fields that are not needed can be removed from the generated
+// endpoints.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/domain/endpoint"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/secret"
+	"regexp"
+)
+
+// ErrInvalidDocument is returned when a document cannot be imported.
+var ErrInvalidDocument = errors.New("invalid document")
+
+// defaultCurrency is the currency of imported integrations unless specified.
+const defaultCurrency = "USD"
+
+// reservedHeaders are set by the REST extender and never imported.
+var reservedHeaders = map[string]bool{
+	"Authorization":  true,
+	"Accept":         true,
+	"Content-Type":   true,
+	"Content-Length": true,
+	"Host":           true,
+}
+
+// invalidPlaceholderChars matches the characters placeholders cannot contain.
+var invalidPlaceholderChars = regexp.MustCompile(`[^\w\-]+`)
+
+// placeholder returns the {{input.*}} placeholder of a field.
+func placeholder(field string) string {
+	return "{{input." + placeholderName(field) + "}}"
+}
+
+// placeholderName turns a field name into a valid placeholder name.
+func placeholderName(field string) string {
+	return invalidPlaceholderChars.ReplaceAllString(field, "_")
+}
+
+// secretReference returns the secret store reference of a credential of an
+// imported integration, e.g. secret://stripe/auth_token.
+func secretReference(integrationName, field string) string {
+	return secret.SchemeSecret + "://" + integrationName + "/" + field
+}
+
+// addEndpoint adds ep to i, rejecting duplicate actions.
+func addEndpoint(i *integration.Integration, ep *endpoint.Endpoint) error {
+	for _, existing := range i.Endpoints {
+		if existing.Action == ep.Action {
+			return fmt.Errorf("%w: action %s is declared more than once", ErrInvalidDocument, ep.Action)
+		}
+	}
+	i.AddEndpoint(ep)
+	return nil
+}
@@ -0,0 +1,545 @@
+package importer
+
+import (
+	"fmt"
+	"generic-integration-platform/internal/domain/endpoint"
+	"generic-integration-platform/internal/domain/integration"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIOptions selects what is imported from an OpenAPI document.
+type OpenAPIOptions struct {
+	Name       string   // Integration name, the title of the document by default
+	BaseURL    string   // Base URL, the first server of the document by default
+	Currency   string   // Currency of the integration, "USD" by default
+	Operations []string // Actions to import, every operation by default
+	Scheme     string   // Security scheme used to authenticate, the first one required by default
+	Username   string   // Basic username or OAuth client ID, for the schemes requiring one
+}
+
+// openAPIMethods are the operations of a path item, in import order.
+var openAPIMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
+// pathParamPattern matches the {param} segments of OpenAPI paths.
+var pathParamPattern = regexp.MustCompile(`{([^{}]+)}`)
+
+type openAPIDocument struct {
+	OpenAPI    string                      `yaml:"openapi"`
+	Info       openAPIInfo                 `yaml:"info"`
+	Servers    []openAPIServer             `yaml:"servers"`
+	Paths      map[string]*openAPIPathItem `yaml:"paths"`
+	Components openAPIComponents           `yaml:"components"`
+	Security   []map[string][]string       `yaml:"security"`
+}
+
+type openAPIInfo struct {
+	Title string `yaml:"title"`
+}
+
+type openAPIServer struct {
+	URL       string                           `yaml:"url"`
+	Variables map[string]openAPIServerVariable `yaml:"variables"`
+}
+
+type openAPIServerVariable struct {
+	Default string `yaml:"default"`
+}
+
+type openAPIPathItem struct {
+	Parameters []*openAPIParameter `yaml:"parameters"`
+	Get        *openAPIOperation   `yaml:"get"`
+	Post       *openAPIOperation   `yaml:"post"`
+	Put        *openAPIOperation   `yaml:"put"`
+	Patch      *openAPIOperation   `yaml:"patch"`
+	Delete     *openAPIOperation   `yaml:"delete"`
+	Head       *openAPIOperation   `yaml:"head"`
+	Options    *openAPIOperation   `yaml:"options"`
+}
+
+// operation returns the operation of the path item for method.
+func (p *openAPIPathItem) operation(method string) *openAPIOperation {
+	switch method {
+	case http.MethodGet:
+		return p.Get
+	case http.MethodPost:
+		return p.Post
+	case http.MethodPut:
+		return p.Put
+	case http.MethodPatch:
+		return p.Patch
+	case http.MethodDelete:
+		return p.Delete
+	case http.MethodHead:
+		return p.Head
+	case http.MethodOptions:
+		return p.Options
+	}
+	return nil
+}
+
+type openAPIOperation struct {
+	OperationID string                      `yaml:"operationId"`
+	Parameters  []*openAPIParameter         `yaml:"parameters"`
+	RequestBody *openAPIRequestBody         `yaml:"requestBody"`
+	Responses   map[string]*openAPIResponse `yaml:"responses"`
+	Security    []map[string][]string       `yaml:"security"`
+}
+
+type openAPIParameter struct {
+	Ref  string `yaml:"$ref"`
+	Name string `yaml:"name"`
+	In   string `yaml:"in"`
+}
+
+type openAPIRequestBody struct {
+	Ref     string                       `yaml:"$ref"`
+	Content map[string]*openAPIMediaType `yaml:"content"`
+}
+
+type openAPIResponse struct {
+	Ref     string                       `yaml:"$ref"`
+	Content map[string]*openAPIMediaType `yaml:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `yaml:"schema"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `yaml:"$ref"`
+	Properties map[string]*openAPISchema `yaml:"properties"`
+	AllOf      []*openAPISchema          `yaml:"allOf"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `yaml:"schemas"`
+	Parameters      map[string]*openAPIParameter      `yaml:"parameters"`
+	RequestBodies   map[string]*openAPIRequestBody    `yaml:"requestBodies"`
+	Responses       map[string]*openAPIResponse       `yaml:"responses"`
+	SecuritySchemes map[string]*openAPISecurityScheme `yaml:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string            `yaml:"type"`
+	Scheme string            `yaml:"scheme"`
+	Name   string            `yaml:"name"`
+	In     string            `yaml:"in"`
+	Flows  openAPIOAuthFlows `yaml:"flows"`
+}
+
+type openAPIOAuthFlows struct {
+	ClientCredentials *openAPIOAuthFlow `yaml:"clientCredentials"`
+}
+
+type openAPIOAuthFlow struct {
+	TokenURL string            `yaml:"tokenUrl"`
+	Scopes   map[string]string `yaml:"scopes"`
+}
+
+// FromOpenAPI creates a REST integration from an OpenAPI 3 document, in YAML
+// or JSON. Every selected operation becomes an endpoint whose action is the
+// operationId, or the method and path when the operation has none. Path,
+// query and header parameters and the properties of the JSON request body
+// become {{input.*}} placeholders of the same name, and the properties of the JSON
+// success response become response mappings. Credentials are referenced as
+// secret://<name>/<field>, to be provisioned in the secret store.
+func FromOpenAPI(content []byte, opts OpenAPIOptions) (*integration.Integration, error) {
+	var doc openAPIDocument
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%w: unsupported OpenAPI version %q, expected 3.x", ErrInvalidDocument, doc.OpenAPI)
+	}
+
+	i := &integration.Integration{
+		Name:     opts.Name,
+		Type:     "rest",
+		BaseURL:  opts.BaseURL,
+		Currency: opts.Currency,
+	}
+	if i.Name == "" {
+		i.Name = doc.Info.Title
+	}
+	if i.Currency == "" {
+		i.Currency = defaultCurrency
+	}
+	if i.BaseURL == "" {
+		baseURL, err := doc.baseURL()
+		if err != nil {
+			return nil, err
+		}
+		i.BaseURL = baseURL
+	}
+
+	wanted := make(map[string]bool, len(opts.Operations))
+	for _, action := range opts.Operations {
+		wanted[action] = false
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var security []map[string][]string
+	for _, path := range paths {
+		item := doc.Paths[path]
+		if item == nil {
+			continue
+		}
+		for _, method := range openAPIMethods {
+			op := item.operation(method)
+			if op == nil {
+				continue
+			}
+
+			action := op.OperationID
+			if action == "" {
+				action = operationAction(method, path)
+			}
+			if len(opts.Operations) > 0 {
+				if _, ok := wanted[action]; !ok {
+					continue
+				}
+				wanted[action] = true
+			}
+
+			ep, err := doc.endpoint(action, method, path, item, op)
+			if err != nil {
+				return nil, fmt.Errorf("%w: operation %s: %v", ErrInvalidDocument, action, err)
+			}
+			if err := addEndpoint(i, ep); err != nil {
+				return nil, err
+			}
+			if security == nil && len(op.Security) > 0 {
+				security = op.Security
+			}
+		}
+	}
+
+	var missing []string
+	for action, found := range wanted {
+		if !found {
+			missing = append(missing, action)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%w: no operation %s", ErrInvalidDocument, strings.Join(missing, ", "))
+	}
+	if len(i.Endpoints) == 0 {
+		return nil, fmt.Errorf("%w: the document has no operation", ErrInvalidDocument)
+	}
+
+	if len(doc.Security) > 0 {
+		security = doc.Security
+	}
+	if err := doc.authenticate(i, security, opts); err != nil {
+		return nil, err
+	}
+
+	if err := i.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	return i, nil
+}
+
+// baseURL returns the URL of the first server, with its variables set to
+// their default value.
+func (doc *openAPIDocument) baseURL() (string, error) {
+	if len(doc.Servers) == 0 {
+		return "", fmt.Errorf("%w: the document declares no server, set the base URL", ErrInvalidDocument)
+	}
+
+	server := doc.Servers[0]
+	baseURL := server.URL
+	for name, variable := range server.Variables {
+		baseURL = strings.ReplaceAll(baseURL, "{"+name+"}", variable.Default)
+	}
+
+	if u, err := url.Parse(baseURL); err != nil || !u.IsAbs() {
+		return "", fmt.Errorf("%w: server URL %q is not absolute, set the base URL", ErrInvalidDocument, baseURL)
+	}
+	return baseURL, nil
+}
+
+// endpoint maps an operation to an endpoint.
+func (doc *openAPIDocument) endpoint(action, method, path string, item *openAPIPathItem, op *openAPIOperation) (*endpoint.Endpoint, error) {
+	ep := &endpoint.Endpoint{
+		Action: action,
+		Method: method,
+		Path: pathParamPattern.ReplaceAllStringFunc(path, func(match string) string {
+			return placeholder(match[1 : len(match)-1])
+		}),
+		Params:  make(map[string]string),
+		Headers: make(map[string]string),
+	}
+
+	parameters, err := doc.parameters(item.Parameters, op.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only GET requests carry their params in the query string.
+	var query []string
+	for _, p := range parameters {
+		switch p.In {
+		case "query":
+			if method == http.MethodGet {
+				ep.Params[p.Name] = placeholder(p.Name)
+			} else {
+				query = append(query, url.QueryEscape(p.Name)+"="+placeholder(p.Name))
+			}
+		case "header":
+			if !reservedHeaders[http.CanonicalHeaderKey(p.Name)] {
+				ep.Headers[p.Name] = placeholder(p.Name)
+			}
+		}
+	}
+	if len(query) > 0 {
+		ep.Path += "?" + strings.Join(query, "&")
+	}
+
+	if op.RequestBody != nil {
+		body, err := resolve(op.RequestBody.Ref, doc.Components.RequestBodies, op.RequestBody)
+		if err != nil {
+			return nil, err
+		}
+		properties, err := doc.properties(jsonSchema(body.Content), 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range properties {
+			ep.Params[name] = placeholder(name)
+		}
+	}
+
+	response, err := doc.successResponse(op.Responses)
+	if err != nil {
+		return nil, err
+	}
+	if response != nil {
+		properties, err := doc.properties(jsonSchema(response.Content), 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range properties {
+			if ep.ResponseMappings == nil {
+				ep.ResponseMappings = make(map[string]string)
+			}
+			ep.ResponseMappings[name] = "{{response." + name + "}}"
+		}
+	}
+
+	return ep, nil
+}
+
+// parameters resolves the parameters of a path item and of its operation,
+// the latter overriding the former.
+func (doc *openAPIDocument) parameters(pathParams, opParams []*openAPIParameter) ([]*openAPIParameter, error) {
+	var parameters []*openAPIParameter
+	index := make(map[string]int)
+	for _, p := range append(append([]*openAPIParameter{}, pathParams...), opParams...) {
+		resolved, err := resolve(p.Ref, doc.Components.Parameters, p)
+		if err != nil {
+			return nil, err
+		}
+		key := resolved.In + ":" + resolved.Name
+		if n, ok := index[key]; ok {
+			parameters[n] = resolved
+			continue
+		}
+		index[key] = len(parameters)
+		parameters = append(parameters, resolved)
+	}
+	return parameters, nil
+}
+
+// successResponse returns the first 2xx response of an operation, or its
+// default response.
+func (doc *openAPIDocument) successResponse(responses map[string]*openAPIResponse) (*openAPIResponse, error) {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	if len(codes) == 0 {
+		codes = append(codes, "default")
+	}
+
+	response := responses[codes[0]]
+	if response == nil {
+		return nil, nil
+	}
+	return resolve(response.Ref, doc.Components.Responses, response)
+}
+
+// maxSchemaDepth bounds the references followed to list the properties of a
+// schema, as schemas may be recursive.
+const maxSchemaDepth = 32
+
+// properties returns the sorted names of the top-level properties of schema,
+// including those of the schemas it is composed of with allOf.
+func (doc *openAPIDocument) properties(schema *openAPISchema, depth int) ([]string, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	if depth > maxSchemaDepth {
+		return nil, fmt.Errorf("schema references are nested too deeply")
+	}
+	schema, err := resolve(schema.Ref, doc.Components.Schemas, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for name := range schema.Properties {
+		seen[name] = true
+		names = append(names, name)
+	}
+	for _, part := range schema.AllOf {
+		inherited, err := doc.properties(part, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range inherited {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// authenticate sets the authentication of i from the first security scheme
+// required, or from opts.Scheme.
+func (doc *openAPIDocument) authenticate(i *integration.Integration, security []map[string][]string, opts OpenAPIOptions) error {
+	name := opts.Scheme
+	if name == "" {
+		for _, requirement := range security {
+			names := make([]string, 0, len(requirement))
+			for n := range requirement {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			if len(names) > 0 {
+				name = names[0]
+				break
+			}
+		}
+	}
+	if name == "" {
+		i.AuthType = integration.AuthTypeNone
+		return nil
+	}
+
+	scheme, ok := doc.Components.SecuritySchemes[name]
+	if !ok || scheme == nil {
+		return fmt.Errorf("%w: no security scheme %s", ErrInvalidDocument, name)
+	}
+
+	switch {
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"):
+		i.AuthType = integration.AuthTypeToken
+		i.AuthHeader = "Authorization"
+		i.AuthToken = secretReference(i.Name, "auth_token")
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+		if opts.Username == "" {
+			return fmt.Errorf("%w: security scheme %s requires a username", ErrInvalidDocument, name)
+		}
+		i.AuthType = integration.AuthTypeBasic
+		i.Basic = &integration.Basic{
+			Username: opts.Username,
+			Password: secretReference(i.Name, "basic_password"),
+		}
+	case scheme.Type == "apiKey" && scheme.In == "header":
+		i.AuthType = integration.AuthTypeToken
+		i.AuthHeader = scheme.Name
+		i.AuthToken = secretReference(i.Name, "auth_token")
+	case scheme.Type == "apiKey" && scheme.In == "query":
+		i.AuthType = integration.AuthTypeQueryKey
+		i.AuthParam = scheme.Name
+		i.AuthToken = secretReference(i.Name, "auth_token")
+	case scheme.Type == "oauth2" && scheme.Flows.ClientCredentials != nil:
+		if opts.Username == "" {
+			return fmt.Errorf("%w: security scheme %s requires a client ID", ErrInvalidDocument, name)
+		}
+		flow := scheme.Flows.ClientCredentials
+		scopes := make([]string, 0, len(flow.Scopes))
+		for scope := range flow.Scopes {
+			scopes = append(scopes, scope)
+		}
+		sort.Strings(scopes)
+		i.AuthType = integration.AuthTypeOAuth
+		i.OAuth = &integration.OAuth{
+			ClientID:     opts.Username,
+			ClientSecret: secretReference(i.Name, "oauth_client_secret"),
+			TokenURL:     flow.TokenURL,
+			Scopes:       scopes,
+		}
+	default:
+		return fmt.Errorf("%w: security scheme %s (%s %s%s) is not supported", ErrInvalidDocument, name, scheme.Type, scheme.Scheme, scheme.In)
+	}
+	return nil
+}
+
+// resolve returns the component ref points to, or value when ref is empty.
+// Only local references to components are supported.
+func resolve[T any](ref string, components map[string]*T, value *T) (*T, error) {
+	if ref == "" {
+		return value, nil
+	}
+	if !strings.HasPrefix(ref, "#/components/") {
+		return nil, fmt.Errorf("unsupported reference %s, only local components are supported", ref)
+	}
+	resolved, ok := components[ref[strings.LastIndex(ref, "/")+1:]]
+	if !ok || resolved == nil {
+		return nil, fmt.Errorf("unresolved reference %s", ref)
+	}
+	return resolved, nil
+}
+
+// jsonSchema returns the schema of the JSON media type of content.
+func jsonSchema(content map[string]*openAPIMediaType) *openAPISchema {
+	types := make([]string, 0, len(content))
+	for mediaType := range content {
+		types = append(types, mediaType)
+	}
+	sort.Strings(types)
+
+	for _, mediaType := range types {
+		if (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) && content[mediaType] != nil {
+			return content[mediaType].Schema
+		}
+	}
+	return nil
+}
+
+// operationAction names an operation without operationId after its method
+// and path, e.g. post_payments_id_capture.
+func operationAction(method, path string) string {
+	action := strings.ToLower(method)
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.Trim(segment, "{}")
+		if segment != "" {
+			action += "_" + placeholderName(segment)
+		}
+	}
+	return action
+}
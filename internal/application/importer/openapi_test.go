@@ -0,0 +1,210 @@
+package importer
+
+import (
+	"errors"
+	"generic-integration-platform/internal/domain/endpoint"
+	"generic-integration-platform/internal/domain/integration"
+	"reflect"
+	"testing"
+)
+
+const openAPIFixture = `
+openapi: 3.0.3
+info:
+  title: acme
+servers:
+  - url: https://{region}.api.acme.com/v1
+    variables:
+      region:
+        default: eu
+paths:
+  /payments:
+    post:
+      operationId: createPayment
+      parameters:
+        - name: Idempotency-Key
+          in: header
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Payment'
+      responses:
+        "201":
+          content:
+            application/json:
+              schema:
+                properties:
+                  id: {type: string}
+                  status: {type: string}
+  /payments/{id}:
+    parameters:
+      - name: id
+        in: path
+    get:
+      parameters:
+        - name: expand
+          in: query
+      responses:
+        "200":
+          description: ok
+components:
+  schemas:
+    Payment:
+      properties:
+        amount: {type: integer}
+        currency: {type: string}
+  securitySchemes:
+    bearer:
+      type: http
+      scheme: bearer
+    key:
+      type: apiKey
+      in: query
+      name: api_key
+    client:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://auth.acme.com/token
+          scopes:
+            write: Write access
+            read: Read access
+security:
+  - bearer: []
+`
+
+func TestFromOpenAPI(t *testing.T) {
+	i, err := FromOpenAPI([]byte(openAPIFixture), OpenAPIOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if i.Name != "acme" || i.Type != "rest" || i.BaseURL != "https://eu.api.acme.com/v1" || i.Currency != defaultCurrency {
+		t.Errorf("FromOpenAPI() = %s %s %s %s, want acme rest https://eu.api.acme.com/v1 %s", i.Name, i.Type, i.BaseURL, i.Currency, defaultCurrency)
+	}
+	if i.AuthType != integration.AuthTypeToken || i.AuthHeader != "Authorization" || i.AuthToken != "secret://acme/auth_token" {
+		t.Errorf("FromOpenAPI() auth = %s %s %s, want token Authorization secret://acme/auth_token", i.AuthType, i.AuthHeader, i.AuthToken)
+	}
+
+	want := []*endpoint.Endpoint{
+		{
+			Action:           "createPayment",
+			Method:           "POST",
+			Path:             "/payments",
+			Params:           map[string]string{"amount": "{{input.amount}}", "currency": "{{input.currency}}"},
+			Headers:          map[string]string{"Idempotency-Key": "{{input.Idempotency-Key}}"},
+			ResponseMappings: map[string]string{"id": "{{response.id}}", "status": "{{response.status}}"},
+		},
+		{
+			Action:  "get_payments_id",
+			Method:  "GET",
+			Path:    "/payments/{{input.id}}",
+			Params:  map[string]string{"expand": "{{input.expand}}"},
+			Headers: map[string]string{},
+		},
+	}
+	if len(i.Endpoints) != len(want) {
+		t.Fatalf("FromOpenAPI() has %d endpoints, want %d", len(i.Endpoints), len(want))
+	}
+	for n, ep := range i.Endpoints {
+		if !reflect.DeepEqual(ep, want[n]) {
+			t.Errorf("endpoint %d = %+v, want %+v", n, ep, want[n])
+		}
+	}
+}
+
+func TestFromOpenAPIOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    OpenAPIOptions
+		check   func(t *testing.T, i *integration.Integration)
+		wantErr bool
+	}{
+		{
+			name: "name, base URL and currency",
+			opts: OpenAPIOptions{Name: "acme_eu", BaseURL: "https://sandbox.acme.com", Currency: "EUR"},
+			check: func(t *testing.T, i *integration.Integration) {
+				if i.Name != "acme_eu" || i.BaseURL != "https://sandbox.acme.com" || i.Currency != "EUR" {
+					t.Errorf("got %s %s %s", i.Name, i.BaseURL, i.Currency)
+				}
+				if i.AuthToken != "secret://acme_eu/auth_token" {
+					t.Errorf("AuthToken = %s, want secret://acme_eu/auth_token", i.AuthToken)
+				}
+			},
+		},
+		{
+			name: "operations",
+			opts: OpenAPIOptions{Operations: []string{"createPayment"}},
+			check: func(t *testing.T, i *integration.Integration) {
+				if len(i.Endpoints) != 1 || i.Endpoints[0].Action != "createPayment" {
+					t.Errorf("Endpoints = %+v, want createPayment only", i.Endpoints)
+				}
+			},
+		},
+		{
+			name: "api key scheme",
+			opts: OpenAPIOptions{Scheme: "key"},
+			check: func(t *testing.T, i *integration.Integration) {
+				if i.AuthType != integration.AuthTypeQueryKey || i.AuthParam != "api_key" || i.AuthToken != "secret://acme/auth_token" {
+					t.Errorf("auth = %s %s %s", i.AuthType, i.AuthParam, i.AuthToken)
+				}
+			},
+		},
+		{
+			name: "oauth scheme",
+			opts: OpenAPIOptions{Scheme: "client", Username: "client-id"},
+			check: func(t *testing.T, i *integration.Integration) {
+				want := &integration.OAuth{
+					ClientID:     "client-id",
+					ClientSecret: "secret://acme/oauth_client_secret",
+					TokenURL:     "https://auth.acme.com/token",
+					Scopes:       []string{"read", "write"},
+				}
+				if i.AuthType != integration.AuthTypeOAuth || !reflect.DeepEqual(i.OAuth, want) {
+					t.Errorf("auth = %s %+v, want oauth %+v", i.AuthType, i.OAuth, want)
+				}
+			},
+		},
+		{name: "oauth scheme without client ID", opts: OpenAPIOptions{Scheme: "client"}, wantErr: true},
+		{name: "unknown scheme", opts: OpenAPIOptions{Scheme: "cookie"}, wantErr: true},
+		{name: "unknown operation", opts: OpenAPIOptions{Operations: []string{"refundPayment"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := FromOpenAPI([]byte(openAPIFixture), tt.opts)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDocument) {
+					t.Fatalf("FromOpenAPI() error = %v, want %v", err, ErrInvalidDocument)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, i)
+		})
+	}
+}
+
+func TestFromOpenAPIInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "not yaml", content: "openapi: [3"},
+		{name: "swagger 2", content: "swagger: \"2.0\"\ninfo: {title: acme}\n"},
+		{name: "no server", content: "openapi: 3.0.0\ninfo: {title: acme}\npaths:\n  /a:\n    get: {}\n"},
+		{name: "no operation", content: "openapi: 3.0.0\ninfo: {title: acme}\nservers: [{url: https://acme.com}]\n"},
+		{name: "unresolved reference", content: "openapi: 3.0.0\ninfo: {title: acme}\nservers: [{url: https://acme.com}]\npaths:\n  /a:\n    post:\n      requestBody:\n        content:\n          application/json:\n            schema: {$ref: '#/components/schemas/Missing'}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromOpenAPI([]byte(tt.content), OpenAPIOptions{}); !errors.Is(err, ErrInvalidDocument) {
+				t.Errorf("FromOpenAPI() error = %v, want %v", err, ErrInvalidDocument)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/template"
+	"net/url"
+	"strings"
+)
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Auth     *postmanAuth      `json:"auth"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// postmanItem is a request or a folder of items.
+type postmanItem struct {
+	Name     string            `json:"name"`
+	Item     []postmanItem     `json:"item"`
+	Auth     *postmanAuth      `json:"auth"`
+	Request  *postmanRequest   `json:"request"`
+	Response []postmanResponse `json:"response"`
+}
+
+type postmanRequest struct {
+	Method string          `json:"method"`
+	Header []postmanField  `json:"header"`
+	URL    json.RawMessage `json:"url"` // A string, or an object with the raw URL
+	Body   *postmanBody    `json:"body"`
+	Auth   *postmanAuth    `json:"auth"`
+}
+
+type postmanField struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+type postmanBody struct {
+	Mode       string         `json:"mode"`
+	Raw        string         `json:"raw"`
+	URLEncoded []postmanField `json:"urlencoded"`
+	FormData   []postmanField `json:"formdata"`
+}
+
+type postmanResponse struct {
+	Body string `json:"body"`
+}
+
+// postmanAuth is the authentication of a collection, folder or request. The
+// settings of each type are key/value pairs.
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Basic  []postmanVariable `json:"basic"`
+	APIKey []postmanVariable `json:"apikey"`
+	OAuth2 []postmanVariable `json:"oauth2"`
+}
+
+type postmanVariable struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// setting returns the value of a setting of the auth type.
+func setting(settings []postmanVariable, key string) string {
+	for _, s := range settings {
+		if s.Key == key {
+			if value, ok := s.Value.(string); ok {
+				return value
+			}
+			return fmt.Sprint(s.Value)
+		}
+	}
+	return ""
+}
+
+// FromPostman creates a REST integration from the requests of a Postman
+// collection (format v2.0 or v2.1), named after the collection, e.g. acme_pay. Every request
+// becomes an endpoint named after the request, and the body of its first
+// saved example, if any, its response mappings. Collection variables are
+// replaced in URLs; a leading variable left unresolved, such as {{baseUrl}},
+// is replaced by the base URL, which must then be set.
+func FromPostman(content []byte, opts CaptureOptions) (*integration.Integration, error) {
+	var collection postmanCollection
+	if err := json.Unmarshal(content, &collection); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	if collection.Info.Schema != "" && !strings.Contains(collection.Info.Schema, "/v2.") {
+		return nil, fmt.Errorf("%w: unsupported Postman collection format %s, expected v2.x", ErrInvalidDocument, collection.Info.Schema)
+	}
+
+	variables := make(map[string]interface{}, len(collection.Variable))
+	for _, v := range collection.Variable {
+		variables[v.Key] = v.Value
+	}
+
+	var requests []capturedRequest
+	var auth *postmanAuth
+	var walk func(items []postmanItem, inherited *postmanAuth) error
+	walk = func(items []postmanItem, inherited *postmanAuth) error {
+		for _, item := range items {
+			itemAuth := inherited
+			if item.Auth != nil {
+				itemAuth = item.Auth
+			}
+			if item.Request == nil {
+				if err := walk(item.Item, itemAuth); err != nil {
+					return err
+				}
+				continue
+			}
+			if item.Request.Auth != nil {
+				itemAuth = item.Request.Auth
+			}
+
+			r, err := postmanCapture(item, variables, opts.BaseURL)
+			if err != nil {
+				return fmt.Errorf("%w: request %s: %v", ErrInvalidDocument, item.Name, err)
+			}
+			requests = append(requests, r)
+			if auth == nil && itemAuth != nil && itemAuth.Type != "noauth" {
+				auth = itemAuth
+			}
+		}
+		return nil
+	}
+	if err := walk(collection.Item, collection.Auth); err != nil {
+		return nil, err
+	}
+
+	return fromCaptures(requests, actionName(collection.Info.Name), postmanIntegrationAuth(auth), opts)
+}
+
+// postmanCapture maps a request of a collection to a captured request.
+func postmanCapture(item postmanItem, variables map[string]interface{}, baseURL string) (capturedRequest, error) {
+	r := capturedRequest{
+		Name:   item.Name,
+		Method: item.Request.Method,
+	}
+	if r.Method == "" {
+		r.Method = "GET"
+	}
+
+	var raw string
+	if err := json.Unmarshal(item.Request.URL, &raw); err != nil {
+		var object struct {
+			Raw string `json:"raw"`
+		}
+		if err := json.Unmarshal(item.Request.URL, &object); err != nil {
+			return r, fmt.Errorf("invalid URL: %v", err)
+		}
+		raw = object.Raw
+	}
+
+	raw = template.Render(raw, withUnresolved(raw, variables))
+	if strings.HasPrefix(raw, "{{") {
+		if baseURL == "" {
+			return r, fmt.Errorf("URL %s starts with an unresolved variable, set the base URL", raw)
+		}
+		raw = strings.TrimRight(baseURL, "/") + raw[strings.Index(raw, "}}")+2:]
+	}
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() {
+		return r, fmt.Errorf("URL %q is not absolute", raw)
+	}
+	r.URL = u
+
+	for _, h := range item.Request.Header {
+		if !h.Disabled {
+			r.Headers = append(r.Headers, capturedField{Name: h.Key, Value: h.Value})
+		}
+	}
+
+	if body := item.Request.Body; body != nil {
+		switch body.Mode {
+		case "raw":
+			// Variables may be used unquoted, which is not valid JSON.
+			r.Body = template.PlaceholderPattern.ReplaceAllString(body.Raw, "0")
+		case "urlencoded", "formdata":
+			values := url.Values{}
+			for _, f := range append(body.URLEncoded, body.FormData...) {
+				if !f.Disabled {
+					values.Set(f.Key, f.Value)
+				}
+			}
+			r.Body = values.Encode()
+		}
+	}
+
+	if len(item.Response) > 0 {
+		r.Response = item.Response[0].Body
+	}
+	return r, nil
+}
+
+// withUnresolved returns the variables with the placeholders of raw that are
+// not collection variables rendered as themselves, so that they are kept.
+func withUnresolved(raw string, variables map[string]interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, len(variables))
+	for key, value := range variables {
+		data[key] = value
+	}
+	for _, match := range template.PlaceholderPattern.FindAllStringSubmatch(raw, -1) {
+		if _, ok := data[match[1]]; !ok && !strings.Contains(match[1], ".") {
+			data[match[1]] = match[0]
+		}
+	}
+	return data
+}
+
+// postmanIntegrationAuth maps the authentication of a collection to the
+// authentication settings of an integration, without its secret.
+func postmanIntegrationAuth(auth *postmanAuth) *integration.Integration {
+	if auth == nil {
+		return nil
+	}
+
+	switch auth.Type {
+	case "bearer":
+		return &integration.Integration{AuthType: integration.AuthTypeToken, AuthHeader: "Authorization"}
+	case "basic":
+		return &integration.Integration{
+			AuthType: integration.AuthTypeBasic,
+			Basic:    &integration.Basic{Username: setting(auth.Basic, "username")},
+		}
+	case "apikey":
+		name := setting(auth.APIKey, "key")
+		if setting(auth.APIKey, "in") == "query" {
+			return &integration.Integration{AuthType: integration.AuthTypeQueryKey, AuthParam: name}
+		}
+		return &integration.Integration{AuthType: integration.AuthTypeToken, AuthHeader: name}
+	case "oauth2":
+		if setting(auth.OAuth2, "grant_type") != "client_credentials" {
+			return nil
+		}
+		var scopes []string
+		if scope := setting(auth.OAuth2, "scope"); scope != "" {
+			scopes = strings.Fields(scope)
+		}
+		style := "header"
+		if setting(auth.OAuth2, "client_authentication") == "body" {
+			style = "params"
+		}
+		return &integration.Integration{
+			AuthType: integration.AuthTypeOAuth,
+			OAuth: &integration.OAuth{
+				ClientID:  setting(auth.OAuth2, "clientId"),
+				TokenURL:  setting(auth.OAuth2, "accessTokenUrl"),
+				Scopes:    scopes,
+				AuthStyle: style,
+			},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package lint
+
+import (
+	"fmt"
+	"generic-integration-platform/internal/domain/flow"
+	"generic-integration-platform/internal/infra/config"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// inputPattern matches the input fields conditions and success criteria use.
+var inputPattern = regexp.MustCompile(`\binput\.([A-Za-z_]\w*)`)
+
+// flowLinter checks a flow file.
+type flowLinter struct {
+	*file
+	integrations map[string]*declaredIntegration
+	inputs       map[string]bool // Declared inputs, checked when some are declared
+	used         map[string]bool // Inputs the steps use
+	steps        map[string]bool // Keys under which steps record their outputs
+	ids          map[string]bool // IDs and names of the steps, which next can reference
+}
+
+// lintFlow checks a file declaring a flow.
+func (l *linter) lintFlow(f *file, raw map[string]interface{}) {
+	f.checkSchema("", raw, reflect.TypeOf(config.FlowDefinition{}))
+
+	var definition config.FlowDefinition
+	// Values of the wrong type, reported by the schema check, are left empty.
+	_ = mapstructure.Decode(raw, &definition)
+
+	c := &flowLinter{
+		file:         f,
+		integrations: l.integrations,
+		inputs:       map[string]bool{},
+		used:         map[string]bool{},
+		steps:        map[string]bool{},
+		ids:          map[string]bool{},
+	}
+	c.lint(definition)
+}
+
+// lint checks the flow definition.
+func (c *flowLinter) lint(definition config.FlowDefinition) {
+	if definition.Name == "" {
+		c.errorf("", "flow name cannot be empty")
+	}
+	if len(definition.Steps) == 0 {
+		c.errorf("steps", "flow must contain at least one step")
+	}
+	for i, name := range definition.Inputs {
+		if c.inputs[name] {
+			c.warnf(index("inputs", i), "input %s is declared twice", name)
+		}
+		c.inputs[name] = true
+	}
+
+	f := definition.ToDomain()
+	for n, step := range f.Steps {
+		if c.steps[step.Key()] {
+			c.errorf(index("steps", n), "step %s is already declared", step.Key())
+		}
+		c.steps[step.Key()] = true
+		c.ids[step.ID] = true
+		c.ids[step.Name] = true
+	}
+
+	scripted := false
+	for n, step := range f.Steps {
+		c.lintStep(index("steps", n), step)
+		scripted = scripted || step.Kind() != flow.StepTypeIntegration
+	}
+
+	// Scripts and transforms read the input without placeholders.
+	if scripted {
+		return
+	}
+	for i, name := range definition.Inputs {
+		if !c.used[name] {
+			c.warnf(index("inputs", i), "input %s is not used by any step", name)
+		}
+	}
+}
+
+// lintStep checks the step declared at path.
+func (c *flowLinter) lintStep(path string, step *flow.Step) {
+	if err := step.Validate(); err != nil {
+		c.errorf(path, "%v", err)
+	}
+	if step.NextStepID != "" && !c.ids[step.NextStepID] {
+		c.errorf(child(path, "next"), "next step %s does not exist", step.NextStepID)
+	}
+
+	c.checkParams(child(path, "params"), step.Params)
+	c.checkExpression(child(path, "condition"), step.Condition)
+	c.checkExpression(child(path, "success"), step.Success)
+	c.checkExpression(child(path, "retry_if"), step.RetryIf)
+	for _, name := range sortedKeys(step.ParamExprs) {
+		c.checkExpression(child(child(path, "param_expressions"), name), step.ParamExprs[name])
+	}
+
+	if step.Kind() == flow.StepTypeIntegration {
+		c.checkAction(path, step)
+	}
+}
+
+// checkParams checks the placeholders of the step params at path.
+func (c *flowLinter) checkParams(path string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		for _, p := range placeholders(v) {
+			c.checkPlaceholder(path, p)
+		}
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			c.checkParams(child(path, key), v[key])
+		}
+	case []interface{}:
+		for i, element := range v {
+			c.checkParams(index(path, i), element)
+		}
+	}
+}
+
+// checkPlaceholder checks that a placeholder of the step params at path
+// names data of the execution context: the input, or a step output.
+func (c *flowLinter) checkPlaceholder(path, placeholder string) {
+	parts := strings.Split(placeholder, ".")
+	switch parts[0] {
+	case "input":
+		if len(parts) > 1 {
+			c.useInput(path, "{{"+placeholder+"}}", parts[1])
+		}
+	case "steps":
+		if len(parts) < 2 || !c.steps[parts[1]] {
+			c.errorf(path, "{{%s}} references a step that does not exist", placeholder)
+		}
+	case "previous_step":
+	default:
+		c.errorf(path, "{{%s}} is not available to steps, placeholders start with input, steps or previous_step", placeholder)
+	}
+}
+
+// checkExpression checks the input fields an expression at path uses.
+func (c *flowLinter) checkExpression(path, expression string) {
+	for _, m := range inputPattern.FindAllStringSubmatch(expression, -1) {
+		c.useInput(path, fmt.Sprintf("%q", m[0]), m[1])
+	}
+}
+
+// useInput records that the input field name is used at path, by reference,
+// reporting it unless it is declared.
+func (c *flowLinter) useInput(path, reference, name string) {
+	c.used[name] = true
+	if len(c.inputs) > 0 && !c.inputs[name] {
+		c.errorf(path, "%s uses input %s, which is not declared in inputs", reference, name)
+	}
+}
+
+// checkAction checks that the action of the integration step at path exists
+// and that the step sets the params its endpoint uses. Integrations that are
+// not declared in the linted files cannot be checked.
+func (c *flowLinter) checkAction(path string, step *flow.Step) {
+	if step.Integration == "" || step.Action == "" || len(c.integrations) == 0 {
+		return
+	}
+	declared, ok := c.integrations[step.Integration]
+	if !ok {
+		c.warnf(child(path, "integration"), "integration %s is not declared in the linted files", step.Integration)
+		return
+	}
+
+	var actions []string
+	for _, ep := range declared.integration.Endpoints {
+		if ep.Action != step.Action {
+			actions = append(actions, ep.Action)
+			continue
+		}
+		for _, param := range params(ep) {
+			if _, computed := step.ParamExprs[param]; computed {
+				continue
+			}
+			if _, ok := step.Params[param]; !ok {
+				c.warnf(child(path, "params"), "step %s does not set %s, used by %s %s", step.Key(), param, step.Integration, step.Action)
+			}
+		}
+		return
+	}
+	if len(actions) == 0 {
+		c.errorf(child(path, "action"), "integration %s has no action %s", step.Integration, step.Action)
+		return
+	}
+	c.errorf(child(path, "action"), "integration %s has no action %s, expected one of %s", step.Integration, step.Action, strings.Join(actions, ", "))
+}
@@ -0,0 +1,209 @@
+package lint
+
+import (
+	"errors"
+	"generic-integration-platform/internal/domain/endpoint"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/template"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/extender/rest"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// authTypes are the supported authentication types.
+var authTypes = []string{
+	integration.AuthTypeNone,
+	integration.AuthTypeBasic,
+	integration.AuthTypeToken,
+	integration.AuthTypeQueryKey,
+	integration.AuthTypeOAuth,
+	integration.AuthTypeHMAC,
+}
+
+// methods are the HTTP methods REST endpoints can use.
+var methods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// declaredIntegration is an integration declared in a linted file.
+type declaredIntegration struct {
+	integration *integration.Integration
+	file        *file
+	path        string // Path of the integration in its file
+}
+
+// lintIntegrations checks a file declaring integrations.
+func (l *linter) lintIntegrations(f *file, raw map[string]interface{}) {
+	f.checkSchema("", raw, reflect.TypeOf(config.IntegrationConfig{}))
+
+	var declarations config.IntegrationConfig
+	// Values of the wrong type, reported by the schema check, are left empty.
+	_ = mapstructure.Decode(raw, &declarations)
+
+	for n, p := range declarations.Integrations {
+		path := index("integrations", n)
+		i := p.ToDomain()
+		if other, ok := l.integrations[i.Name]; ok && i.Name != "" {
+			pos := other.file.positions.lookup(other.path)
+			f.errorf(child(path, "name"), "integration %s is already declared at %s:%d", i.Name, other.file.name, pos.line)
+		} else {
+			l.integrations[i.Name] = &declaredIntegration{integration: i, file: f, path: path}
+		}
+		f.lintIntegration(path, p, i)
+	}
+}
+
+// lintIntegration checks an integration declared at path.
+func (f *file) lintIntegration(path string, p config.PaymentProvider, i *integration.Integration) {
+	if p.AuthType != "" && !slices.Contains(authTypes, p.AuthType) {
+		f.errorf(child(path, "auth_type"), "unsupported auth type %q, expected one of %s", p.AuthType, strings.Join(authTypes, ", "))
+	} else if err := i.Validate(); err != nil {
+		f.errorf(path, "integration %s: %v", nameOr(i.Name, path), err)
+	}
+
+	if p.Currency != "" && !isCurrency(p.Currency) {
+		f.errorf(child(path, "currency"), "currency %q is not an ISO 4217 code", p.Currency)
+	}
+
+	isREST := strings.EqualFold(strings.TrimSpace(p.Type), rest.Type)
+	if isREST {
+		f.checkURL(child(path, "base_url"), p.BaseURL)
+	}
+	if p.OAuth != nil {
+		f.checkURL(child(path, "oauth.token_url"), p.OAuth.TokenURL)
+	}
+	for _, name := range sortedKeys(p.Environments) {
+		env := p.Environments[name]
+		envPath := child(child(path, "environments"), name)
+		if isREST {
+			f.checkURL(child(envPath, "base_url"), env.BaseURL)
+		}
+		if env.OAuth != nil {
+			f.checkURL(child(envPath, "oauth.token_url"), env.OAuth.TokenURL)
+		}
+	}
+
+	actions := map[string]bool{}
+	for n, ep := range i.Endpoints {
+		epPath := index(child(path, "endpoints"), n)
+		if ep.Action != "" && actions[ep.Action] {
+			f.errorf(child(epPath, "action"), "action %s is already declared by integration %s", ep.Action, nameOr(i.Name, path))
+		}
+		actions[ep.Action] = true
+		f.lintEndpoint(epPath, ep, isREST)
+	}
+}
+
+// lintEndpoint checks an endpoint declared at path.
+func (f *file) lintEndpoint(path string, ep *endpoint.Endpoint, isREST bool) {
+	if err := ep.Validate(); err != nil {
+		f.errorf(path, "endpoint %s: %v", nameOr(ep.Action, path), err)
+	}
+	if isREST && ep.Method != "" && !methods[ep.Method] {
+		if methods[strings.ToUpper(ep.Method)] {
+			f.errorf(child(path, "method"), "HTTP method %q must be written in upper case", ep.Method)
+		} else {
+			f.errorf(child(path, "method"), "unsupported HTTP method %q", ep.Method)
+		}
+	}
+
+	// Requests are rendered from the step params, responses from the response.
+	checkRequest := func(key, tpl string) {
+		for _, p := range placeholders(tpl) {
+			if root(p) == "response" {
+				f.errorf(key, "{{%s}} is only available to response mappings", p)
+			}
+		}
+	}
+	checkRequest(child(path, "path"), ep.Path)
+	for _, key := range sortedKeys(ep.Params) {
+		checkRequest(child(child(path, "params"), key), ep.Params[key])
+	}
+	for _, key := range sortedKeys(ep.Headers) {
+		checkRequest(child(child(path, "headers"), key), ep.Headers[key])
+	}
+	for _, key := range sortedKeys(ep.ResponseMappings) {
+		for _, p := range placeholders(ep.ResponseMappings[key]) {
+			if root(p) != "response" {
+				f.errorf(child(child(path, "response_mappings"), key), "response mappings can only use {{response.*}} placeholders, not {{%s}}", p)
+			}
+		}
+	}
+}
+
+// checkURL reports value, the URL at path, unless it is an absolute HTTP URL.
+// Empty URLs are reported by the domain validation, when they are required.
+func (f *file) checkURL(path, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	switch {
+	case err != nil:
+		f.errorf(path, "invalid URL %q: %v", value, err)
+	case u.Scheme != "http" && u.Scheme != "https":
+		f.errorf(path, "URL %q must start with http:// or https://", value)
+	case u.Host == "":
+		f.errorf(path, "URL %q has no host", value)
+	}
+}
+
+// params returns the step params the placeholders of an endpoint request
+// use, which are available both as {{name}} and {{input.name}}.
+func params(ep *endpoint.Endpoint) []string {
+	found := map[string]bool{}
+	add := func(tpl string) {
+		for _, p := range placeholders(tpl) {
+			p = strings.TrimPrefix(p, "input.")
+			if name := root(p); name != "auth_token" && name != "response" && name != "input" {
+				found[name] = true
+			}
+		}
+	}
+	add(ep.Path)
+	for _, tpl := range ep.Params {
+		add(tpl)
+	}
+	for _, tpl := range ep.Headers {
+		add(tpl)
+	}
+	return sortedKeys(found)
+}
+
+// placeholders returns the paths of the {{placeholders}} of tpl.
+func placeholders(tpl string) []string {
+	var paths []string
+	for _, m := range template.PlaceholderPattern.FindAllStringSubmatch(tpl, -1) {
+		paths = append(paths, m[1])
+	}
+	return paths
+}
+
+// root returns the first key of a placeholder path.
+func root(path string) string {
+	return strings.SplitN(path, ".", 2)[0]
+}
+
+// nameOr returns name, or the path of the unnamed item.
+func nameOr(name, path string) string {
+	if name == "" {
+		return path
+	}
+	return name
+}
@@ -0,0 +1,230 @@
+// Package lint checks integration declarations, such as payments.toml, and
+// flow files without loading them into a platform, so that mistakes are found
+// before they are deployed rather than when a flow is executed.
+//
+// Files are checked against the schema of the declaration files, then the
+// integrations and flows they declare are validated: URLs, HTTP methods,
+// ISO 4217 currencies, the {{placeholders}} of endpoints and steps, and the
+// integrations and actions steps call. Nothing is read but the files, so no
+// database or event store is needed.
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/infra/config"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity tells whether a diagnostic is a mistake or a likely one.
+type Severity string
+
+// Severities of diagnostics.
+const (
+	SeverityError   Severity = "error"   // The file would be rejected, or fail at execution time
+	SeverityWarning Severity = "warning" // The file is valid, but probably not what was meant
+)
+
+// Diagnostic is a problem found in a file.
+type Diagnostic struct {
+	File     string   `json:"file"`             // Path of the file
+	Line     int      `json:"line,omitempty"`   // Line of the problem, starting at 1, or 0 for the whole file
+	Column   int      `json:"column,omitempty"` // Column of the problem, starting at 1
+	Path     string   `json:"path,omitempty"`   // Key of the problem, e.g. integrations[0].endpoints[1].method
+	Severity Severity `json:"severity"`         // Error or warning
+	Message  string   `json:"message"`          // Description of the problem
+}
+
+// String formats the diagnostic as file:line:column: severity: message.
+func (d Diagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location += ":" + strconv.Itoa(d.Line) + ":" + strconv.Itoa(d.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, d.Severity, d.Message)
+}
+
+// Files returns the files named by paths, and the YAML and TOML files of the
+// directories they name.
+func Files(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		var found []string
+		for _, entry := range entries {
+			if _, err := config.FormatOf(entry.Name()); err == nil && !entry.IsDir() {
+				found = append(found, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(found)
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+// Lint checks the integration declarations and flow files at paths. A file
+// declaring integrations has an integrations array; any other file declares
+// a flow. Steps are checked against the integrations declared in the files,
+// so lint flows together with the integrations they call.
+func Lint(paths []string) []Diagnostic {
+	l := &linter{integrations: map[string]*declaredIntegration{}}
+
+	type flowFile struct {
+		file *file
+		raw  map[string]interface{}
+	}
+	var flows []flowFile
+	for _, path := range paths {
+		f, raw := l.read(path)
+		if f == nil {
+			continue
+		}
+		if _, ok := raw["integrations"]; ok {
+			l.lintIntegrations(f, raw)
+		} else {
+			flows = append(flows, flowFile{f, raw})
+		}
+	}
+
+	// Flows are checked once every integration is known.
+	for _, f := range flows {
+		l.lintFlow(f.file, f.raw)
+	}
+
+	return l.sorted(paths)
+}
+
+// linter collects the diagnostics of the files being checked.
+type linter struct {
+	diagnostics  []Diagnostic
+	integrations map[string]*declaredIntegration // Integrations declared in the files, by name
+}
+
+// file is a file being checked.
+type file struct {
+	linter    *linter
+	name      string
+	positions positions
+}
+
+// errorf reports an error at the key of the file named by path.
+func (f *file) errorf(path, format string, args ...interface{}) {
+	f.report(SeverityError, path, fmt.Sprintf(format, args...))
+}
+
+// warnf reports a warning at the key of the file named by path.
+func (f *file) warnf(path, format string, args ...interface{}) {
+	f.report(SeverityWarning, path, fmt.Sprintf(format, args...))
+}
+
+// report records a diagnostic at the key of the file named by path.
+func (f *file) report(severity Severity, path, message string) {
+	pos := f.positions.lookup(path)
+	f.linter.diagnostics = append(f.linter.diagnostics, Diagnostic{
+		File:     f.name,
+		Line:     pos.line,
+		Column:   pos.column,
+		Path:     path,
+		Severity: severity,
+		Message:  message,
+	})
+}
+
+// syntaxError reports a file that cannot be parsed.
+func (f *file) syntaxError(pos position, message string) {
+	f.linter.diagnostics = append(f.linter.diagnostics, Diagnostic{
+		File:     f.name,
+		Line:     pos.line,
+		Column:   pos.column,
+		Severity: SeverityError,
+		Message:  message,
+	})
+}
+
+// yamlErrorPattern matches the line yaml.v3 gives in its errors.
+var yamlErrorPattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// read parses the file at path, returning nil when it cannot be parsed.
+func (l *linter) read(path string) (*file, map[string]interface{}) {
+	f := &file{linter: l, name: path, positions: positions{}}
+
+	format, err := config.FormatOf(path)
+	if err != nil {
+		f.errorf("", "%v", err)
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		f.errorf("", "%v", err)
+		return nil, nil
+	}
+
+	var raw map[string]interface{}
+	switch format {
+	case config.FormatTOML:
+		err = toml.Unmarshal(content, &raw)
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			line, column := decodeErr.Position()
+			f.syntaxError(position{line: line, column: column}, decodeErr.Error())
+			return nil, nil
+		}
+		f.positions = tomlPositions(content)
+	case config.FormatYAML:
+		err = yaml.Unmarshal(content, &raw)
+		if m := yamlErrorPattern.FindStringSubmatch(fmt.Sprint(err)); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			f.syntaxError(position{line: line, column: 1}, m[2])
+			return nil, nil
+		}
+		f.positions = yamlPositions(content)
+	}
+	if err != nil {
+		f.errorf("", "%v", err)
+		return nil, nil
+	}
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+	return f, raw
+}
+
+// sorted returns the diagnostics in the order of the files, then of the lines.
+func (l *linter) sorted(paths []string) []Diagnostic {
+	order := make(map[string]int, len(paths))
+	for i, path := range paths {
+		if _, ok := order[path]; !ok {
+			order[path] = i
+		}
+	}
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		if a.File != b.File {
+			return order[a.File] < order[b.File]
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.diagnostics
+}
@@ -0,0 +1,154 @@
+package lint
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// position is a line and column of a file, starting at 1.
+type position struct {
+	line   int
+	column int
+}
+
+// positions maps the path of the keys and array elements of a file, such as
+// integrations[0].endpoints[1].method, to where they are written.
+type positions map[string]position
+
+// lookup returns the position of path or, when it is not written in the file,
+// of its closest parent. The whole file has no position.
+func (p positions) lookup(path string) position {
+	for path != "" {
+		if pos, ok := p[path]; ok {
+			return pos
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return position{}
+}
+
+// child returns the path of key in the table at path.
+func child(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// index returns the path of the element i of the array at path.
+func index(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// tomlPositions returns the positions of the keys of a TOML document.
+// Arrays of tables are numbered in the order they are written, so that
+// [[integrations.endpoints]] belongs to the last [[integrations]].
+func tomlPositions(content []byte) positions {
+	pos := positions{}
+	arrays := map[string]int{} // Number of elements of the arrays of tables, by path
+
+	var p unstable.Parser
+	p.Reset(content)
+	table := ""
+	for p.NextExpression() {
+		e := p.Expression()
+		switch e.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table = ""
+			it := e.Key()
+			for it.Next() {
+				table = child(table, string(it.Node().Data))
+				if e.Kind == unstable.ArrayTable && it.IsLast() {
+					arrays[table]++
+				}
+				pos.set(&p, table, it.Node())
+				if n := arrays[table]; n > 0 {
+					table = index(table, n-1)
+					pos.set(&p, table, it.Node())
+				}
+			}
+		case unstable.KeyValue:
+			pos.addKeyValue(&p, table, e)
+		}
+	}
+	return pos
+}
+
+// addKeyValue records the positions of a key-value of the table at path.
+func (pos positions) addKeyValue(p *unstable.Parser, path string, kv *unstable.Node) {
+	it := kv.Key()
+	for it.Next() {
+		path = child(path, string(it.Node().Data))
+		pos.set(p, path, it.Node())
+	}
+	pos.addValue(p, path, kv.Value())
+}
+
+// addValue records the positions of the keys and elements of a value.
+func (pos positions) addValue(p *unstable.Parser, path string, value *unstable.Node) {
+	switch value.Kind {
+	case unstable.InlineTable:
+		it := value.Children()
+		for it.Next() {
+			pos.addKeyValue(p, path, it.Node())
+		}
+	case unstable.Array:
+		it := value.Children()
+		for i := 0; it.Next(); i++ {
+			element := index(path, i)
+			pos.set(p, element, it.Node())
+			pos.addValue(p, element, it.Node())
+		}
+	}
+}
+
+// set records the position of the node at path, unless it is already known,
+// such as a table first written as a header and then extended.
+func (pos positions) set(p *unstable.Parser, path string, n *unstable.Node) {
+	if _, ok := pos[path]; ok || n.Raw.Length == 0 {
+		return
+	}
+	start := p.Shape(n.Raw).Start
+	pos[path] = position{line: start.Line, column: start.Column}
+}
+
+// yamlPositions returns the positions of the keys of a YAML document.
+func yamlPositions(content []byte) positions {
+	pos := positions{}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return pos
+	}
+
+	var walk func(path string, n *yaml.Node)
+	walk = func(path string, n *yaml.Node) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(path, c)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key := n.Content[i]
+				p := child(path, key.Value)
+				pos[p] = position{line: key.Line, column: key.Column}
+				walk(p, n.Content[i+1])
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				p := index(path, i)
+				pos[p] = position{line: c.Line, column: c.Column}
+				walk(p, c)
+			}
+		}
+	}
+	walk("", &doc)
+	return pos
+}
@@ -0,0 +1,151 @@
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// checkSchema reports the keys of value that the type t, a declaration type
+// of the config package, does not have, such as misspelled keys, and the
+// values of the wrong type. Such keys are silently ignored when loading.
+func (f *file) checkSchema(path string, value interface{}, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			f.errorf(path, "%s must be a table, not %s", describe(path), typeOf(value))
+			return
+		}
+		fields := fieldsOf(t)
+		for _, key := range sortedKeys(m) {
+			field, ok := fields[key]
+			if !ok {
+				f.errorf(child(path, key), "unknown key %q%s", key, suggest(key, fields))
+				continue
+			}
+			f.checkSchema(child(path, key), m[key], field)
+		}
+	case reflect.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			f.errorf(path, "%s must be a table, not %s", describe(path), typeOf(value))
+			return
+		}
+		for _, key := range sortedKeys(m) {
+			f.checkSchema(child(path, key), m[key], t.Elem())
+		}
+	case reflect.Slice:
+		s, ok := value.([]interface{})
+		if !ok {
+			f.errorf(path, "%s must be an array, not %s", describe(path), typeOf(value))
+			return
+		}
+		for i, element := range s {
+			f.checkSchema(index(path, i), element, t.Elem())
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			f.errorf(path, "%s must be a string, not %s", describe(path), typeOf(value))
+		}
+	case reflect.Int, reflect.Int64:
+		if typeOf(value) != "an integer" {
+			f.errorf(path, "%s must be an integer, not %s", describe(path), typeOf(value))
+		}
+	}
+}
+
+// fieldsOf returns the types of the fields of a struct, by key.
+func fieldsOf(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if key := strings.Split(field.Tag.Get("mapstructure"), ",")[0]; key != "" && key != "-" {
+			fields[key] = field.Type
+		}
+	}
+	return fields
+}
+
+// describe names the key at path in messages.
+func describe(path string) string {
+	if path == "" {
+		return "the file"
+	}
+	return path
+}
+
+// typeOf names the TOML or YAML type of a decoded value.
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case int, int64, uint64:
+		return "an integer"
+	case float64:
+		return "a float"
+	case time.Time:
+		return "a date"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}, map[interface{}]interface{}:
+		return "a table"
+	}
+	return fmt.Sprintf("a %T", value)
+}
+
+// suggest returns a hint naming the key closest to key, if one is close
+// enough to be a typo.
+func suggest(key string, fields map[string]reflect.Type) string {
+	best, distance := "", 3
+	for name := range fields {
+		if d := levenshtein(key, name); d < distance || (d == distance && name < best) {
+			best, distance = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// levenshtein returns the number of edits turning a into b.
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// sortedKeys returns the keys of m in order, so that diagnostics are stable.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/application/bundle"
+	"generic-integration-platform/internal/application/dto"
+	"generic-integration-platform/internal/domain/flow"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/db"
+	"generic-integration-platform/internal/infra/eventstore"
+	"generic-integration-platform/internal/infra/extender"
+	"strings"
+)
+
+// Conflict strategies applied when an imported item already exists.
+const (
+	ConflictFail      = "fail"      // Reject the whole import
+	ConflictSkip      = "skip"      // Keep the existing item
+	ConflictOverwrite = "overwrite" // Replace the existing item
+)
+
+var (
+	// ErrInvalidImport is returned when a bundle cannot be imported as it is.
+	ErrInvalidImport = errors.New("invalid import")
+	// ErrImportConflict is returned when imported items already exist and the
+	// conflict strategy is "fail".
+	ErrImportConflict = errors.New("import conflicts with existing items")
+)
+
+// BundleService exports and imports integrations and flows as bundles.
+type BundleService struct {
+	IntegrationRepository db.IntegrationRepository
+	FlowRepository        db.FlowRepository
+	IntegrationEvents     eventstore.IntegrationEventStore
+	FlowEvents            eventstore.FlowEventStore
+	Extenders             *extender.Registry
+	Transactor            db.Transactor
+}
+
+// NewBundleService creates a new instance of BundleService.
+func NewBundleService(integrationRepo db.IntegrationRepository, flowRepo db.FlowRepository, integrationEvents eventstore.IntegrationEventStore, flowEvents eventstore.FlowEventStore, extenders *extender.Registry, transactor db.Transactor) *BundleService {
+	return &BundleService{
+		IntegrationRepository: integrationRepo,
+		FlowRepository:        flowRepo,
+		IntegrationEvents:     integrationEvents,
+		FlowEvents:            flowEvents,
+		Extenders:             extenders,
+		Transactor:            transactor,
+	}
+}
+
+// Export returns every integration and flow as a bundle.
+func (s *BundleService) Export(ctx context.Context) (*bundle.Bundle, error) {
+	integrations, err := s.IntegrationRepository.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flows, err := s.FlowRepository.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle.New(integrations, flows)
+}
+
+// Import creates the integrations and flows of b, resolving items that already
+// exist, matched by name, with the conflict strategy. Everything is validated
+// first, including by the extender of every integration, so that invalid
+// bundles are rejected before the first write. The writes are applied in one
+// transaction, so that either all of them or none are stored. Databases that
+// cannot run transactions, such as a standalone MongoDB server, get the writes
+// one by one instead: if one fails, the changes already made are reverted on a
+// best-effort basis, and a revert that fails too is reported with the error.
+func (s *BundleService) Import(ctx context.Context, b *bundle.Bundle, conflict string) (dto.ImportResultDTO, error) {
+	var result dto.ImportResultDTO
+
+	switch conflict {
+	case "":
+		conflict = ConflictFail
+	case ConflictFail, ConflictSkip, ConflictOverwrite:
+	default:
+		return result, fmt.Errorf("%w: unknown conflict strategy %q", ErrInvalidImport, conflict)
+	}
+
+	integrations, flows := b.ToDomain()
+
+	storedIntegrations, err := s.IntegrationRepository.GetAll(ctx)
+	if err != nil {
+		return result, err
+	}
+	storedFlows, err := s.FlowRepository.GetAll(ctx)
+	if err != nil {
+		return result, err
+	}
+	existingIntegrations := make(map[string]*integration.Integration, len(storedIntegrations))
+	for _, i := range storedIntegrations {
+		existingIntegrations[i.Name] = i
+	}
+	existingFlows := make(map[string]*flow.Flow, len(storedFlows))
+	for _, f := range storedFlows {
+		existingFlows[f.Name] = f
+	}
+
+	if err := s.validate(ctx, integrations, flows, existingIntegrations); err != nil {
+		return result, err
+	}
+
+	var (
+		steps     []writeStep
+		conflicts []string
+		created   []*integration.Integration
+		updated   []*integration.Integration
+		replaced  []*integration.Integration
+		flowsDone []*flow.Flow
+		flowsNew  []bool
+	)
+
+	for _, i := range integrations {
+		i := i
+		current, exists := existingIntegrations[i.Name]
+		switch {
+		case !exists:
+			steps = append(steps, writeStep{
+				apply: func(ctx context.Context, _ db.FlowRepository, integrations db.IntegrationRepository) error {
+					return integrations.Create(ctx, i)
+				},
+				revert: func(ctx context.Context, _ db.FlowRepository, integrations db.IntegrationRepository) error {
+					return integrations.Delete(ctx, i.ID)
+				},
+			})
+			created = append(created, i)
+			result.Integrations.Created = append(result.Integrations.Created, i.Name)
+		case conflict == ConflictFail:
+			conflicts = append(conflicts, "integration "+i.Name)
+		case conflict == ConflictSkip:
+			result.Integrations.Skipped = append(result.Integrations.Skipped, i.Name)
+		default:
+			// Rotated credentials are not part of bundles.
+			i.ID = current.ID
+			i.Credentials = current.Credentials
+			steps = append(steps, writeStep{
+				apply: func(ctx context.Context, _ db.FlowRepository, integrations db.IntegrationRepository) error {
+					return integrations.Update(ctx, i)
+				},
+				revert: func(ctx context.Context, _ db.FlowRepository, integrations db.IntegrationRepository) error {
+					return integrations.Update(ctx, current)
+				},
+			})
+			updated = append(updated, i)
+			replaced = append(replaced, current)
+			result.Integrations.Updated = append(result.Integrations.Updated, i.Name)
+		}
+	}
+
+	for _, f := range flows {
+		f := f
+		current, exists := existingFlows[f.Name]
+		switch {
+		case !exists:
+			steps = append(steps, writeStep{
+				apply: func(ctx context.Context, flows db.FlowRepository, _ db.IntegrationRepository) error {
+					return flows.Create(ctx, f)
+				},
+				revert: func(ctx context.Context, flows db.FlowRepository, _ db.IntegrationRepository) error {
+					return flows.Delete(ctx, f.ID)
+				},
+			})
+			flowsDone = append(flowsDone, f)
+			flowsNew = append(flowsNew, true)
+			result.Flows.Created = append(result.Flows.Created, f.Name)
+		case conflict == ConflictFail:
+			conflicts = append(conflicts, "flow "+f.Name)
+		case conflict == ConflictSkip:
+			result.Flows.Skipped = append(result.Flows.Skipped, f.Name)
+		default:
+			f.ID = current.ID
+			steps = append(steps, writeStep{
+				apply: func(ctx context.Context, flows db.FlowRepository, _ db.IntegrationRepository) error {
+					return flows.Update(ctx, f)
+				},
+				revert: func(ctx context.Context, flows db.FlowRepository, _ db.IntegrationRepository) error {
+					return flows.Update(ctx, current)
+				},
+			})
+			flowsDone = append(flowsDone, f)
+			flowsNew = append(flowsNew, false)
+			result.Flows.Updated = append(result.Flows.Updated, f.Name)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return dto.ImportResultDTO{}, fmt.Errorf("%w: %s", ErrImportConflict, strings.Join(conflicts, ", "))
+	}
+
+	if err := write(ctx, s.Transactor, s.FlowRepository, s.IntegrationRepository, steps); err != nil {
+		return dto.ImportResultDTO{}, fmt.Errorf("import failed: %w", err)
+	}
+
+	// Extenders of replaced integrations are rebuilt on their next use.
+	for _, i := range replaced {
+		_ = s.Extenders.Remove(ctx, i)
+	}
+
+	for _, i := range created {
+		if err := s.IntegrationEvents.AppendIntegrationCreatedEvent(ctx, eventstore.FromIntegration(i)); err != nil {
+			return result, err
+		}
+	}
+	for _, i := range updated {
+		if err := s.IntegrationEvents.AppendIntegrationUpdatedEvent(ctx, eventstore.FromIntegration(i)); err != nil {
+			return result, err
+		}
+	}
+	for n, f := range flowsDone {
+		var err error
+		if flowsNew[n] {
+			err = s.FlowEvents.AppendFlowCreatedEvent(ctx, eventstore.FromFlow(f))
+		} else {
+			err = s.FlowEvents.AppendFlowUpdatedEvent(ctx, eventstore.FromUpdatedFlow(f))
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// validate checks the imported integrations, with the extender of their type,
+// and flows, and that flows only reference integrations of the bundle or
+// already stored. The extenders built to validate integrations are closed: they
+// are built again on their first use.
+func (s *BundleService) validate(ctx context.Context, integrations []*integration.Integration, flows []*flow.Flow, existing map[string]*integration.Integration) error {
+	var errs []error
+
+	names := make(map[string]bool, len(integrations))
+	for _, i := range integrations {
+		if names[i.Name] {
+			errs = append(errs, fmt.Errorf("integration %s is declared more than once", i.Name))
+			continue
+		}
+		names[i.Name] = true
+
+		if err := i.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("integration %s: %w", i.Name, err))
+			continue
+		}
+		ext, err := s.Extenders.Build(ctx, i)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("integration %s: %w", i.Name, err))
+			continue
+		}
+		_ = ext.Close(ctx)
+	}
+
+	flowNames := make(map[string]bool, len(flows))
+	for _, f := range flows {
+		if flowNames[f.Name] {
+			errs = append(errs, fmt.Errorf("flow %s is declared more than once", f.Name))
+			continue
+		}
+		flowNames[f.Name] = true
+
+		if err := f.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("flow %s: %w", f.Name, err))
+			continue
+		}
+		for _, step := range f.Steps {
+			if step.Kind() != flow.StepTypeIntegration {
+				continue
+			}
+			if _, stored := existing[step.Integration]; !names[step.Integration] && !stored {
+				errs = append(errs, fmt.Errorf("flow %s, step %s: integration %s does not exist", f.Name, step.Key(), step.Integration))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidImport, errors.Join(errs...))
+	}
+	return nil
+}
@@ -6,24 +6,43 @@ import (
 	"generic-integration-platform/internal/application/dto"
 	"generic-integration-platform/internal/domain/flow"
 	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/template"
+	"generic-integration-platform/internal/infra/config"
 	"generic-integration-platform/internal/infra/db"
 	"generic-integration-platform/internal/infra/eventstore"
+	"generic-integration-platform/internal/infra/extender"
+	"generic-integration-platform/internal/infra/script"
+	"generic-integration-platform/internal/infra/transform"
 	"time"
 )
 
+// retryBackoff is the delay before the first retry of a failed step; it grows
+// linearly with every attempt.
+const retryBackoff = 500 * time.Millisecond
+
+// maxStepRuns bounds the steps run by one execution, so that next steps that
+// loop back cannot run a flow forever.
+const maxStepRuns = 1000
+
 // FlowService provides methods for managing flows.
 type FlowService struct {
 	Repository            db.FlowRepository
 	IntegrationRepository db.IntegrationRepository
 	EventStore            eventstore.FlowEventStore
+	Extenders             *extender.Registry
+	Scripts               *script.Runner
+	Env                   string // Environment used when the execution does not request one
 }
 
 // NewFlowService creates a new instance of FlowService.
-func NewFlowService(repository db.FlowRepository, integrationRepo db.IntegrationRepository, es eventstore.FlowEventStore) *FlowService {
+func NewFlowService(repository db.FlowRepository, integrationRepo db.IntegrationRepository, es eventstore.FlowEventStore, extenders *extender.Registry, scripts *script.Runner, cfg *config.Config) *FlowService {
 	return &FlowService{
 		Repository:            repository,
 		IntegrationRepository: integrationRepo,
 		EventStore:            es,
+		Extenders:             extenders,
+		Scripts:               scripts,
+		Env:                   cfg.Env,
 	}
 }
 
@@ -47,6 +66,11 @@ func (s *FlowService) CreateFlow(ctx context.Context, input dto.FlowDTO) (dto.Fl
 	// Convert the input DTO to a domain model
 	newFlow := input.ToDomain()
 
+	// Ensure the flow is valid before saving it
+	if err := newFlow.Validate(); err != nil {
+		return dto.FlowDTO{}, fmt.Errorf("flow validation failed: %w", err)
+	}
+
 	// Save the new flow to the repository
 	if err := s.Repository.Create(ctx, newFlow); err != nil {
 		return dto.FlowDTO{}, err
@@ -83,6 +107,10 @@ func (s *FlowService) UpdateFlow(ctx context.Context, id string, input dto.FlowD
 	updatedFlow := input.ToDomain()
 	updatedFlow.ID = existingFlow.ID
 
+	if err := updatedFlow.Validate(); err != nil {
+		return dto.FlowDTO{}, fmt.Errorf("flow validation failed: %w", err)
+	}
+
 	// Save the updated flow to the repository
 	if err := s.Repository.Update(ctx, updatedFlow); err != nil {
 		return dto.FlowDTO{}, err
@@ -117,8 +145,17 @@ func (s *FlowService) DeleteFlow(ctx context.Context, id string) error {
 	return nil
 }
 
-// ExecuteFlow executes a specific flow by its ID.
-func (s *FlowService) ExecuteFlow(ctx context.Context, id string) (dto.FlowDTO, error) {
+// ExecuteFlow executes a specific flow by its ID with the given input. Its
+// integrations are called with the settings of env, or of the environment of
+// the platform when env is empty.
+func (s *FlowService) ExecuteFlow(ctx context.Context, id string, input map[string]interface{}, env string) (dto.FlowDTO, error) {
+	// Data shared between the steps of this execution
+	execCtx := flow.NewExecutionContext(input)
+	execCtx.Environment = env
+	if execCtx.Environment == "" {
+		execCtx.Environment = s.Env
+	}
+
 	// Retrieve the flow by ID from the repository
 	flow, err := s.Repository.GetByID(ctx, id)
 	if err != nil {
@@ -130,42 +167,58 @@ func (s *FlowService) ExecuteFlow(ctx context.Context, id string) (dto.FlowDTO,
 		return dto.FlowDTO{}, fmt.Errorf("flow validation failed: %w", err)
 	}
 
-	// Logic to execute the flow
-	// Iterating through each step and executing the action
-	for _, step := range flow.Steps {
+	// Run the steps in order, jumping to the next step of the steps that have one
+	for n, runs := 0, 0; n < len(flow.Steps); runs++ {
+		if runs == maxStepRuns {
+			return dto.FlowDTO{}, fmt.Errorf("flow '%s' ran %d steps without finishing, its next steps may loop", flow.Name, maxStepRuns)
+		}
+		step := flow.Steps[n]
+
 		// Execute each step (this could involve calling an external service)
-		_, err := s.executeStep(ctx, step)
+		outputs, skipped, err := s.runStep(ctx, step, execCtx)
 		if err != nil {
 			// If a step fails, append the FlowStepFailedEvent
 			stepFailedEvent := eventstore.FlowStepFailedEvent{
-				FlowID:    flow.ID,
-				StepID:    step.ID,
-				StepName:  step.Name,
-				Error:     err.Error(),
-				Params:    step.Params,
-				Timestamp: time.Now(),
+				FlowID:      flow.ID,
+				StepID:      step.ID,
+				StepName:    step.Name,
+				Error:       err.Error(),
+				Params:      step.Params,
+				Environment: execCtx.Environment,
+				Timestamp:   time.Now(),
 			}
 			_ = s.EventStore.AppendFlowStepFailedEvent(ctx, stepFailedEvent)
 			return dto.FlowDTO{}, fmt.Errorf("failed to execute step '%s' in flow '%s': %w", step.Name, flow.Name, err)
 		}
 
+		// Steps whose condition does not hold are skipped
+		if skipped {
+			_ = s.EventStore.AppendFlowStepSkippedEvent(ctx, eventstore.FromSkippedStep(flow.ID, step))
+			n = flow.Next(n, false)
+			continue
+		}
+		execCtx.Record(step, outputs)
+
 		// Append FlowStepCompletedEvent after successful execution of the step
 		stepCompletedEvent := eventstore.FlowStepCompletedEvent{
-			FlowID:     flow.ID,
-			StepID:     step.ID,
-			StepName:   step.Name,
-			Params:     step.Params,
-			NextStepID: step.NextStepID,
-			Timestamp:  time.Now(),
+			FlowID:      flow.ID,
+			StepID:      step.ID,
+			StepName:    step.Name,
+			Params:      step.Params,
+			NextStepID:  step.NextStepID,
+			Environment: execCtx.Environment,
+			Timestamp:   time.Now(),
 		}
 		_ = s.EventStore.AppendFlowStepCompletedEvent(ctx, stepCompletedEvent)
+		n = flow.Next(n, true)
 	}
 
 	// After executing all steps, append FlowExecutedEvent to the EventStore
 	flowExecutedEvent := eventstore.FlowExecutedEvent{
-		FlowID:    flow.ID,
-		Name:      flow.Name,
-		Timestamp: time.Now(),
+		FlowID:      flow.ID,
+		Name:        flow.Name,
+		Environment: execCtx.Environment,
+		Timestamp:   time.Now(),
 	}
 	if err := s.EventStore.AppendFlowExecutedEvent(ctx, flowExecutedEvent); err != nil {
 		return dto.FlowDTO{}, fmt.Errorf("failed to append FlowExecutedEvent: %w", err)
@@ -175,49 +228,155 @@ func (s *FlowService) ExecuteFlow(ctx context.Context, id string) (dto.FlowDTO,
 	return dto.FromFlowDomain(flow), nil
 }
 
-// executeStep executes a specific step in a flow.
-func (s *FlowService) executeStep(ctx context.Context, step *flow.Step) (map[string]interface{}, error) {
-	// Retrieve the integration associated with the step
-	integration, err := s.IntegrationRepository.GetByID(ctx, step.IntegrationID)
+// GetFlowEvents retrieves up to limit events of a flow, starting at version
+// from, such as the steps completed by its executions.
+func (s *FlowService) GetFlowEvents(ctx context.Context, id string, from, limit uint64) ([]dto.FlowEventDTO, error) {
+	if _, err := s.Repository.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	events, err := s.EventStore.ReadFlowEvents(ctx, id, from, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve integration for step %s: %w", step.ID, err)
+		return nil, err
 	}
 
-	// Execute the action associated with the step using the integration and params
-	result, err := s.performAction(ctx, integration, step.Action, step.Params)
+	result := make([]dto.FlowEventDTO, len(events))
+	for i, e := range events {
+		result[i] = dto.FlowEventDTO{
+			Version:   e.Version,
+			Type:      e.Type,
+			Data:      e.Data,
+			CreatedAt: e.CreatedAt,
+		}
+	}
+	return result, nil
+}
+
+// runStep evaluates the step condition, executes the step and checks its
+// success criteria. A failed attempt is retried up to the Retries of the step,
+// as long as its retry condition holds. It reports whether the step was skipped.
+func (s *FlowService) runStep(ctx context.Context, step *flow.Step, execCtx *flow.ExecutionContext) (map[string]interface{}, bool, error) {
+	run, err := step.ShouldRun(execCtx.Data())
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute action for step %s: %w", step.ID, err)
+		return nil, false, err
+	}
+	if !run {
+		return nil, true, nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		outputs, err := s.attemptStep(ctx, step, execCtx)
+		if err == nil {
+			return outputs, false, nil
+		}
+		if attempt >= step.Retries {
+			return nil, false, err
+		}
+		retry, retryErr := step.ShouldRetry(execCtx.Data(), outputs, err)
+		if retryErr != nil {
+			return nil, false, fmt.Errorf("%w; evaluating the retry condition failed: %w", err, retryErr)
+		}
+		if !retry {
+			return nil, false, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * retryBackoff):
+		}
 	}
+}
 
-	// If the step is successfully executed, append the FlowStepCompleted event
-	completedEvent := eventstore.FlowStepCompletedEvent{
-		FlowID:     step.ID,
-		StepID:     step.ID,
-		StepName:   step.Name,
-		Params:     step.Params,
-		NextStepID: step.NextStepID,
-		Timestamp:  time.Now(),
+// attemptStep executes the step once and checks its success criteria. The
+// outputs of a step that does not meet them are returned with the error.
+func (s *FlowService) attemptStep(ctx context.Context, step *flow.Step, execCtx *flow.ExecutionContext) (map[string]interface{}, error) {
+	outputs, err := s.executeStep(ctx, step, execCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := step.Succeeded(execCtx.Data(), outputs)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return outputs, fmt.Errorf("success criteria %q not met", step.Success)
+	}
+
+	return outputs, nil
+}
+
+// executeStep executes a specific step in a flow and returns its outputs.
+func (s *FlowService) executeStep(ctx context.Context, step *flow.Step, execCtx *flow.ExecutionContext) (map[string]interface{}, error) {
+	switch step.Kind() {
+	case flow.StepTypeScript:
+		return s.Scripts.Run(ctx, step.Script, execCtx.Data())
+	case flow.StepTypeTransform:
+		return transform.Run(ctx, step.Transforms, execCtx.Data())
+	default:
+		return s.executeIntegrationStep(ctx, step, execCtx)
+	}
+}
+
+// executeIntegrationStep calls the integration action bound to the step.
+func (s *FlowService) executeIntegrationStep(ctx context.Context, step *flow.Step, execCtx *flow.ExecutionContext) (map[string]interface{}, error) {
+	// Retrieve the integration associated with the step, by ID or by name
+	var integration *integration.Integration
+	var err error
+	if step.IntegrationID != "" {
+		integration, err = s.IntegrationRepository.GetByID(ctx, step.IntegrationID)
+	} else {
+		integration, err = s.IntegrationRepository.GetByName(ctx, step.Integration)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve integration for step %s: %w", step.ID, err)
 	}
-	if err := s.EventStore.AppendFlowStepCompletedEvent(ctx, completedEvent); err != nil {
-		return nil, fmt.Errorf("failed to append FlowStepCompletedEvent for step %s: %w", step.ID, err)
+	integration = integration.ForEnvironment(execCtx.Environment)
+
+	// Render the step params against the execution context
+	data := execCtx.Data()
+	params := make(map[string]interface{}, len(step.Params))
+	for key, value := range step.Params {
+		if tpl, ok := value.(string); ok {
+			params[key] = template.RenderValue(tpl, data)
+			continue
+		}
+		params[key] = value
+	}
+	computed, err := step.EvalParams(data)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range computed {
+		params[key] = value
+	}
+
+	// Execute the action associated with the step using the integration and params
+	result, err := s.performAction(ctx, integration, step.Action, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute action for step %s: %w", step.ID, err)
 	}
 
 	return result, nil
 }
 
-// performAction performs the action associated with a step using the provided integration.
+// performAction performs the action associated with a step using the extender
+// registered for the integration type.
 func (s *FlowService) performAction(ctx context.Context, integration *integration.Integration, action string, params map[string]interface{}) (map[string]interface{}, error) {
-	// The actual logic of how the integration performs the action depends on your business needs.
-	// This could involve making an API call, interacting with an external system, etc.
-	// For simplicity, we'll assume this is an HTTP call to the integration's API.
+	ext, err := s.Extenders.Get(ctx, integration)
+	if err != nil {
+		return nil, err
+	}
 
-	// Example: Make an HTTP request to the integration's base URL with the action and parameters
-	/*response, err := integration.ExecuteAction(ctx, action, params)
+	response, err := ext.Execute(ctx, action, params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Process the response and return the result
-	return response, nil*/
-	return nil, nil
+	if result, ok := response.(map[string]interface{}); ok {
+		return result, nil
+	}
+
+	return map[string]interface{}{"result": response}, nil
 }
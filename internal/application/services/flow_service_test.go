@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"generic-integration-platform/internal/application/dto"
+	"generic-integration-platform/internal/infra/db"
+	"generic-integration-platform/internal/infra/eventstore"
+	"generic-integration-platform/internal/infra/extender"
+	"generic-integration-platform/internal/infra/secrets"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordingExtender records the actions it executes and their params. Actions
+// listed in failures fail with their error.
+type recordingExtender struct {
+	fakeExtender
+	actions  []string
+	params   []map[string]interface{}
+	failures map[string]error
+}
+
+func (e *recordingExtender) Execute(ctx context.Context, action string, params map[string]interface{}) (interface{}, error) {
+	e.actions = append(e.actions, action)
+	e.params = append(e.params, params)
+	if err := e.failures[action]; err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "approved"}, nil
+}
+
+// newTestFlowService returns a flow service calling ext for the integration
+// "bank", and the ID of the flow of steps it stored.
+func newTestFlowService(t *testing.T, ext *recordingExtender, steps []dto.StepDTO) (*FlowService, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	extenders := extender.NewRegistry(nil)
+	extenders.Register("fake", func(*secrets.Resolver) extender.IntegrationExtender {
+		return ext
+	})
+	integrations := db.NewMemoryIntegrationRepository()
+	if err := integrations.Create(ctx, testIntegration("bank", "https://api.bank.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &FlowService{
+		Repository:            db.NewMemoryFlowRepository(),
+		IntegrationRepository: integrations,
+		EventStore:            eventstore.NewFlowEventStore(eventstore.NewMemoryLog()),
+		Extenders:             extenders,
+	}
+	for n := range steps {
+		steps[n].Integration = "bank"
+	}
+	created, err := s.CreateFlow(ctx, dto.FlowDTO{Name: "payment", Steps: steps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, created.ID
+}
+
+func TestExecuteFlowNextSteps(t *testing.T) {
+	tests := []struct {
+		name        string
+		steps       []dto.StepDTO
+		wantActions []string
+		wantErr     string
+	}{
+		{
+			name: "following steps",
+			steps: []dto.StepDTO{
+				{Name: "authorize", Action: "authorize"},
+				{Name: "capture", Action: "capture"},
+			},
+			wantActions: []string{"authorize", "capture"},
+		},
+		{
+			name: "next step",
+			steps: []dto.StepDTO{
+				{Name: "authorize", Action: "authorize", NextStepID: "capture"},
+				{Name: "void", Action: "void"},
+				{Name: "capture", Action: "capture"},
+			},
+			wantActions: []string{"authorize", "capture"},
+		},
+		{
+			name: "skipped step",
+			steps: []dto.StepDTO{
+				{Name: "authorize", Action: "authorize", Condition: "false", NextStepID: "capture"},
+				{Name: "void", Action: "void"},
+				{Name: "capture", Action: "capture"},
+			},
+			wantActions: []string{"void", "capture"},
+		},
+		{
+			name: "loop",
+			steps: []dto.StepDTO{
+				{Name: "poll", Action: "poll", NextStepID: "poll"},
+			},
+			wantErr: "may loop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &recordingExtender{}
+			s, id := newTestFlowService(t, ext, tt.steps)
+
+			_, err := s.ExecuteFlow(context.Background(), id, nil, "")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ExecuteFlow() error = %v, want %q", err, tt.wantErr)
+				}
+				if len(ext.actions) != maxStepRuns {
+					t.Errorf("ExecuteFlow() ran %d steps, want %d", len(ext.actions), maxStepRuns)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ext.actions, tt.wantActions) {
+				t.Errorf("ExecuteFlow() executed %v, want %v", ext.actions, tt.wantActions)
+			}
+		})
+	}
+}
+
+func TestExecuteFlowRetryIf(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryIf      string
+		failure      error
+		wantAttempts int
+	}{
+		{name: "no condition", failure: errors.New("declined"), wantAttempts: 2},
+		{name: "condition holds", retryIf: `error.message contains "timeout"`, failure: errors.New("read: timeout"), wantAttempts: 2},
+		{name: "condition does not hold", retryIf: `error.message contains "timeout"`, failure: errors.New("declined"), wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &recordingExtender{failures: map[string]error{"authorize": tt.failure}}
+			s, id := newTestFlowService(t, ext, []dto.StepDTO{
+				{Name: "authorize", Action: "authorize", Retries: 1, RetryIf: tt.retryIf},
+			})
+
+			if _, err := s.ExecuteFlow(context.Background(), id, nil, ""); !errors.Is(err, tt.failure) {
+				t.Fatalf("ExecuteFlow() error = %v, want %v", err, tt.failure)
+			}
+			if len(ext.actions) != tt.wantAttempts {
+				t.Errorf("ExecuteFlow() attempted the step %d times, want %d", len(ext.actions), tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestExecuteFlowParamExpressions(t *testing.T) {
+	ext := &recordingExtender{}
+	s, id := newTestFlowService(t, ext, []dto.StepDTO{
+		{
+			Name:       "authorize",
+			Action:     "authorize",
+			Params:     map[string]interface{}{"reference": "{{input.reference}}"},
+			ParamExprs: map[string]string{"amount": `input.amount * 100`},
+		},
+	})
+
+	input := map[string]interface{}{"reference": "order-1", "amount": 12}
+	if _, err := s.ExecuteFlow(context.Background(), id, input, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"reference": "order-1", "amount": 1200}
+	if len(ext.params) != 1 || !reflect.DeepEqual(ext.params[0], want) {
+		t.Errorf("ExecuteFlow() sent params %v, want %v", ext.params, want)
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/domain/flow"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/db"
+	"generic-integration-platform/internal/infra/eventstore"
+	"log"
+	"sync"
+
+	"go.uber.org/fx"
+)
+
+// FlowSyncService makes the flow repository match the flows declared in
+// definition files.
+type FlowSyncService struct {
+	Repository            db.FlowRepository
+	IntegrationRepository db.IntegrationRepository
+	EventStore            eventstore.FlowEventStore
+
+	mu sync.Mutex // Serializes synchronizations
+}
+
+// NewFlowSyncService creates a new instance of FlowSyncService.
+func NewFlowSyncService(repository db.FlowRepository, integrationRepo db.IntegrationRepository, es eventstore.FlowEventStore) *FlowSyncService {
+	return &FlowSyncService{
+		Repository:            repository,
+		IntegrationRepository: integrationRepo,
+		EventStore:            es,
+	}
+}
+
+// Sync creates or updates the declared flows, matched by name. Every flow is
+// validated, and the integrations its steps reference by name must exist,
+// before any change is made. With prune, stored flows that are not declared
+// are removed.
+func (s *FlowSyncService) Sync(ctx context.Context, declared []*flow.Flow, prune bool) (SyncResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result SyncResult
+
+	if err := s.validate(ctx, declared); err != nil {
+		return result, err
+	}
+
+	stored, err := s.Repository.GetAll(ctx)
+	if err != nil {
+		return result, err
+	}
+	existing := make(map[string]*flow.Flow, len(stored))
+	for _, f := range stored {
+		existing[f.Name] = f
+	}
+
+	for _, f := range declared {
+		current, ok := existing[f.Name]
+		delete(existing, f.Name)
+
+		if !ok {
+			if err := s.Repository.Create(ctx, f); err != nil {
+				return result, fmt.Errorf("failed to create flow %s: %w", f.Name, err)
+			}
+			if err := s.EventStore.AppendFlowCreatedEvent(ctx, eventstore.FromFlow(f)); err != nil {
+				return result, err
+			}
+			result.Created = append(result.Created, f.Name)
+			continue
+		}
+
+		f.ID = current.ID
+		if sameFlow(f, current) {
+			result.Unchanged = append(result.Unchanged, f.Name)
+			continue
+		}
+		if err := s.Repository.Update(ctx, f); err != nil {
+			return result, fmt.Errorf("failed to update flow %s: %w", f.Name, err)
+		}
+		if err := s.EventStore.AppendFlowUpdatedEvent(ctx, eventstore.FromUpdatedFlow(f)); err != nil {
+			return result, err
+		}
+		result.Updated = append(result.Updated, f.Name)
+	}
+
+	if !prune {
+		return result, nil
+	}
+
+	for _, f := range stored {
+		if _, undeclared := existing[f.Name]; !undeclared {
+			continue
+		}
+		if err := s.Repository.Delete(ctx, f.ID); err != nil {
+			return result, fmt.Errorf("failed to delete flow %s: %w", f.Name, err)
+		}
+		if err := s.EventStore.AppendFlowDeletedEvent(ctx, eventstore.FromDeletedFlow(f)); err != nil {
+			return result, err
+		}
+		result.Deleted = append(result.Deleted, f.Name)
+	}
+
+	return result, nil
+}
+
+// validate checks every declared flow, that their names are unique and that
+// the integrations they reference by name exist.
+func (s *FlowSyncService) validate(ctx context.Context, declared []*flow.Flow) error {
+	var errs []error
+	names := make(map[string]bool, len(declared))
+	integrations := make(map[string]error)
+	for _, f := range declared {
+		if names[f.Name] {
+			errs = append(errs, fmt.Errorf("flow %s is declared more than once", f.Name))
+			continue
+		}
+		names[f.Name] = true
+
+		if err := f.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("flow %s: %w", f.Name, err))
+			continue
+		}
+
+		for _, step := range f.Steps {
+			if step.Kind() != flow.StepTypeIntegration || step.IntegrationID != "" {
+				continue
+			}
+			err, checked := integrations[step.Integration]
+			if !checked {
+				_, err = s.IntegrationRepository.GetByName(ctx, step.Integration)
+				integrations[step.Integration] = err
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("flow %s, step %s: integration %s: %w", f.Name, step.Key(), step.Integration, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sameFlow reports whether a and b declare the same flow. Flows are compared
+// through their JSON form, which leaves out compiled expressions and the
+// numeric types MongoDB decodes.
+func sameFlow(a, b *flow.Flow) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// RegisterFlowSync syncs the flows declared in the flow directory when the
+// application starts. It must be invoked after RegisterIntegrationSync, as
+// flows reference the declared integrations.
+func RegisterFlowSync(lc fx.Lifecycle, s *FlowSyncService, cfg *config.Config) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			definitions, err := config.LoadFlowDefinitions(cfg.Flows.Path)
+			if err != nil {
+				return fmt.Errorf("failed to load flows from %s: %w", cfg.Flows.Path, err)
+			}
+
+			declared := make([]*flow.Flow, len(definitions))
+			for i, definition := range definitions {
+				declared[i] = definition.ToDomain()
+			}
+
+			result, err := s.Sync(ctx, declared, cfg.Flows.Prune)
+			if err != nil {
+				return fmt.Errorf("failed to sync flows from %s: %w", cfg.Flows.Path, err)
+			}
+
+			log.Printf("Synced flows from %s: %d created %v, %d updated %v, %d unchanged, %d deleted %v",
+				cfg.Flows.Path, len(result.Created), result.Created, len(result.Updated), result.Updated, len(result.Unchanged), len(result.Deleted), result.Deleted)
+			return nil
+		},
+	})
+}
@@ -3,24 +3,39 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"generic-integration-platform/internal/application/dto"
+	"generic-integration-platform/internal/application/importer"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/secret"
 	"generic-integration-platform/internal/infra/db"
 	"generic-integration-platform/internal/infra/eventstore"
+	"generic-integration-platform/internal/infra/extender"
+	"time"
 )
 
-var ErrIntegrationNotFound = errors.New("integration not found")
+var ErrIntegrationNotFound = db.ErrIntegrationNotFound
+
+// ErrInvalidRotation is returned when a credential rotation is rejected.
+var ErrInvalidRotation = errors.New("invalid credential rotation")
+
+// defaultRotationOverlap is how long the previous credential remains active
+// after a rotation when no end is given.
+const defaultRotationOverlap = 24 * time.Hour
 
 // IntegrationService provides methods for managing integrations.
 type IntegrationService struct {
 	Repository db.IntegrationRepository
 	EventStore eventstore.IntegrationEventStore
+	Extenders  *extender.Registry
 }
 
 // NewIntegrationService creates a new instance of IntegrationService.
-func NewIntegrationService(repository db.IntegrationRepository, store eventstore.IntegrationEventStore) *IntegrationService {
+func NewIntegrationService(repository db.IntegrationRepository, store eventstore.IntegrationEventStore, extenders *extender.Registry) *IntegrationService {
 	return &IntegrationService{
 		Repository: repository,
 		EventStore: store,
+		Extenders:  extenders,
 	}
 }
 
@@ -42,23 +57,65 @@ func (s *IntegrationService) ListIntegrations(ctx context.Context) ([]dto.Integr
 // CreateIntegration adds a new integration to the repository.
 func (s *IntegrationService) CreateIntegration(ctx context.Context, input dto.IntegrationRequestDTO) (dto.IntegrationResponseDTO, error) {
 	newIntegration := input.ToDomain()
+	return s.create(ctx, &newIntegration)
+}
 
-	if err := s.Repository.Create(ctx, &newIntegration); err != nil {
+// ImportOpenAPI creates an integration from an OpenAPI document.
+func (s *IntegrationService) ImportOpenAPI(ctx context.Context, content []byte, opts importer.OpenAPIOptions) (dto.IntegrationResponseDTO, error) {
+	newIntegration, err := importer.FromOpenAPI(content, opts)
+	if err != nil {
 		return dto.IntegrationResponseDTO{}, err
 	}
+	return s.create(ctx, newIntegration)
+}
 
-	if err := s.EventStore.AppendIntegrationCreatedEvent(ctx, eventstore.FromIntegration(&newIntegration)); err != nil {
+// ImportPostman creates an integration from the requests of a Postman collection.
+func (s *IntegrationService) ImportPostman(ctx context.Context, content []byte, opts importer.CaptureOptions) (dto.IntegrationResponseDTO, error) {
+	newIntegration, err := importer.FromPostman(content, opts)
+	if err != nil {
 		return dto.IntegrationResponseDTO{}, err
 	}
+	return s.create(ctx, newIntegration)
+}
 
-	return dto.IntegrationResponseDTO{
-		ID:       newIntegration.ID,
-		Name:     newIntegration.Name,
-		Type:     newIntegration.Type,
-		BaseURL:  newIntegration.BaseURL,
-		AuthType: newIntegration.AuthType,
-		Currency: newIntegration.Currency,
-	}, nil
+// ImportHAR creates an integration from the API calls recorded in a HAR file.
+func (s *IntegrationService) ImportHAR(ctx context.Context, content []byte, opts importer.CaptureOptions) (dto.IntegrationResponseDTO, error) {
+	newIntegration, err := importer.FromHAR(content, opts)
+	if err != nil {
+		return dto.IntegrationResponseDTO{}, err
+	}
+	return s.create(ctx, newIntegration)
+}
+
+// create stores and records a new integration, then activates its extender.
+func (s *IntegrationService) create(ctx context.Context, newIntegration *integration.Integration) (dto.IntegrationResponseDTO, error) {
+	if err := s.store(ctx, newIntegration, s.Repository.Create); err != nil {
+		return dto.IntegrationResponseDTO{}, err
+	}
+
+	if err := s.EventStore.AppendIntegrationCreatedEvent(ctx, eventstore.FromIntegration(newIntegration)); err != nil {
+		return dto.IntegrationResponseDTO{}, err
+	}
+
+	return dto.FromDomain(*newIntegration), nil
+}
+
+// store initializes and validates the extender of i, saves i with save and
+// only then activates the extender, so that a failed write leaves the active
+// extenders unchanged. The extender is keyed by the ID set by save.
+func (s *IntegrationService) store(ctx context.Context, i *integration.Integration, save func(context.Context, *integration.Integration) error) error {
+	ext, err := s.Extenders.Build(ctx, i)
+	if err != nil {
+		return err
+	}
+
+	if err := save(ctx, i); err != nil {
+		_ = ext.Close(ctx)
+		return err
+	}
+
+	s.Extenders.Activate(ctx, i, ext)
+	return nil
 }
 
 // GetIntegrationByID retrieves a specific integration by its ID.
@@ -68,21 +125,23 @@ func (s *IntegrationService) GetIntegrationByID(ctx context.Context, id string)
 		return dto.IntegrationResponseDTO{}, err
 	}
 
-	return dto.IntegrationResponseDTO{
-		ID:       integration.ID,
-		Name:     integration.Name,
-		Type:     integration.Type,
-		BaseURL:  integration.BaseURL,
-		AuthType: integration.AuthType,
-		Currency: integration.Currency,
-	}, nil
+	return dto.FromDomain(*integration), nil
 }
 
-// UpdateIntegration updates an existing integration by its ID.
+// UpdateIntegration updates an existing integration by its ID. Credentials
+// left empty or masked, as the API returns them, and rotated credentials keep
+// their stored values.
 func (s *IntegrationService) UpdateIntegration(ctx context.Context, id string, input dto.IntegrationRequestDTO) (dto.IntegrationResponseDTO, error) {
+	stored, err := s.Repository.GetByID(ctx, id)
+	if err != nil {
+		return dto.IntegrationResponseDTO{}, err
+	}
+
 	updatedIntegration := input.ToDomain()
+	updatedIntegration.ID = id
+	updatedIntegration.KeepSecrets(stored)
 
-	if err := s.Repository.Update(ctx, &updatedIntegration); err != nil {
+	if err := s.store(ctx, &updatedIntegration, s.Repository.Update); err != nil {
 		return dto.IntegrationResponseDTO{}, err
 	}
 
@@ -90,17 +149,61 @@ func (s *IntegrationService) UpdateIntegration(ctx context.Context, id string, i
 		return dto.IntegrationResponseDTO{}, err
 	}
 
-	return dto.IntegrationResponseDTO{
-		ID:       updatedIntegration.ID,
-		Name:     updatedIntegration.Name,
-		Type:     updatedIntegration.Type,
-		BaseURL:  updatedIntegration.BaseURL,
-		AuthType: updatedIntegration.AuthType,
-		Currency: updatedIntegration.Currency,
-	}, nil
+	return dto.FromDomain(updatedIntegration), nil
+}
+
+// RotateCredentials makes input.Value the primary credential of an integration.
+// The previous credential becomes the secondary one and is still used until
+// input.PreviousActiveUntil, so that requests keep succeeding while the
+// provider switches keys.
+func (s *IntegrationService) RotateCredentials(ctx context.Context, id string, input dto.RotateCredentialsDTO) (dto.IntegrationResponseDTO, error) {
+	integration, err := s.Repository.GetByID(ctx, id)
+	if err != nil {
+		return dto.IntegrationResponseDTO{}, err
+	}
+
+	activeFrom := time.Now().UTC()
+	if input.ActiveFrom != nil {
+		activeFrom = input.ActiveFrom.UTC()
+	}
+	previousUntil := activeFrom.Add(defaultRotationOverlap)
+	if input.PreviousActiveUntil != nil {
+		previousUntil = input.PreviousActiveUntil.UTC()
+	}
+
+	if err := integration.RotateCredential(input.Value, activeFrom, previousUntil); err != nil {
+		return dto.IntegrationResponseDTO{}, fmt.Errorf("%w: %v", ErrInvalidRotation, err)
+	}
+
+	if err := s.store(ctx, integration, s.Repository.Update); err != nil {
+		return dto.IntegrationResponseDTO{}, err
+	}
+
+	event := eventstore.IntegrationCredentialsRotatedEvent{
+		IntegrationID:       integration.ID,
+		Name:                integration.Name,
+		Reference:           secret.Redact(input.Value),
+		ActiveFrom:          activeFrom,
+		PreviousActiveUntil: previousUntil,
+		Timestamp:           time.Now(),
+	}
+	if err := s.EventStore.AppendIntegrationCredentialsRotatedEvent(ctx, event); err != nil {
+		return dto.IntegrationResponseDTO{}, err
+	}
+
+	return dto.FromDomain(*integration), nil
 }
 
 // DeleteIntegration removes an integration by its ID.
 func (s *IntegrationService) DeleteIntegration(ctx context.Context, id string) error {
-	return s.Repository.Delete(ctx, id)
+	integration, err := s.Repository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Repository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return s.Extenders.Remove(ctx, integration)
 }
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/db"
+	"generic-integration-platform/internal/infra/eventstore"
+	"generic-integration-platform/internal/infra/extender"
+	"log"
+	"reflect"
+	"sync"
+
+	"go.uber.org/fx"
+)
+
+// SyncResult lists the integrations changed by a synchronization, by name.
+type SyncResult struct {
+	Created   []string
+	Updated   []string
+	Unchanged []string
+	Deleted   []string
+}
+
+// IntegrationSyncService makes the integration repository match the
+// integrations declared in configuration files.
+type IntegrationSyncService struct {
+	Repository db.IntegrationRepository
+	EventStore eventstore.IntegrationEventStore
+	Extenders  *extender.Registry
+	Transactor db.Transactor
+
+	mu sync.Mutex // Serializes synchronizations
+}
+
+// NewIntegrationSyncService creates a new instance of IntegrationSyncService.
+func NewIntegrationSyncService(repository db.IntegrationRepository, store eventstore.IntegrationEventStore, extenders *extender.Registry, transactor db.Transactor) *IntegrationSyncService {
+	return &IntegrationSyncService{
+		Repository: repository,
+		EventStore: store,
+		Extenders:  extenders,
+		Transactor: transactor,
+	}
+}
+
+// syncChange is a declared integration to create or update, with its extender
+// and the stored integration it replaces, if any.
+type syncChange struct {
+	integration *integration.Integration
+	current     *integration.Integration
+	extender    extender.IntegrationExtender
+}
+
+// Sync creates or updates the declared integrations, matched by name. Every
+// integration is validated and its extender built before any change is made,
+// so an invalid declaration leaves the repository and the running extenders
+// untouched. With prune, stored integrations that are not declared are removed.
+// The changes are stored in one transaction, see write, and the new extenders
+// are only activated once they are all stored.
+func (s *IntegrationSyncService) Sync(ctx context.Context, declared []*integration.Integration, prune bool) (SyncResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result SyncResult
+
+	if err := s.validate(declared); err != nil {
+		return result, err
+	}
+
+	stored, err := s.Repository.GetAll(ctx)
+	if err != nil {
+		return result, err
+	}
+	existing := make(map[string]*integration.Integration, len(stored))
+	for _, i := range stored {
+		existing[i.Name] = i
+	}
+
+	var changes []syncChange
+	for _, i := range declared {
+		current, ok := existing[i.Name]
+		delete(existing, i.Name)
+
+		if ok {
+			// Rotated credentials are managed through the API, not declared.
+			i.ID = current.ID
+			i.Credentials = current.Credentials
+			if reflect.DeepEqual(i, current) {
+				result.Unchanged = append(result.Unchanged, i.Name)
+				continue
+			}
+		}
+		changes = append(changes, syncChange{integration: i, current: current})
+	}
+
+	for n, change := range changes {
+		ext, err := s.Extenders.Build(ctx, change.integration)
+		if err != nil {
+			for _, built := range changes[:n] {
+				_ = built.extender.Close(ctx)
+			}
+			return SyncResult{}, err
+		}
+		changes[n].extender = ext
+	}
+
+	var (
+		steps   []writeStep
+		deleted []*integration.Integration
+	)
+	for _, change := range changes {
+		steps = append(steps, change.step())
+	}
+	if prune {
+		for _, i := range stored {
+			if _, undeclared := existing[i.Name]; !undeclared {
+				continue
+			}
+			i := i
+			steps = append(steps, writeStep{
+				apply: func(ctx context.Context, _ db.FlowRepository, integrations db.IntegrationRepository) error {
+					if err := integrations.Delete(ctx, i.ID); err != nil {
+						return fmt.Errorf("failed to delete integration %s: %w", i.Name, err)
+					}
+					return nil
+				},
+				revert: func(ctx context.Context, _ db.FlowRepository, integrations db.IntegrationRepository) error {
+					return integrations.Create(ctx, i)
+				},
+			})
+			deleted = append(deleted, i)
+		}
+	}
+
+	if err := write(ctx, s.Transactor, nil, s.Repository, steps); err != nil {
+		for _, change := range changes {
+			_ = change.extender.Close(ctx)
+		}
+		return SyncResult{}, err
+	}
+
+	for _, change := range changes {
+		s.Extenders.Activate(ctx, change.integration, change.extender)
+		if change.current == nil {
+			result.Created = append(result.Created, change.integration.Name)
+		} else {
+			result.Updated = append(result.Updated, change.integration.Name)
+		}
+	}
+	for _, i := range deleted {
+		if err := s.Extenders.Remove(ctx, i); err != nil {
+			return result, err
+		}
+		result.Deleted = append(result.Deleted, i.Name)
+	}
+
+	for _, change := range changes {
+		event := eventstore.FromIntegration(change.integration)
+		if change.current == nil {
+			err = s.EventStore.AppendIntegrationCreatedEvent(ctx, event)
+		} else {
+			err = s.EventStore.AppendIntegrationUpdatedEvent(ctx, event)
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// step returns the write storing the change.
+func (c syncChange) step() writeStep {
+	i, current := c.integration, c.current
+	if current == nil {
+		return writeStep{
+			apply: func(ctx context.Context, _ db.FlowRepository, integrations db.IntegrationRepository) error {
+				if err := integrations.Create(ctx, i); err != nil {
+					return fmt.Errorf("failed to store integration %s: %w", i.Name, err)
+				}
+				return nil
+			},
+			revert: func(ctx context.Context, _ db.FlowRepository, integrations db.IntegrationRepository) error {
+				return integrations.Delete(ctx, i.ID)
+			},
+		}
+	}
+	return writeStep{
+		apply: func(ctx context.Context, _ db.FlowRepository, integrations db.IntegrationRepository) error {
+			if err := integrations.Update(ctx, i); err != nil {
+				return fmt.Errorf("failed to store integration %s: %w", i.Name, err)
+			}
+			return nil
+		},
+		revert: func(ctx context.Context, _ db.FlowRepository, integrations db.IntegrationRepository) error {
+			return integrations.Update(ctx, current)
+		},
+	}
+}
+
+// validate checks every declared integration and that their names are unique.
+func (s *IntegrationSyncService) validate(declared []*integration.Integration) error {
+	var errs []error
+	names := make(map[string]bool, len(declared))
+	for _, i := range declared {
+		if names[i.Name] {
+			errs = append(errs, fmt.Errorf("integration %s is declared more than once", i.Name))
+			continue
+		}
+		names[i.Name] = true
+
+		if err := i.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("integration %s: %w", i.Name, err))
+			continue
+		}
+		if !s.Extenders.Supports(i.Type) {
+			errs = append(errs, fmt.Errorf("integration %s: %w: %s", i.Name, extender.ErrUnsupportedType, i.Type))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RegisterIntegrationSync syncs the integrations declared in the integration
+// configuration when the application starts and, if enabled, every time the
+// declarations change. A change that cannot be loaded or applied is rejected
+// and the last good configuration stays active. It must be invoked after
+// every integration type has been registered.
+func RegisterIntegrationSync(lc fx.Lifecycle, s *IntegrationSyncService, cfg *config.Config, iCfg *config.IntegrationConfig) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	lc.Append(fx.Hook{
+		OnStart: func(startCtx context.Context) error {
+			result, err := s.Sync(startCtx, iCfg.ToDomain(), cfg.Integrations.Prune)
+			if err != nil {
+				return fmt.Errorf("failed to sync integrations from %s: %w", cfg.Integrations.Path, err)
+			}
+			logSync(cfg.Integrations.Path, result)
+
+			if !cfg.Integrations.Watch {
+				return nil
+			}
+			return config.WatchIntegrationConfig(ctx, cfg.Integrations.Path, func(changed *config.IntegrationConfig, err error) {
+				if err == nil {
+					var result SyncResult
+					if result, err = s.Sync(ctx, changed.ToDomain(), cfg.Integrations.Prune); err == nil {
+						logSync(cfg.Integrations.Path, result)
+						return
+					}
+				}
+				log.Printf("Rejected integration changes in %s, keeping the last good configuration: %v", cfg.Integrations.Path, err)
+			})
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+// logSync logs the outcome of a synchronization.
+func logSync(path string, result SyncResult) {
+	log.Printf("Synced integrations from %s: %d created %v, %d updated %v, %d unchanged, %d deleted %v",
+		path, len(result.Created), result.Created, len(result.Updated), result.Updated, len(result.Unchanged), len(result.Deleted), result.Deleted)
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/db"
+	"generic-integration-platform/internal/infra/eventstore"
+	"generic-integration-platform/internal/infra/extender"
+	"generic-integration-platform/internal/infra/secrets"
+	"testing"
+)
+
+var errWrite = errors.New("write failed")
+
+// fakeExtender records whether it was closed.
+type fakeExtender struct {
+	closed bool
+}
+
+func (e *fakeExtender) Initialize(ctx context.Context, config *integration.Integration) error {
+	return nil
+}
+
+func (e *fakeExtender) Execute(ctx context.Context, action string, params map[string]interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func (e *fakeExtender) Validate(ctx context.Context) error {
+	return nil
+}
+
+func (e *fakeExtender) Close(ctx context.Context) error {
+	e.closed = true
+	return nil
+}
+
+// failingRepository fails the writes of one integration.
+type failingRepository struct {
+	db.IntegrationRepository
+	fail string // Name or ID of the integration whose writes fail
+}
+
+func (r failingRepository) Create(ctx context.Context, i *integration.Integration) error {
+	if i.Name == r.fail || i.ID == r.fail {
+		return errWrite
+	}
+	return r.IntegrationRepository.Create(ctx, i)
+}
+
+func (r failingRepository) Update(ctx context.Context, i *integration.Integration) error {
+	if i.Name == r.fail || i.ID == r.fail {
+		return errWrite
+	}
+	return r.IntegrationRepository.Update(ctx, i)
+}
+
+func (r failingRepository) Delete(ctx context.Context, id string) error {
+	if id == r.fail {
+		return errWrite
+	}
+	return r.IntegrationRepository.Delete(ctx, id)
+}
+
+// failingTransactor fails the writes of one integration in its transactions.
+type failingTransactor struct {
+	db.Transactor
+	fail string // Name or ID of the integration whose writes fail
+}
+
+func (t failingTransactor) Transact(ctx context.Context, fn func(ctx context.Context, flows db.FlowRepository, integrations db.IntegrationRepository) error) error {
+	return t.Transactor.Transact(ctx, func(ctx context.Context, flows db.FlowRepository, integrations db.IntegrationRepository) error {
+		return fn(ctx, flows, failingRepository{IntegrationRepository: integrations, fail: t.fail})
+	})
+}
+
+// testIntegration returns a valid integration of the fake type.
+func testIntegration(name, baseURL string) *integration.Integration {
+	return &integration.Integration{Name: name, Type: "fake", BaseURL: baseURL, AuthType: integration.AuthTypeNone, Currency: "USD"}
+}
+
+func TestIntegrationSyncFailedWrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(s *IntegrationSyncService, fail string)
+	}{
+		{
+			name: "transaction",
+			setup: func(s *IntegrationSyncService, fail string) {
+				s.Transactor = failingTransactor{Transactor: s.Transactor, fail: fail}
+			},
+		},
+		{
+			name: "writes reverted one by one",
+			setup: func(s *IntegrationSyncService, fail string) {
+				s.Transactor = nil
+				s.Repository = failingRepository{IntegrationRepository: s.Repository, fail: fail}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			var built []*fakeExtender
+			extenders := extender.NewRegistry(nil)
+			extenders.Register("fake", func(*secrets.Resolver) extender.IntegrationExtender {
+				e := &fakeExtender{}
+				built = append(built, e)
+				return e
+			})
+
+			flows, integrations := db.NewMemoryFlowRepository(), db.NewMemoryIntegrationRepository()
+			s := NewIntegrationSyncService(integrations, eventstore.NewIntegrationEventStore(eventstore.NewMemoryLog()), extenders, db.NewMemoryTransactor(flows, integrations))
+
+			declared := []*integration.Integration{testIntegration("stripe", "https://api.stripe.com"), testIntegration("adyen", "https://api.adyen.com")}
+			if _, err := s.Sync(ctx, declared, false); err != nil {
+				t.Fatal(err)
+			}
+			before, err := integrations.GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stripe, adyen := before[0], before[1]
+			active := built[0]
+
+			// Update stripe, create paypal, then fail to prune adyen.
+			tt.setup(s, adyen.ID)
+			declared = []*integration.Integration{testIntegration("stripe", "https://api.stripe.com/v2"), testIntegration("paypal", "https://api.paypal.com")}
+			if _, err := s.Sync(ctx, declared, true); !errors.Is(err, errWrite) {
+				t.Fatalf("Sync() error = %v, want %v", err, errWrite)
+			}
+
+			after, err := integrations.GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(after) != 2 || after[0].Name != "stripe" || after[0].BaseURL != stripe.BaseURL || after[1].Name != "adyen" {
+				t.Errorf("integrations after a failed sync = %+v, want the ones stored before", after)
+			}
+
+			if ext, err := extenders.Get(ctx, stripe); err != nil || ext != active || active.closed {
+				t.Errorf("extender of stripe after a failed sync = %v, %v, want the one active before", ext, err)
+			}
+			for _, e := range built[2:] {
+				if !e.closed {
+					t.Error("an extender built by the failed sync was not closed")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"generic-integration-platform/internal/application/dto"
+	"generic-integration-platform/internal/domain/secret"
+	"generic-integration-platform/internal/infra/db"
+	"generic-integration-platform/internal/infra/secrets"
+	"strings"
+)
+
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretService manages the secrets kept in the platform secret store.
+type SecretService struct {
+	Store *secrets.Store
+}
+
+// NewSecretService creates a new instance of SecretService.
+func NewSecretService(store *secrets.Store) *SecretService {
+	return &SecretService{
+		Store: store,
+	}
+}
+
+// ListSecrets returns the stored secrets without their values.
+func (s *SecretService) ListSecrets(ctx context.Context) ([]dto.SecretResponseDTO, error) {
+	stored, err := s.Store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.SecretResponseDTO, 0, len(stored))
+	for _, sec := range stored {
+		responses = append(responses, toSecretResponse(sec))
+	}
+
+	return responses, nil
+}
+
+// PutSecret encrypts and stores a secret, replacing any previous value.
+func (s *SecretService) PutSecret(ctx context.Context, name string, input dto.SecretRequestDTO) (dto.SecretResponseDTO, error) {
+	stored, err := s.Store.Put(ctx, strings.Trim(name, "/"), input.Value)
+	if err != nil {
+		return dto.SecretResponseDTO{}, err
+	}
+
+	return toSecretResponse(stored), nil
+}
+
+// DeleteSecret removes a secret from the store.
+func (s *SecretService) DeleteSecret(ctx context.Context, name string) error {
+	if err := s.Store.Delete(ctx, strings.Trim(name, "/")); err != nil {
+		if errors.Is(err, db.ErrSecretNotFound) {
+			return ErrSecretNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// toSecretResponse describes a stored secret without its value.
+func toSecretResponse(sec *db.Secret) dto.SecretResponseDTO {
+	return dto.SecretResponseDTO{
+		Name:      sec.Name,
+		Reference: secret.SchemeSecret + "://" + sec.Name,
+		UpdatedAt: sec.UpdatedAt,
+	}
+}
@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"generic-integration-platform/internal/application/bundle"
 	"generic-integration-platform/internal/application/dto"
+	"generic-integration-platform/internal/application/importer"
+
+	"go.uber.org/fx"
 )
 
 type IFlowService interface {
@@ -21,8 +25,11 @@ type IFlowService interface {
 	// DeleteFlow removes a flow by its ID.
 	DeleteFlow(ctx context.Context, id string) error
 
-	// ExecuteFlow executes a specific flow by its ID.
-	ExecuteFlow(ctx context.Context, id string) (dto.FlowDTO, error)
+	// ExecuteFlow executes a specific flow by its ID with the given input.
+	ExecuteFlow(ctx context.Context, id string, input map[string]interface{}, env string) (dto.FlowDTO, error)
+
+	// GetFlowEvents retrieves up to limit events of a flow, starting at version from.
+	GetFlowEvents(ctx context.Context, id string, from, limit uint64) ([]dto.FlowEventDTO, error)
 }
 
 type IIntegrationService interface {
@@ -38,6 +45,52 @@ type IIntegrationService interface {
 	// UpdateIntegration updates an existing integration by its ID.
 	UpdateIntegration(ctx context.Context, id string, input dto.IntegrationRequestDTO) (dto.IntegrationResponseDTO, error)
 
+	// ImportOpenAPI creates an integration from the operations of an OpenAPI document.
+	ImportOpenAPI(ctx context.Context, content []byte, opts importer.OpenAPIOptions) (dto.IntegrationResponseDTO, error)
+
+	// ImportPostman creates an integration from the requests of a Postman collection.
+	ImportPostman(ctx context.Context, content []byte, opts importer.CaptureOptions) (dto.IntegrationResponseDTO, error)
+
+	// ImportHAR creates an integration from the API calls recorded in a HAR file.
+	ImportHAR(ctx context.Context, content []byte, opts importer.CaptureOptions) (dto.IntegrationResponseDTO, error)
+
+	// RotateCredentials replaces the credential of an integration, keeping the previous one active for a while.
+	RotateCredentials(ctx context.Context, id string, input dto.RotateCredentialsDTO) (dto.IntegrationResponseDTO, error)
+
 	// DeleteIntegration removes an integration by its ID.
 	DeleteIntegration(ctx context.Context, id string) error
 }
+
+type ISecretService interface {
+	// ListSecrets retrieves the stored secrets, without their values.
+	ListSecrets(ctx context.Context) ([]dto.SecretResponseDTO, error)
+
+	// PutSecret stores a secret under the given name.
+	PutSecret(ctx context.Context, name string, input dto.SecretRequestDTO) (dto.SecretResponseDTO, error)
+
+	// DeleteSecret removes a secret by its name.
+	DeleteSecret(ctx context.Context, name string) error
+}
+
+type IBundleService interface {
+	// Export returns every integration and flow as a bundle.
+	Export(ctx context.Context) (*bundle.Bundle, error)
+
+	// Import creates the integrations and flows of a bundle, resolving existing ones with the conflict strategy.
+	Import(ctx context.Context, b *bundle.Bundle, conflict string) (dto.ImportResultDTO, error)
+}
+
+var Module = fx.Options(
+	fx.Provide(
+		NewFlowService,
+		NewIntegrationService,
+		NewSecretService,
+		NewIntegrationSyncService,
+		NewFlowSyncService,
+		NewBundleService,
+		func(s *FlowService) IFlowService { return s },
+		func(s *IntegrationService) IIntegrationService { return s },
+		func(s *SecretService) ISecretService { return s },
+		func(s *BundleService) IBundleService { return s },
+	),
+)
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/infra/db"
+)
+
+// writeStep is a change to store and how to revert it, with the repositories
+// it writes to.
+type writeStep struct {
+	apply  func(ctx context.Context, flows db.FlowRepository, integrations db.IntegrationRepository) error
+	revert func(ctx context.Context, flows db.FlowRepository, integrations db.IntegrationRepository) error
+}
+
+// write applies the steps in a transaction of transactor, so that either all
+// of them or none are stored. When there is no transactor or the database
+// cannot run transactions, they are applied one by one to flows and
+// integrations instead, and the steps already applied are reverted if one
+// fails, on a best-effort basis: a revert that fails too is reported with
+// the error.
+func write(ctx context.Context, transactor db.Transactor, flows db.FlowRepository, integrations db.IntegrationRepository, steps []writeStep) error {
+	if transactor != nil {
+		err := transactor.Transact(ctx, func(ctx context.Context, flows db.FlowRepository, integrations db.IntegrationRepository) error {
+			for _, step := range steps {
+				if err := step.apply(ctx, flows, integrations); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if !errors.Is(err, db.ErrTransactionsUnsupported) {
+			if err != nil {
+				return fmt.Errorf("%w; the changes were rolled back", err)
+			}
+			return nil
+		}
+	}
+
+	for n, step := range steps {
+		if err := step.apply(ctx, flows, integrations); err != nil {
+			var errs []error
+			for r := n - 1; r >= 0; r-- {
+				if rerr := steps[r].revert(ctx, flows, integrations); rerr != nil {
+					errs = append(errs, rerr)
+				}
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("%w; reverting the changes failed: %w", err, errors.Join(errs...))
+			}
+			return fmt.Errorf("%w; the changes were reverted", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Package expression compiles and evaluates the expressions of flow steps:
+// their conditions, success criteria, retry conditions and computed params.
+//
+// Expressions are evaluated over the execution context, exposed as the
+// variables input, steps, previous_step, output and error, and support
+// arithmetic, comparisons, boolean logic, string functions (upper, lower,
+// trim, split, hasPrefix, ...), the contains/startsWith/endsWith/matches
+// operators and null-safe navigation with ?. and ??:
+//
+//	input.amount * 100 >= 5000 && lower(input.currency) == "usd"
+//	(steps.authorize?.status ?? "pending") == "approved"
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/file"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Kind is the type an expression must evaluate to.
+type Kind int
+
+const (
+	Any    Kind = iota // Any value, e.g. for computed params
+	Bool               // A boolean, e.g. for conditions and success criteria
+	Number             // A number, converted to float64
+	String             // A string
+)
+
+// variables are the names available to every expression. They are declared as
+// maps so that their fields are resolved when the expression is evaluated.
+var variables = map[string]interface{}{
+	"input":         map[string]interface{}{},
+	"steps":         map[string]interface{}{},
+	"previous_step": map[string]interface{}{},
+	"output":        map[string]interface{}{},
+	"error":         map[string]interface{}{},
+}
+
+// Error describes an expression that failed to compile or evaluate.
+type Error struct {
+	Source  string // The expression source
+	Line    int    // Line of the error, starting at 1
+	Column  int    // Column of the error, starting at 1
+	Message string // Description of the error
+}
+
+// Error formats the error with its position.
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("expression %q: %s", e.Source, e.Message)
+	}
+	return fmt.Sprintf("expression %q: line %d, column %d: %s", e.Source, e.Line, e.Column, e.Message)
+}
+
+// Expression is a compiled expression ready to be evaluated.
+type Expression struct {
+	source  string
+	kind    Kind
+	program *vm.Program
+}
+
+// Compile parses and type-checks source, which must evaluate to kind.
+func Compile(source string, kind Kind) (*Expression, error) {
+	options := []expr.Option{expr.Env(variables)}
+	switch kind {
+	case Bool:
+		options = append(options, expr.AsBool())
+	case Number:
+		options = append(options, expr.AsFloat64())
+	case String:
+		options = append(options, expr.AsKind(reflect.String))
+	}
+
+	program, err := expr.Compile(source, options...)
+	if err != nil {
+		return nil, newError(source, err)
+	}
+
+	return &Expression{source: source, kind: kind, program: program}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression over data.
+func (e *Expression) Eval(data map[string]interface{}) (interface{}, error) {
+	env := make(map[string]interface{}, len(variables))
+	for name := range variables {
+		value, ok := data[name].(map[string]interface{})
+		if !ok {
+			value = map[string]interface{}{}
+		}
+		env[name] = value
+	}
+
+	value, err := expr.Run(e.program, env)
+	if err != nil {
+		return nil, newError(e.source, err)
+	}
+
+	return value, nil
+}
+
+// EvalBool evaluates an expression compiled with the Bool kind.
+func (e *Expression) EvalBool(data map[string]interface{}) (bool, error) {
+	value, err := e.Eval(data)
+	if err != nil {
+		return false, err
+	}
+
+	result, ok := value.(bool)
+	if !ok {
+		return false, &Error{Source: e.source, Message: fmt.Sprintf("expected a boolean, got %T", value)}
+	}
+
+	return result, nil
+}
+
+// EvalNumber evaluates an expression compiled with the Number kind.
+func (e *Expression) EvalNumber(data map[string]interface{}) (float64, error) {
+	value, err := e.Eval(data)
+	if err != nil {
+		return 0, err
+	}
+
+	result, ok := value.(float64)
+	if !ok {
+		return 0, &Error{Source: e.source, Message: fmt.Sprintf("expected a number, got %T", value)}
+	}
+
+	return result, nil
+}
+
+// EvalString evaluates an expression compiled with the String kind.
+func (e *Expression) EvalString(data map[string]interface{}) (string, error) {
+	value, err := e.Eval(data)
+	if err != nil {
+		return "", err
+	}
+
+	result, ok := value.(string)
+	if !ok {
+		return "", &Error{Source: e.source, Message: fmt.Sprintf("expected a string, got %T", value)}
+	}
+
+	return result, nil
+}
+
+// newError converts an expr error into an Error carrying its position.
+func newError(source string, err error) error {
+	var fileErr *file.Error
+	if errors.As(err, &fileErr) {
+		return &Error{
+			Source:  source,
+			Line:    fileErr.Line,
+			Column:  fileErr.Column + 1,
+			Message: fileErr.Message,
+		}
+	}
+
+	return &Error{Source: source, Message: err.Error()}
+}
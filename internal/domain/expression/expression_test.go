@@ -0,0 +1,208 @@
+package expression
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		kind       Kind
+		wantErr    bool
+		wantLine   int
+		wantColumn int
+	}{
+		{name: "condition", source: `input.amount > 100 && input.currency == "usd"`, kind: Bool},
+		{name: "null-safe navigation", source: `(steps.authorize?.status ?? "pending") == "approved"`, kind: Bool},
+		{name: "string functions", source: `upper(trim(input.currency))`, kind: Any},
+		{name: "any value", source: `input.amount * 100`, kind: Any},
+		{name: "not a boolean", source: `"approved"`, kind: Bool, wantErr: true},
+		{name: "number", source: `input.amount * 100`, kind: Number},
+		{name: "not a number", source: `"approved"`, kind: Number, wantErr: true},
+		{name: "string", source: `lower(input.currency)`, kind: String},
+		{name: "not a string", source: `input.amount > 100`, kind: String, wantErr: true},
+		{name: "error variable", source: `error.message contains "timeout"`, kind: Bool},
+		{name: "unknown variable", source: `response.status == 200`, kind: Bool, wantErr: true, wantLine: 1, wantColumn: 1},
+		{name: "syntax error", source: `input.amount >`, kind: Bool, wantErr: true, wantLine: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Compile(tt.source, tt.kind)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compile(%q) error = %v, wantErr %v", tt.source, err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				if e.String() != tt.source {
+					t.Errorf("String() = %q, want %q", e.String(), tt.source)
+				}
+				return
+			}
+
+			var exprErr *Error
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("Compile(%q) error = %T, want *Error", tt.source, err)
+			}
+			if exprErr.Source != tt.source {
+				t.Errorf("Error.Source = %q, want %q", exprErr.Source, tt.source)
+			}
+			if tt.wantLine != 0 && exprErr.Line != tt.wantLine {
+				t.Errorf("Error.Line = %d, want %d", exprErr.Line, tt.wantLine)
+			}
+			if tt.wantColumn != 0 && exprErr.Column != tt.wantColumn {
+				t.Errorf("Error.Column = %d, want %d", exprErr.Column, tt.wantColumn)
+			}
+		})
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	data := map[string]interface{}{
+		"input": map[string]interface{}{"amount": 150, "currency": "USD", "card": "4242 4242"},
+		"steps": map[string]interface{}{
+			"authorize": map[string]interface{}{"status": "approved"},
+		},
+		"previous_step": map[string]interface{}{"status": "approved"},
+		"output":        map[string]interface{}{"code": 201},
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		data    map[string]interface{}
+		want    bool
+		wantErr bool
+	}{
+		{name: "arithmetic", source: `input.amount * 100 >= 5000`, data: data, want: true},
+		{name: "string function", source: `lower(input.currency) == "usd"`, data: data, want: true},
+		{name: "operators", source: `input.card startsWith "4242" && input.card contains " "`, data: data, want: true},
+		{name: "matches", source: `input.currency matches "^[A-Z]{3}$"`, data: data, want: true},
+		{name: "step output", source: `steps.authorize.status == "approved"`, data: data, want: true},
+		{name: "previous step", source: `previous_step.status != "approved"`, data: data, want: false},
+		{name: "output", source: `output.code in [200, 201]`, data: data, want: true},
+		{name: "missing step", source: `(steps.capture?.status ?? "pending") == "pending"`, data: data, want: true},
+		{name: "missing variables", source: `input.amount == nil`, data: nil, want: true},
+		{name: "runtime error", source: `input.amount / input.zero > 1`, data: data, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Compile(tt.source, Bool)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := e.EvalBool(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvalBool() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EvalBool() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+	data := map[string]interface{}{
+		"input": map[string]interface{}{"amount": 150, "currency": " usd "},
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   interface{}
+	}{
+		{name: "number", source: `input.amount * 2`, want: 300},
+		{name: "string", source: `upper(trim(input.currency))`, want: "USD"},
+		{name: "missing field", source: `input.missing`, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Compile(tt.source, Any)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := e.Eval(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalNumber(t *testing.T) {
+	data := map[string]interface{}{
+		"input": map[string]interface{}{"amount": 150, "rate": 0.5, "currency": "USD"},
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		want    float64
+		wantErr bool
+	}{
+		{name: "integer", source: `input.amount * 2`, want: 300},
+		{name: "float", source: `input.amount * input.rate`, want: 75},
+		{name: "not a number", source: `input.currency`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Compile(tt.source, Number)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := e.EvalNumber(data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvalNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EvalNumber() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalString(t *testing.T) {
+	data := map[string]interface{}{
+		"input": map[string]interface{}{"amount": 150, "currency": " usd "},
+		"error": map[string]interface{}{"message": "connection timeout"},
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{name: "string function", source: `upper(trim(input.currency))`, want: "USD"},
+		{name: "error message", source: `error.message`, want: "connection timeout"},
+		{name: "not a string", source: `input.amount`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Compile(tt.source, String)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := e.EvalString(data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvalString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EvalString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
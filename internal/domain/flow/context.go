@@ -0,0 +1,46 @@
+package flow
+
+// ExecutionContext holds the data available to the steps of a flow execution.
+type ExecutionContext struct {
+	Input        map[string]interface{}            // Input provided when the flow was executed
+	Steps        map[string]map[string]interface{} // Outputs of the executed steps keyed by step name
+	PreviousStep map[string]interface{}            // Outputs of the last executed step
+	Environment  string                            // Environment of the integrations called, e.g. sandbox or live
+}
+
+// NewExecutionContext creates an ExecutionContext for the given input.
+func NewExecutionContext(input map[string]interface{}) *ExecutionContext {
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+
+	return &ExecutionContext{
+		Input:        input,
+		Steps:        map[string]map[string]interface{}{},
+		PreviousStep: map[string]interface{}{},
+	}
+}
+
+// Record stores the outputs of an executed step.
+func (c *ExecutionContext) Record(step *Step, outputs map[string]interface{}) {
+	if outputs == nil {
+		outputs = map[string]interface{}{}
+	}
+
+	c.Steps[step.Key()] = outputs
+	c.PreviousStep = outputs
+}
+
+// Data returns the context as the data used to render templates and run scripts.
+func (c *ExecutionContext) Data() map[string]interface{} {
+	steps := make(map[string]interface{}, len(c.Steps))
+	for name, outputs := range c.Steps {
+		steps[name] = outputs
+	}
+
+	return map[string]interface{}{
+		"input":         c.Input,
+		"steps":         steps,
+		"previous_step": c.PreviousStep,
+	}
+}
@@ -2,11 +2,12 @@ package flow
 
 import (
 	"errors"
+	"fmt"
 )
 
 // Flow represents a sequence of steps that define the integration process.
 type Flow struct {
-	ID          string  `json:"id,omitempty"`
+	ID          string  `json:"id,omitempty" bson:"_id,omitempty"`
 	Name        string  // The name of the flow
 	Steps       []*Step // List of steps in the flow
 	Description string  // A brief description of the flow
@@ -34,10 +35,38 @@ func (f *Flow) Validate() error {
 	if len(f.Steps) == 0 {
 		return errors.New("flow must contain at least one step")
 	}
+	steps := make(map[string]bool, 2*len(f.Steps))
 	for _, step := range f.Steps {
 		if err := step.Validate(); err != nil {
 			return err
 		}
+		steps[step.ID] = true
+		steps[step.Name] = true
+	}
+	for _, step := range f.Steps {
+		if step.NextStepID != "" && !steps[step.NextStepID] {
+			return fmt.Errorf("step %s: next step %s does not exist", step.Key(), step.NextStepID)
+		}
 	}
 	return nil
 }
+
+// Next returns the index of the step that follows the step at index n, or
+// len(f.Steps) when the flow is over. A step that ran continues with its next
+// step, matched by ID or name, if it has one; other steps continue with the
+// step declared after them.
+func (f *Flow) Next(n int, ran bool) int {
+	if ran && f.Steps[n].NextStepID != "" {
+		for i, step := range f.Steps {
+			if step.ID == f.Steps[n].NextStepID {
+				return i
+			}
+		}
+		for i, step := range f.Steps {
+			if step.Name == f.Steps[n].NextStepID {
+				return i
+			}
+		}
+	}
+	return n + 1
+}
@@ -0,0 +1,129 @@
+package flow
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// scriptStep returns a valid script step named name.
+func scriptStep(name string) *Step {
+	return &Step{Name: name, Type: StepTypeScript, Script: "return {}"}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    func(s *Step)
+		wantErr bool
+	}{
+		{name: "valid", step: func(s *Step) {}},
+		{name: "retry condition", step: func(s *Step) { s.Retries, s.RetryIf = 2, `error.message contains "timeout"` }},
+		{name: "retry condition without retries", step: func(s *Step) { s.RetryIf = `error.message contains "timeout"` }, wantErr: true},
+		{name: "retry condition not a boolean", step: func(s *Step) { s.Retries, s.RetryIf = 2, `"timeout"` }, wantErr: true},
+		{name: "param expression", step: func(s *Step) { s.ParamExprs = map[string]string{"amount": `input.amount * 100`} }},
+		{name: "invalid param expression", step: func(s *Step) { s.ParamExprs = map[string]string{"amount": `input.amount *`} }, wantErr: true},
+		{
+			name: "param set twice",
+			step: func(s *Step) {
+				s.Params = map[string]interface{}{"amount": 1}
+				s.ParamExprs = map[string]string{"amount": `input.amount`}
+			},
+			wantErr: true,
+		},
+		{name: "next step by name", step: func(s *Step) { s.NextStepID = "capture" }},
+		{name: "unknown next step", step: func(s *Step) { s.NextStepID = "refund" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := scriptStep("authorize")
+			tt.step(step)
+			f := NewFlow("payment", "", []*Step{step, scriptStep("capture")})
+
+			if err := f.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	f := NewFlow("payment", "", []*Step{
+		{ID: "1", Name: "authorize", NextStepID: "capture"},
+		{ID: "2", Name: "void"},
+		{ID: "3", Name: "capture", NextStepID: "1"},
+	})
+
+	tests := []struct {
+		name string
+		n    int
+		ran  bool
+		want int
+	}{
+		{name: "next step by name", n: 0, ran: true, want: 2},
+		{name: "next step by ID", n: 2, ran: true, want: 0},
+		{name: "skipped step", n: 0, ran: false, want: 1},
+		{name: "no next step", n: 1, ran: true, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.Next(tt.n, tt.ran); got != tt.want {
+				t.Errorf("Next(%d, %v) = %d, want %d", tt.n, tt.ran, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	data := map[string]interface{}{"input": map[string]interface{}{"attempts": 3}}
+
+	tests := []struct {
+		name    string
+		retryIf string
+		outputs map[string]interface{}
+		failure error
+		want    bool
+	}{
+		{name: "no condition", failure: errors.New("declined"), want: true},
+		{name: "error message", retryIf: `error.message contains "timeout"`, failure: errors.New("read: timeout"), want: true},
+		{name: "other error", retryIf: `error.message contains "timeout"`, failure: errors.New("declined"), want: false},
+		{name: "output", retryIf: `output.status == 503`, outputs: map[string]interface{}{"status": 503}, failure: errors.New("success criteria not met"), want: true},
+		{name: "input", retryIf: `input.attempts > 5`, failure: errors.New("declined"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := scriptStep("authorize")
+			step.Retries, step.RetryIf = 2, tt.retryIf
+
+			got, err := step.ShouldRetry(data, tt.outputs, tt.failure)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ShouldRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalParams(t *testing.T) {
+	step := scriptStep("capture")
+	step.ParamExprs = map[string]string{
+		"amount":   `input.amount * 100`,
+		"currency": `upper(input.currency)`,
+		"auth":     `steps.authorize?.id ?? "none"`,
+	}
+	data := map[string]interface{}{"input": map[string]interface{}{"amount": 12, "currency": "usd"}}
+
+	got, err := step.EvalParams(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"amount": 1200, "currency": "USD", "auth": "none"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EvalParams() = %v, want %v", got, want)
+	}
+}
@@ -2,16 +2,43 @@ package flow
 
 import (
 	"errors"
+	"fmt"
+	"generic-integration-platform/internal/domain/expression"
+	"strings"
+
+	"github.com/itchyny/gojq"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Step types supported by the flow engine.
+const (
+	StepTypeIntegration = "integration" // Calls an action of an integration (default)
+	StepTypeScript      = "script"      // Runs a sandboxed script over the execution context
+	StepTypeTransform   = "transform"   // Reshapes the execution context with jq expressions
 )
 
 // Step represents an individual step in a flow of an integration process.
 type Step struct {
 	ID            string                 // Unique identifier for the step
 	Name          string                 // Name of the step
+	Type          string                 // Type of the step (e.g., "integration", "script")
 	IntegrationID string                 // ID of the integration to use
+	Integration   string                 // Name of the integration to use, when IntegrationID is not set
 	Action        string                 // Action to be performed (e.g., "authorize", "capture", etc.)
 	Params        map[string]interface{} // Parameters to be sent to the endpoint
-	NextStepID    string                 // ID of the next step (for transitioning)
+	ParamExprs    map[string]string      // Parameters computed by expressions over the execution context, keyed by name
+	Script        string                 // Source of the script run by script steps
+	Transforms    map[string]string      // jq expressions evaluated by transform steps, keyed by output name
+	Condition     string                 // Expression that must be true for the step to run
+	Success       string                 // Expression over the step output that must be true for the step to succeed
+	NextStepID    string                 // ID or name of the step run after this one, instead of the following step
+	Retries       int                    // Times the step is retried when it fails
+	RetryIf       string                 // Expression over the failure that must be true for the step to be retried
+
+	condition  *expression.Expression            // Compiled Condition, set by Validate
+	success    *expression.Expression            // Compiled Success, set by Validate
+	retryIf    *expression.Expression            // Compiled RetryIf, set by Validate
+	paramExprs map[string]*expression.Expression // Compiled ParamExprs, set by Validate
 }
 
 // New creates a new Step instance.
@@ -26,13 +53,178 @@ func New(id, name, integrationID, action string, params map[string]interface{},
 	}
 }
 
-// Validate checks if the step has the necessary fields set.
+// Kind returns the type of the step, defaulting to an integration step.
+func (s *Step) Kind() string {
+	if s.Type == "" {
+		return StepTypeIntegration
+	}
+	return s.Type
+}
+
+// Key returns the name under which the step outputs are recorded.
+func (s *Step) Key() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.ID
+}
+
+// Validate checks if the step has the necessary fields set and compiles its expressions.
 func (s *Step) Validate() error {
+	if err := s.compileExpressions(); err != nil {
+		return err
+	}
+	if s.Retries < 0 {
+		return fmt.Errorf("retries of step %s cannot be negative", s.Key())
+	}
+	if s.RetryIf != "" && s.Retries == 0 {
+		return fmt.Errorf("step %s has a retry condition but no retries", s.Key())
+	}
+	for name := range s.ParamExprs {
+		if _, ok := s.Params[name]; ok {
+			return fmt.Errorf("param %s of step %s is set both as a value and as an expression", name, s.Key())
+		}
+	}
+
+	switch s.Kind() {
+	case StepTypeIntegration:
+	case StepTypeScript:
+		if strings.TrimSpace(s.Script) == "" {
+			return errors.New("script step must define a script")
+		}
+		if _, err := parse.Parse(strings.NewReader(s.Script), s.Key()); err != nil {
+			return fmt.Errorf("invalid script in step %s: %w", s.Key(), err)
+		}
+		return nil
+	case StepTypeTransform:
+		if len(s.Transforms) == 0 {
+			return errors.New("transform step must define at least one transform")
+		}
+		for output, expression := range s.Transforms {
+			query, err := gojq.Parse(expression)
+			if err != nil {
+				return fmt.Errorf("invalid transform %s in step %s: %w", output, s.Key(), err)
+			}
+			if _, err := gojq.Compile(query); err != nil {
+				return fmt.Errorf("invalid transform %s in step %s: %w", output, s.Key(), err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown step type %q", s.Type)
+	}
+
 	if s.Action == "" {
 		return errors.New("step action cannot be empty")
 	}
-	if s.IntegrationID == "" {
+	if s.IntegrationID == "" && s.Integration == "" {
 		return errors.New("step integration cannot be empty")
 	}
 	return nil
 }
+
+// ShouldRun evaluates the step condition over the execution context data.
+// Steps without a condition always run.
+func (s *Step) ShouldRun(data map[string]interface{}) (bool, error) {
+	if s.Condition == "" {
+		return true, nil
+	}
+	if err := s.compileExpressions(); err != nil {
+		return false, err
+	}
+	return s.condition.EvalBool(data)
+}
+
+// Succeeded evaluates the step success criteria over the execution context
+// data and the step outputs, exposed as "output". Steps without success
+// criteria always succeed.
+func (s *Step) Succeeded(data map[string]interface{}, outputs map[string]interface{}) (bool, error) {
+	if s.Success == "" {
+		return true, nil
+	}
+	if err := s.compileExpressions(); err != nil {
+		return false, err
+	}
+
+	env := make(map[string]interface{}, len(data)+1)
+	for key, value := range data {
+		env[key] = value
+	}
+	env["output"] = outputs
+
+	return s.success.EvalBool(env)
+}
+
+// ShouldRetry evaluates the retry condition of the step over the execution
+// context data, the outputs of the failed attempt, exposed as "output", and
+// its error, exposed as "error" with its message. Steps without a retry
+// condition are retried whatever the error, up to their Retries count.
+func (s *Step) ShouldRetry(data map[string]interface{}, outputs map[string]interface{}, failure error) (bool, error) {
+	if s.RetryIf == "" {
+		return true, nil
+	}
+	if err := s.compileExpressions(); err != nil {
+		return false, err
+	}
+
+	env := make(map[string]interface{}, len(data)+2)
+	for key, value := range data {
+		env[key] = value
+	}
+	env["output"] = outputs
+	env["error"] = map[string]interface{}{"message": failure.Error()}
+
+	return s.retryIf.EvalBool(env)
+}
+
+// EvalParams evaluates the parameters computed by expressions over the
+// execution context data.
+func (s *Step) EvalParams(data map[string]interface{}) (map[string]interface{}, error) {
+	if err := s.compileExpressions(); err != nil {
+		return nil, err
+	}
+
+	params := make(map[string]interface{}, len(s.paramExprs))
+	for name, e := range s.paramExprs {
+		value, err := e.Eval(data)
+		if err != nil {
+			return nil, fmt.Errorf("param %s of step %s: %w", name, s.Key(), err)
+		}
+		params[name] = value
+	}
+	return params, nil
+}
+
+// compileExpressions compiles the step expressions once.
+func (s *Step) compileExpressions() error {
+	var err error
+	if s.Condition != "" && (s.condition == nil || s.condition.String() != s.Condition) {
+		if s.condition, err = expression.Compile(s.Condition, expression.Bool); err != nil {
+			return fmt.Errorf("invalid condition in step %s: %w", s.Key(), err)
+		}
+	}
+	if s.Success != "" && (s.success == nil || s.success.String() != s.Success) {
+		if s.success, err = expression.Compile(s.Success, expression.Bool); err != nil {
+			return fmt.Errorf("invalid success criteria in step %s: %w", s.Key(), err)
+		}
+	}
+	if s.RetryIf != "" && (s.retryIf == nil || s.retryIf.String() != s.RetryIf) {
+		if s.retryIf, err = expression.Compile(s.RetryIf, expression.Bool); err != nil {
+			return fmt.Errorf("invalid retry condition in step %s: %w", s.Key(), err)
+		}
+	}
+	if len(s.paramExprs) != len(s.ParamExprs) {
+		s.paramExprs = make(map[string]*expression.Expression, len(s.ParamExprs))
+	}
+	for name, source := range s.ParamExprs {
+		if e := s.paramExprs[name]; e != nil && e.String() == source {
+			continue
+		}
+		e, err := expression.Compile(source, expression.Any)
+		if err != nil {
+			return fmt.Errorf("invalid param %s in step %s: %w", name, s.Key(), err)
+		}
+		s.paramExprs[name] = e
+	}
+	return nil
+}
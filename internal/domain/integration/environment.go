@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Environment overrides the settings of an integration in a named
+// environment, such as the sandbox or the live platform of the provider.
+// Empty fields keep the settings of the integration.
+type Environment struct {
+	BaseURL   string            // The base URL in this environment
+	AuthToken string            // The token or API key in this environment
+	Basic     *Basic            // HTTP Basic credentials in this environment
+	OAuth     *OAuth            // OAuth2 client credentials in this environment
+	HMAC      *HMAC             // Request signing settings in this environment
+	Headers   map[string]string // Headers added to, or replacing, the headers of the integration
+	TLS       *TLS              // Client certificate and trusted CAs in this environment
+}
+
+// Copy returns a deep copy of the environment.
+func (e *Environment) Copy() *Environment {
+	copied := *e
+	if e.Basic != nil {
+		basic := *e.Basic
+		copied.Basic = &basic
+	}
+	if e.OAuth != nil {
+		oauth := *e.OAuth
+		copied.OAuth = &oauth
+	}
+	if e.HMAC != nil {
+		hmac := *e.HMAC
+		copied.HMAC = &hmac
+	}
+	if e.TLS != nil {
+		tls := *e.TLS
+		copied.TLS = &tls
+	}
+	if e.Headers != nil {
+		copied.Headers = make(map[string]string, len(e.Headers))
+		for key, value := range e.Headers {
+			copied.Headers[key] = value
+		}
+	}
+	return &copied
+}
+
+// ForEnvironment returns a copy of the integration with the overrides of the
+// named environment applied, and Environment set to its name. Integrations
+// that do not declare the environment are returned as they are, with their
+// own settings. Rotated credentials only apply to the secret of the
+// integration, so they are dropped when the environment replaces it.
+func (i *Integration) ForEnvironment(name string) *Integration {
+	env, ok := i.Environments[name]
+	if name == "" || !ok || env == nil {
+		return i
+	}
+
+	c := *i
+	c.Environment = name
+	c.Environments = nil
+
+	if env.BaseURL != "" {
+		c.BaseURL = env.BaseURL
+	}
+	if env.AuthToken != "" {
+		c.AuthToken = env.AuthToken
+	}
+	if env.Basic != nil {
+		c.Basic = env.Basic
+	}
+	if env.OAuth != nil {
+		c.OAuth = env.OAuth
+	}
+	if env.HMAC != nil {
+		c.HMAC = env.HMAC
+	}
+	if env.TLS != nil {
+		c.TLS = env.TLS
+	}
+	if len(env.Headers) > 0 {
+		c.Headers = make(map[string]string, len(i.Headers)+len(env.Headers))
+		for key, value := range i.Headers {
+			c.Headers[key] = value
+		}
+		for key, value := range env.Headers {
+			c.Headers[key] = value
+		}
+	}
+	if c.Secret() != i.Secret() {
+		c.Credentials = nil
+	}
+
+	return &c
+}
+
+// validateEnvironments checks that the integration is valid in every
+// environment it declares.
+func (i *Integration) validateEnvironments() error {
+	for name, env := range i.Environments {
+		if name == "" {
+			return errors.New("environment name cannot be empty")
+		}
+		if env == nil {
+			return fmt.Errorf("environment %s cannot be empty", name)
+		}
+		if err := i.ForEnvironment(name).Validate(); err != nil {
+			return fmt.Errorf("environment %s: %w", name, err)
+		}
+	}
+	return nil
+}
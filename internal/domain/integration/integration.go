@@ -2,19 +2,120 @@ package integration
 
 import (
 	"errors"
+	"fmt"
 	"generic-integration-platform/internal/domain/endpoint"
+	"generic-integration-platform/internal/domain/secret"
+	"strings"
+	"time"
+)
+
+// Authentication types supported by integrations.
+const (
+	AuthTypeNone     = "none"      // No credentials, e.g. when mutual TLS is enough
+	AuthTypeBasic    = "basic"     // HTTP Basic authentication
+	AuthTypeToken    = "token"     // Static token sent in the auth header
+	AuthTypeQueryKey = "query_key" // API key sent as a query parameter
+	AuthTypeOAuth    = "oauth"     // OAuth2 client credentials
+	AuthTypeHMAC     = "hmac"      // HMAC request signing
 )
 
 // Integration represents a payment integration with a service provider.
 type Integration struct {
-	ID        string               `json:"id,omitempty"`
-	Name      string               // The name of the integration
-	Type      string               // The type of integration (e.g., REST, gRPC)
-	BaseURL   string               // The base URL for API requests
-	AuthType  string               // The type of authentication (e.g., Bearer, Basic)
-	AuthToken string               // The authentication token
-	Currency  string               // Currency for the transactions
-	Endpoints []*endpoint.Endpoint // List of endpoints associated with this integration
+	ID          string               `json:"id,omitempty" bson:"_id,omitempty"`
+	Name        string               // The name of the integration
+	Type        string               // The type of integration (e.g., REST, gRPC)
+	BaseURL     string               // The base URL for API requests
+	AuthType    string               // The type of authentication (e.g., Bearer, Basic)
+	AuthHeader  string               // The header carrying the credentials (e.g., Authorization)
+	AuthToken   string               // The authentication token, or the API key for "query_key"
+	AuthParam   string               // The query parameter carrying the API key (default "api_key")
+	Basic       *Basic               // Username and password, used when AuthType is "basic"
+	OAuth       *OAuth               // OAuth2 client credentials, used when AuthType is "oauth"
+	HMAC        *HMAC                // Request signing settings, used when AuthType is "hmac"
+	TLS         *TLS                 // Client certificate and trusted CAs, if the provider requires them
+	Credentials *Credentials         // Primary and secondary credentials, set when the credential is rotated
+	Headers     map[string]string    // Headers sent with every request
+	Currency    string               // Currency for the transactions
+	Endpoints   []*endpoint.Endpoint // List of endpoints associated with this integration
+
+	Environments map[string]*Environment // Settings overridden per environment, e.g. sandbox and live
+	Environment  string                  `json:"environment,omitempty" bson:"-"` // The environment applied by ForEnvironment, never stored
+}
+
+// Credentials holds the credentials accepted by the provider while a key is
+// being rotated. Their values replace the secret of the auth type: the token
+// or API key, the basic password, the OAuth client secret or the HMAC key.
+type Credentials struct {
+	Primary   *Credential // The current credential
+	Secondary *Credential // The previous credential, used when the primary is rejected or not active yet
+}
+
+// Credential is a secret, or a reference to it, with its validity window.
+type Credential struct {
+	Value       string    // The secret or a secret reference
+	ActiveFrom  time.Time // When the provider starts accepting it; zero if already accepted
+	ActiveUntil time.Time // When the provider stops accepting it; zero if it does not expire
+}
+
+// Active reports whether the credential is valid at t.
+func (c *Credential) Active(t time.Time) bool {
+	return c != nil && !t.Before(c.ActiveFrom) && (c.ActiveUntil.IsZero() || t.Before(c.ActiveUntil))
+}
+
+// Copy returns a deep copy of the credentials.
+func (c *Credentials) Copy() *Credentials {
+	copied := &Credentials{}
+	if c.Primary != nil {
+		primary := *c.Primary
+		copied.Primary = &primary
+	}
+	if c.Secondary != nil {
+		secondary := *c.Secondary
+		copied.Secondary = &secondary
+	}
+	return copied
+}
+
+// Basic holds the HTTP Basic credentials of an integration.
+type Basic struct {
+	Username string // The username
+	Password string // The password
+}
+
+// HMAC holds the request signing settings of an integration. The canonical
+// string and the header value are templates that can reference {{method}},
+// {{path}}, {{query}}, {{host}}, {{timestamp}}, {{nonce}}, {{body_hash}},
+// {{key_id}} and, for the header value, {{signature}}.
+type HMAC struct {
+	Secret          string // The signing key
+	KeyID           string // Identifier of the key, sent to the provider if required
+	Algorithm       string // Hash algorithm: "sha256" (default), "sha512" or "sha1"
+	Encoding        string // Signature encoding: "hex" (default) or "base64"
+	Canonical       string // Template of the signed string
+	Header          string // Header carrying the signature (default "X-Signature")
+	HeaderFormat    string // Template of the signature header value (default "{{signature}}")
+	TimestampHeader string // Header carrying the timestamp, if any
+	TimestampFormat string // Timestamp format: "unix" (default), "unix_ms" or "rfc3339"
+	NonceHeader     string // Header carrying the nonce, if any
+}
+
+// TLS holds the transport security settings of an integration. Certificates
+// are read from files so that they can be renewed without restarting.
+type TLS struct {
+	CertFile   string   // PEM client certificate presented to the provider
+	KeyFile    string   // PEM private key of the client certificate
+	CAFiles    []string // PEM root CAs trusted in addition to the system pool
+	MinVersion string   // Minimum TLS version: "1.2" (default) or "1.3"
+	ServerName string   // Overrides the server name used for SNI and verification
+}
+
+// OAuth holds the OAuth2 client credentials of an integration.
+type OAuth struct {
+	ClientID     string   // The client identifier issued by the provider
+	ClientSecret string   // The client secret issued by the provider
+	TokenURL     string   // The endpoint issuing access tokens
+	Scopes       []string // Scopes requested with the token
+	AuthStyle    string   // How credentials are sent: "header" (HTTP Basic, default) or "params"
 }
 
 // NewIntegration creates a new Integration instance.
@@ -30,6 +131,139 @@ func NewIntegration(name, integrationType, baseURL, authType, authToken, currenc
 	}
 }
 
+// Secret returns the secret of the auth type, ignoring rotated credentials.
+func (i *Integration) Secret() string {
+	switch i.AuthType {
+	case AuthTypeBasic:
+		if i.Basic != nil {
+			return i.Basic.Password
+		}
+	case AuthTypeOAuth:
+		if i.OAuth != nil {
+			return i.OAuth.ClientSecret
+		}
+	case AuthTypeHMAC:
+		if i.HMAC != nil {
+			return i.HMAC.Secret
+		}
+	case AuthTypeToken, AuthTypeQueryKey:
+		return i.AuthToken
+	}
+	return ""
+}
+
+// WithSecret returns a copy of the integration using value as the secret of
+// its auth type.
+func (i *Integration) WithSecret(value string) *Integration {
+	c := *i
+	switch i.AuthType {
+	case AuthTypeBasic:
+		if i.Basic != nil {
+			basic := *i.Basic
+			basic.Password = value
+			c.Basic = &basic
+		}
+	case AuthTypeOAuth:
+		if i.OAuth != nil {
+			oauth := *i.OAuth
+			oauth.ClientSecret = value
+			c.OAuth = &oauth
+		}
+	case AuthTypeHMAC:
+		if i.HMAC != nil {
+			hmac := *i.HMAC
+			hmac.Secret = value
+			c.HMAC = &hmac
+		}
+	case AuthTypeToken, AuthTypeQueryKey:
+		c.AuthToken = value
+	}
+	return &c
+}
+
+// RotateCredential makes value the primary credential from activeFrom and
+// keeps the current credential as the secondary until previousUntil, so that
+// both are accepted while the provider switches keys.
+func (i *Integration) RotateCredential(value string, activeFrom, previousUntil time.Time) error {
+	if i.AuthType == AuthTypeNone {
+		return errors.New("integrations without credentials cannot be rotated")
+	}
+	if value == "" {
+		return errors.New("credential cannot be empty")
+	}
+	if !previousUntil.After(activeFrom) {
+		return errors.New("the previous credential must remain active after the new one is activated")
+	}
+
+	previous := &Credential{Value: i.Secret()}
+	if i.Credentials != nil && i.Credentials.Primary != nil {
+		current := *i.Credentials.Primary
+		previous = &current
+	}
+	previous.ActiveUntil = previousUntil
+
+	i.Credentials = &Credentials{
+		Primary:   &Credential{Value: value, ActiveFrom: activeFrom},
+		Secondary: previous,
+	}
+
+	return nil
+}
+
+// KeepSecrets copies the credentials of stored into i wherever i leaves them
+// redacted, e.g. when i was read back from the API, which never returns
+// credential values. The rotated credentials of stored are kept as well when i
+// does not set any.
+func (i *Integration) KeepSecrets(stored *Integration) {
+	keepSecret(&i.AuthToken, stored.AuthToken)
+	if i.Basic != nil && stored.Basic != nil {
+		keepSecret(&i.Basic.Password, stored.Basic.Password)
+	}
+	if i.OAuth != nil && stored.OAuth != nil {
+		keepSecret(&i.OAuth.ClientSecret, stored.OAuth.ClientSecret)
+	}
+	if i.HMAC != nil && stored.HMAC != nil {
+		keepSecret(&i.HMAC.Secret, stored.HMAC.Secret)
+	}
+
+	for name, env := range i.Environments {
+		previous := stored.Environments[name]
+		if env == nil || previous == nil {
+			continue
+		}
+		keepSecret(&env.AuthToken, previous.AuthToken)
+		if env.Basic != nil && previous.Basic != nil {
+			keepSecret(&env.Basic.Password, previous.Basic.Password)
+		}
+		if env.OAuth != nil && previous.OAuth != nil {
+			keepSecret(&env.OAuth.ClientSecret, previous.OAuth.ClientSecret)
+		}
+		if env.HMAC != nil && previous.HMAC != nil {
+			keepSecret(&env.HMAC.Secret, previous.HMAC.Secret)
+		}
+	}
+
+	switch {
+	case stored.Credentials == nil:
+	case i.Credentials == nil:
+		i.Credentials = stored.Credentials.Copy()
+	default:
+		if i.Credentials.Primary != nil && stored.Credentials.Primary != nil {
+			keepSecret(&i.Credentials.Primary.Value, stored.Credentials.Primary.Value)
+		}
+		if i.Credentials.Secondary != nil && stored.Credentials.Secondary != nil {
+			keepSecret(&i.Credentials.Secondary.Value, stored.Credentials.Secondary.Value)
+		}
+	}
+}
+
+// keepSecret replaces a redacted value with the stored one.
+func keepSecret(value *string, stored string) {
+	if secret.IsRedacted(*value) {
+		*value = stored
+	}
+}
+
 // AddEndpoint adds a new endpoint to the integration.
 func (i *Integration) AddEndpoint(endpoint *endpoint.Endpoint) {
 	i.Endpoints = append(i.Endpoints, endpoint)
@@ -52,6 +286,144 @@ func (i *Integration) Validate() error {
 	if i.Currency == "" {
 		return errors.New("currency cannot be empty")
 	}
+	switch i.AuthType {
+	case AuthTypeNone:
+	case AuthTypeBasic:
+		if i.Basic == nil || i.Basic.Username == "" {
+			return errors.New("basic username is required for basic auth type")
+		}
+	case AuthTypeToken, AuthTypeQueryKey:
+		if i.AuthToken == "" {
+			return fmt.Errorf("auth token is required for %s auth type", i.AuthType)
+		}
+	case AuthTypeOAuth:
+		if i.OAuth == nil {
+			return errors.New("oauth configuration is required for oauth auth type")
+		}
+		if err := i.OAuth.Validate(); err != nil {
+			return err
+		}
+	case AuthTypeHMAC:
+		if i.HMAC == nil {
+			return errors.New("hmac configuration is required for hmac auth type")
+		}
+		if err := i.HMAC.Validate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported auth type %q", i.AuthType)
+	}
+	if i.TLS != nil {
+		if err := i.TLS.Validate(); err != nil {
+			return err
+		}
+	}
+	return i.validateEnvironments()
+}
+
+// CheckReferences returns an error if the integration, in any of its
+// environments, reads environment variables or files outside allowlist:
+// through the references of its credentials or its TLS certificates.
+func (i *Integration) CheckReferences(allowlist secret.Allowlist) error {
+	if err := i.checkReferences(allowlist); err != nil {
+		return err
+	}
+	for name := range i.Environments {
+		if err := i.ForEnvironment(name).checkReferences(allowlist); err != nil {
+			return fmt.Errorf("environment %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// checkReferences checks the credentials and TLS files of the integration,
+// without its environments, against allowlist.
+func (i *Integration) checkReferences(allowlist secret.Allowlist) error {
+	values := []string{i.AuthToken}
+	if i.Basic != nil {
+		values = append(values, i.Basic.Password)
+	}
+	if i.OAuth != nil {
+		values = append(values, i.OAuth.ClientSecret)
+	}
+	if i.HMAC != nil {
+		values = append(values, i.HMAC.Secret)
+	}
+	if i.Credentials != nil {
+		for _, c := range []*Credential{i.Credentials.Primary, i.Credentials.Secondary} {
+			if c != nil {
+				values = append(values, c.Value)
+			}
+		}
+	}
 
+	for _, value := range values {
+		if err := allowlist.Check(value); err != nil {
+			return err
+		}
+	}
+
+	if i.TLS != nil {
+		files := append([]string{i.TLS.CertFile, i.TLS.KeyFile}, i.TLS.CAFiles...)
+		for _, file := range files {
+			if file == "" {
+				continue
+			}
+			if err := allowlist.CheckFile(file); err != nil {
+				return fmt.Errorf("tls: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks if the TLS configuration is consistent.
+func (t *TLS) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return errors.New("tls client certificate and key must be set together")
+	}
+	switch t.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		return errors.New("tls minimum version must be \"1.2\" or \"1.3\"")
+	}
+	return nil
+}
+
+// Validate checks if the OAuth configuration has the necessary fields set.
+func (o *OAuth) Validate() error {
+	if o.ClientID == "" {
+		return errors.New("oauth client ID cannot be empty")
+	}
+	if o.TokenURL == "" {
+		return errors.New("oauth token URL cannot be empty")
+	}
+	if o.AuthStyle != "" && o.AuthStyle != "header" && o.AuthStyle != "params" {
+		return errors.New("oauth auth style must be \"header\" or \"params\"")
+	}
+	return nil
+}
+
+// Validate checks if the HMAC configuration has the necessary fields set.
+// Algorithm and encoding names are case-insensitive, as in the signer.
+func (h *HMAC) Validate() error {
+	if h.Secret == "" {
+		return errors.New("hmac secret cannot be empty")
+	}
+	switch strings.ToLower(h.Algorithm) {
+	case "", "sha256", "sha512", "sha1":
+	default:
+		return errors.New("hmac algorithm must be \"sha256\", \"sha512\" or \"sha1\"")
+	}
+	switch strings.ToLower(h.Encoding) {
+	case "", "hex", "base64":
+	default:
+		return errors.New("hmac encoding must be \"hex\" or \"base64\"")
+	}
+	switch h.TimestampFormat {
+	case "", "unix", "unix_ms", "rfc3339":
+	default:
+		return errors.New("hmac timestamp format must be \"unix\", \"unix_ms\" or \"rfc3339\"")
+	}
 	return nil
 }
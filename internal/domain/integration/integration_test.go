@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"generic-integration-platform/internal/domain/secret"
+	"strings"
+	"testing"
+)
+
+func TestCheckReferences(t *testing.T) {
+	allowlist := secret.Allowlist{EnvPrefixes: []string{"STRIPE_"}, FileDirs: []string{"/run/secrets/integrations"}}
+
+	tests := []struct {
+		name        string
+		integration Integration
+		wantErr     string
+	}{
+		{
+			name:        "literal token",
+			integration: Integration{AuthToken: "sk_test_123"},
+		},
+		{
+			name:        "allowed variable",
+			integration: Integration{AuthToken: "env://STRIPE_KEY"},
+		},
+		{
+			name:        "variable of the platform",
+			integration: Integration{AuthToken: "env://ENCRYPTION_MASTER_KEY"},
+			wantErr:     "env://ENCRYPTION_MASTER_KEY is not allowed",
+		},
+		{
+			name:        "file outside the directories",
+			integration: Integration{Basic: &Basic{Username: "merchant", Password: "file:///etc/shadow"}},
+			wantErr:     "file:///etc/shadow is not allowed",
+		},
+		{
+			name:        "secondary credential",
+			integration: Integration{Credentials: &Credentials{Primary: &Credential{Value: "env://STRIPE_KEY"}, Secondary: &Credential{Value: "env://HOME"}}},
+			wantErr:     "env://HOME is not allowed",
+		},
+		{
+			name: "tls files inside the directories",
+			integration: Integration{TLS: &TLS{
+				CertFile: "/run/secrets/integrations/bank/client.pem",
+				KeyFile:  "/run/secrets/integrations/bank/client.key",
+				CAFiles:  []string{"/run/secrets/integrations/bank/ca.pem"},
+			}},
+		},
+		{
+			name:        "tls key outside the directories",
+			integration: Integration{TLS: &TLS{CertFile: "/run/secrets/integrations/bank/client.pem", KeyFile: "/etc/ssl/private/server.key"}},
+			wantErr:     "tls: file /etc/ssl/private/server.key is not allowed",
+		},
+		{
+			name:        "tls ca file escaping the directories",
+			integration: Integration{TLS: &TLS{CAFiles: []string{"/run/secrets/integrations/../../etc/passwd"}}},
+			wantErr:     "is not allowed",
+		},
+		{
+			name: "environment credential",
+			integration: Integration{
+				AuthToken:    "env://STRIPE_KEY",
+				Environments: map[string]*Environment{"live": {AuthToken: "env://API_KEY"}},
+			},
+			wantErr: "environment live: secret reference env://API_KEY is not allowed",
+		},
+		{
+			name: "environment tls files",
+			integration: Integration{
+				Environments: map[string]*Environment{"live": {TLS: &TLS{CAFiles: []string{"/root/ca.pem"}}}},
+			},
+			wantErr: "environment live: tls: file /root/ca.pem is not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.integration.CheckReferences(allowlist)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("CheckReferences() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("CheckReferences() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Without an allowlist, only literal values are accepted.
+	if err := (&Integration{AuthToken: "env://STRIPE_KEY"}).CheckReferences(secret.Allowlist{}); err == nil {
+		t.Error("CheckReferences() with an empty allowlist accepted an env:// reference")
+	}
+}
@@ -0,0 +1,71 @@
+package secret
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Allowlist restricts the environment variables and files that env:// and
+// file:// references can read. Integrations are created through the API, so
+// without it any caller could reference the settings of the platform itself,
+// such as env://ENCRYPTION_MASTER_KEY, and have them sent to its own server.
+type Allowlist struct {
+	EnvPrefixes []string // Prefixes of the environment variables env:// references may read
+	FileDirs    []string // Directories whose files file:// references may read
+}
+
+// AllowsEnv reports whether name starts with one of the allowed prefixes.
+func (a Allowlist) AllowsEnv(name string) bool {
+	for _, prefix := range a.EnvPrefixes {
+		if prefix != "" && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsFile reports whether path is an absolute path inside one of the
+// allowed directories, once cleaned of its "." and ".." elements.
+func (a Allowlist) AllowsFile(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	for _, dir := range a.FileDirs {
+		rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFile returns an error if path is a file outside the allowed
+// directories, for settings naming files directly, such as TLS certificates.
+func (a Allowlist) CheckFile(path string) error {
+	if !a.AllowsFile(path) {
+		return fmt.Errorf("file %s is not allowed: it is outside the allowed directories", path)
+	}
+	return nil
+}
+
+// Check returns an error if value is an env:// or file:// reference the
+// allowlist does not allow. Other values are always allowed.
+func (a Allowlist) Check(value string) error {
+	scheme, name, ok := Parse(value)
+	if !ok {
+		return nil
+	}
+
+	switch scheme {
+	case SchemeEnv:
+		if !a.AllowsEnv(name) {
+			return fmt.Errorf("secret reference %s is not allowed: the variable does not have an allowed prefix", value)
+		}
+	case SchemeFile:
+		if !a.AllowsFile(name) {
+			return fmt.Errorf("secret reference %s is not allowed: the file is outside the allowed directories", value)
+		}
+	}
+	return nil
+}
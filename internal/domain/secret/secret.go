@@ -0,0 +1,53 @@
+// Package secret defines the references used by integrations to point to
+// credentials instead of storing them.
+//
+// A reference is a URI whose scheme selects the backend holding the value:
+//
+//	env://STRIPE_KEY            environment variable
+//	file:///run/secrets/stripe  file contents
+//	secret://stripe/live-key    encrypted secret store of the platform
+package secret
+
+import "strings"
+
+// Reference schemes.
+const (
+	SchemeEnv    = "env"
+	SchemeFile   = "file"
+	SchemeSecret = "secret"
+)
+
+// schemes are the schemes recognized as references.
+var schemes = []string{SchemeEnv, SchemeFile, SchemeSecret}
+
+// Parse splits a reference into its scheme and name. ok is false when value is
+// not a reference, i.e. when it is a literal credential.
+func Parse(value string) (scheme, name string, ok bool) {
+	for _, s := range schemes {
+		if name, found := strings.CutPrefix(value, s+"://"); found {
+			return s, name, true
+		}
+	}
+	return "", "", false
+}
+
+// IsReference reports whether value is a secret reference.
+func IsReference(value string) bool {
+	_, _, ok := Parse(value)
+	return ok
+}
+
+// Redact returns value if it is a reference and an empty string otherwise, so
+// that literal credentials never leave the platform.
+func Redact(value string) string {
+	if IsReference(value) {
+		return value
+	}
+	return ""
+}
+
+// IsRedacted reports whether value stands for a credential that was withheld,
+// by Redact or masked by a client: it is empty or only made of asterisks.
+func IsRedacted(value string) bool {
+	return strings.Trim(value, "*") == ""
+}
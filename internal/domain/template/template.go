@@ -0,0 +1,51 @@
+// Package template renders the {{placeholder}} templates used in integration
+// and flow definitions.
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PlaceholderPattern matches placeholders such as {{amount}} or {{input.amount}}.
+var PlaceholderPattern = regexp.MustCompile(`{{\s*([\w.\-]+)\s*}}`)
+
+// Render replaces every placeholder in tpl with the value found in data.
+// Unknown placeholders are replaced with an empty string.
+func Render(tpl string, data map[string]interface{}) string {
+	return PlaceholderPattern.ReplaceAllStringFunc(tpl, func(match string) string {
+		path := PlaceholderPattern.FindStringSubmatch(match)[1]
+		value, ok := Lookup(data, path)
+		if !ok || value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	})
+}
+
+// RenderValue renders tpl, keeping the original type of the value when the
+// template is made of a single placeholder.
+func RenderValue(tpl string, data map[string]interface{}) interface{} {
+	if m := PlaceholderPattern.FindStringSubmatch(tpl); m != nil && m[0] == strings.TrimSpace(tpl) {
+		value, _ := Lookup(data, m[1])
+		return value
+	}
+	return Render(tpl, data)
+}
+
+// Lookup resolves a dotted path such as "response.transaction_id" in data.
+func Lookup(data map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = data
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
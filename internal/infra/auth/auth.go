@@ -0,0 +1,136 @@
+// Package auth implements the authentication strategies used to call
+// integration providers.
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/secrets"
+	"net/http"
+)
+
+const (
+	defaultAuthHeader = "Authorization"
+	defaultAuthParam  = "api_key"
+)
+
+// Authenticator adds the credentials of an integration to outgoing requests.
+type Authenticator interface {
+	// Credential returns the credential to use for the next request. It is
+	// also exposed to endpoint templates as {{auth_token}}.
+	Credential(ctx context.Context) (string, error)
+	// Apply adds credential to req. body is the rendered request payload.
+	Apply(req *http.Request, credential string, body []byte) error
+}
+
+// Refresher is implemented by authenticators whose credentials can be renewed
+// after the provider rejected them.
+type Refresher interface {
+	// Invalidate discards credential so that the next call obtains a new one.
+	Invalidate(credential string)
+}
+
+// New returns the Authenticator for the auth type of config, or nil when the
+// integration does not send credentials. client is used by strategies that
+// call the provider themselves, such as OAuth, and resolver resolves the secret
+// references of the integration every time a credential is needed.
+func New(config *integration.Integration, client *http.Client, resolver *secrets.Resolver) (Authenticator, error) {
+	switch config.AuthType {
+	case integration.AuthTypeNone:
+		return nil, nil
+	case integration.AuthTypeBasic:
+		if config.Basic == nil {
+			return nil, fmt.Errorf("basic credentials are required for %s auth type", config.AuthType)
+		}
+		return &BasicAuth{Username: config.Basic.Username, Password: config.Basic.Password, Secrets: resolver}, nil
+	case integration.AuthTypeToken:
+		return &TokenAuth{Header: config.AuthHeader, Value: config.AuthToken, Secrets: resolver}, nil
+	case integration.AuthTypeQueryKey:
+		return &QueryKeyAuth{Param: config.AuthParam, Value: config.AuthToken, Secrets: resolver}, nil
+	case integration.AuthTypeOAuth:
+		if config.OAuth == nil {
+			return nil, fmt.Errorf("oauth configuration is required for %s auth type", config.AuthType)
+		}
+		return NewTokenSource(config.OAuth, config.AuthHeader, client, resolver), nil
+	case integration.AuthTypeHMAC:
+		if config.HMAC == nil {
+			return nil, fmt.Errorf("hmac configuration is required for %s auth type", config.AuthType)
+		}
+		return NewHMACSigner(config.HMAC, resolver), nil
+	default:
+		return nil, fmt.Errorf("unsupported auth type %q", config.AuthType)
+	}
+}
+
+// BasicAuth sends a username and password with HTTP Basic authentication.
+type BasicAuth struct {
+	Username string            // The username
+	Password string            // The password or a reference to it
+	Secrets  *secrets.Resolver // Resolves the password reference
+}
+
+// Credential returns the base64-encoded "username:password" pair.
+func (b *BasicAuth) Credential(ctx context.Context) (string, error) {
+	password, err := b.Secrets.Resolve(ctx, b.Password)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString([]byte(b.Username + ":" + password)), nil
+}
+
+// Apply sets the Authorization header.
+func (b *BasicAuth) Apply(req *http.Request, credential string, body []byte) error {
+	req.Header.Set(defaultAuthHeader, "Basic "+credential)
+	return nil
+}
+
+// TokenAuth sends a static token in a header, verbatim. The token includes its
+// scheme if the provider expects one, e.g. "Bearer sk_test_...".
+type TokenAuth struct {
+	Header  string            // The header carrying the token (default "Authorization")
+	Value   string            // The token or a reference to it
+	Secrets *secrets.Resolver // Resolves the token reference
+}
+
+// Credential returns the token.
+func (t *TokenAuth) Credential(ctx context.Context) (string, error) {
+	return t.Secrets.Resolve(ctx, t.Value)
+}
+
+// Apply sets the token header.
+func (t *TokenAuth) Apply(req *http.Request, credential string, body []byte) error {
+	header := t.Header
+	if header == "" {
+		header = defaultAuthHeader
+	}
+	req.Header.Set(header, credential)
+	return nil
+}
+
+// QueryKeyAuth sends an API key as a query parameter.
+type QueryKeyAuth struct {
+	Param   string            // The query parameter (default "api_key")
+	Value   string            // The API key or a reference to it
+	Secrets *secrets.Resolver // Resolves the API key reference
+}
+
+// Credential returns the API key.
+func (q *QueryKeyAuth) Credential(ctx context.Context) (string, error) {
+	return q.Secrets.Resolve(ctx, q.Value)
+}
+
+// Apply appends the API key to the request URL.
+func (q *QueryKeyAuth) Apply(req *http.Request, credential string, body []byte) error {
+	param := q.Param
+	if param == "" {
+		param = defaultAuthParam
+	}
+
+	query := req.URL.Query()
+	query.Set(param, credential)
+	req.URL.RawQuery = query.Encode()
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/template"
+	"generic-integration-platform/internal/infra/secrets"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCanonical       = "{{method}}\n{{path}}\n{{timestamp}}\n{{nonce}}\n{{body_hash}}"
+	defaultSignatureHeader = "X-Signature"
+	defaultHeaderFormat    = "{{signature}}"
+)
+
+// HMACSigner signs requests with an HMAC over a canonical string built from
+// the request method, path, timestamp, nonce and body hash.
+type HMACSigner struct {
+	config  *integration.HMAC
+	secrets *secrets.Resolver
+	hash    func() hash.Hash
+	now     func() time.Time
+}
+
+// NewHMACSigner creates an HMACSigner for the given configuration. The signing
+// key is resolved with resolver every time a request is signed.
+func NewHMACSigner(config *integration.HMAC, resolver *secrets.Resolver) *HMACSigner {
+	return &HMACSigner{
+		config:  config,
+		secrets: resolver,
+		hash:    hashFunc(config.Algorithm),
+		now:     time.Now,
+	}
+}
+
+// Sign adds the signature headers to req. body must be the exact payload that
+// will be sent, after templates have been rendered.
+func (s *HMACSigner) Sign(req *http.Request, body []byte) error {
+	key, err := s.secrets.Resolve(req.Context(), s.config.Secret)
+	if err != nil {
+		return err
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+
+	bodyHash := s.hash()
+	bodyHash.Write(body)
+
+	data := map[string]interface{}{
+		"method":    req.Method,
+		"path":      req.URL.EscapedPath(),
+		"query":     req.URL.RawQuery,
+		"host":      req.URL.Host,
+		"timestamp": s.timestamp(),
+		"nonce":     nonce,
+		"body_hash": hex.EncodeToString(bodyHash.Sum(nil)),
+		"key_id":    s.config.KeyID,
+	}
+
+	canonical := s.config.Canonical
+	if canonical == "" {
+		canonical = defaultCanonical
+	}
+
+	mac := hmac.New(s.hash, []byte(key))
+	mac.Write([]byte(template.Render(canonical, data)))
+	data["signature"] = s.encode(mac.Sum(nil))
+
+	header, format := s.config.Header, s.config.HeaderFormat
+	if header == "" {
+		header = defaultSignatureHeader
+	}
+	if format == "" {
+		format = defaultHeaderFormat
+	}
+
+	req.Header.Set(header, template.Render(format, data))
+	if s.config.TimestampHeader != "" {
+		req.Header.Set(s.config.TimestampHeader, data["timestamp"].(string))
+	}
+	if s.config.NonceHeader != "" {
+		req.Header.Set(s.config.NonceHeader, nonce)
+	}
+
+	return nil
+}
+
+// Credential returns an empty credential: requests are signed, not sent with a secret.
+func (s *HMACSigner) Credential(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+// Apply signs req.
+func (s *HMACSigner) Apply(req *http.Request, credential string, body []byte) error {
+	return s.Sign(req, body)
+}
+
+// timestamp formats the current time as configured.
+func (s *HMACSigner) timestamp() string {
+	now := s.now().UTC()
+	switch s.config.TimestampFormat {
+	case "unix_ms":
+		return strconv.FormatInt(now.UnixMilli(), 10)
+	case "rfc3339":
+		return now.Format(time.RFC3339)
+	default:
+		return strconv.FormatInt(now.Unix(), 10)
+	}
+}
+
+// encode encodes the signature as configured.
+func (s *HMACSigner) encode(sum []byte) string {
+	if strings.EqualFold(s.config.Encoding, "base64") {
+		return base64.StdEncoding.EncodeToString(sum)
+	}
+	return hex.EncodeToString(sum)
+}
+
+// hashFunc returns the hash constructor for the algorithm name.
+func hashFunc(algorithm string) func() hash.Hash {
+	switch strings.ToLower(algorithm) {
+	case "sha512":
+		return sha512.New
+	case "sha1":
+		return sha1.New
+	default:
+		return sha256.New
+	}
+}
+
+// newNonce returns a random hex string.
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"generic-integration-platform/internal/domain/integration"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHMACSignerSign(t *testing.T) {
+	// RFC 4231 and RFC 2202 test case 2, with the canonical string as the data.
+	const rfcData = "what do ya want for nothing?"
+	// The canonical string of the request below, without the nonce.
+	const canonical = "{{method}}\n{{path}}\n{{timestamp}}\n{{body_hash}}"
+
+	tests := []struct {
+		name    string
+		config  integration.HMAC
+		headers map[string]string
+	}{
+		{
+			name:   "sha256",
+			config: integration.HMAC{Secret: "Jefe", Canonical: rfcData},
+			headers: map[string]string{
+				"X-Signature": "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+			},
+		},
+		{
+			name:   "upper-case sha256",
+			config: integration.HMAC{Secret: "Jefe", Canonical: rfcData, Algorithm: "SHA256", Encoding: "HEX"},
+			headers: map[string]string{
+				"X-Signature": "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+			},
+		},
+		{
+			name:   "sha512",
+			config: integration.HMAC{Secret: "Jefe", Canonical: rfcData, Algorithm: "SHA512"},
+			headers: map[string]string{
+				"X-Signature": "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+			},
+		},
+		{
+			name:   "sha1",
+			config: integration.HMAC{Secret: "Jefe", Canonical: rfcData, Algorithm: "sha1"},
+			headers: map[string]string{
+				"X-Signature": "effcdf6ae5eb2fa2d27416d5f184df9c259a7c79",
+			},
+		},
+		{
+			name:   "request fields",
+			config: integration.HMAC{Secret: "Jefe", Canonical: canonical, TimestampHeader: "X-Timestamp"},
+			headers: map[string]string{
+				"X-Signature": "733b14356f1e73246d2662001828b48dd844e8ba668607b1223474bbde1a13eb",
+				"X-Timestamp": "1700000000",
+			},
+		},
+		{
+			name:   "base64 encoding",
+			config: integration.HMAC{Secret: "Jefe", Canonical: canonical, Encoding: "base64"},
+			headers: map[string]string{
+				"X-Signature": "czsUNW8ecyRtJmIAGCi0jdhE6LpmhgexIjR0u94aE+s=",
+			},
+		},
+		{
+			name:   "unix_ms timestamp",
+			config: integration.HMAC{Secret: "Jefe", Canonical: canonical, TimestampFormat: "unix_ms", TimestampHeader: "X-Timestamp"},
+			headers: map[string]string{
+				"X-Signature": "07fb654db9123a17a07317dfc87d2fa0190529a1f78c96f2f91c55b8a21d263e",
+				"X-Timestamp": "1700000000000",
+			},
+		},
+		{
+			name:   "rfc3339 timestamp",
+			config: integration.HMAC{Secret: "Jefe", Canonical: canonical, TimestampFormat: "rfc3339"},
+			headers: map[string]string{
+				"X-Signature": "a504f080992d6f336ff22326b3c39e05aa232cde7e1149308767a1c19991bed6",
+			},
+		},
+		{
+			name: "header format",
+			config: integration.HMAC{
+				Secret:       "Jefe",
+				Canonical:    rfcData,
+				KeyID:        "key-1",
+				Header:       "Authorization",
+				HeaderFormat: "HMAC keyId={{key_id}},signature={{signature}}",
+			},
+			headers: map[string]string{
+				"Authorization": "HMAC keyId=key-1,signature=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every configuration the signer accepts passes validation.
+			if err := tt.config.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			signer := NewHMACSigner(&tt.config, nil)
+			signer.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+			req, err := http.NewRequest(http.MethodPost, "https://api.example.com/v1/charges", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := signer.Sign(req, []byte(`{"amount":100}`)); err != nil {
+				t.Fatal(err)
+			}
+
+			for header, want := range tt.headers {
+				if got := req.Header.Get(header); got != want {
+					t.Errorf("header %s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHMACSignerNonce(t *testing.T) {
+	config := &integration.HMAC{Secret: "Jefe", NonceHeader: "X-Nonce"}
+	signer := NewHMACSigner(config, nil)
+
+	nonces := make(map[string]bool)
+	signatures := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest(http.MethodPost, "https://api.example.com/v1/charges", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := signer.Sign(req, nil); err != nil {
+			t.Fatal(err)
+		}
+		nonces[req.Header.Get("X-Nonce")] = true
+		signatures[req.Header.Get("X-Signature")] = true
+	}
+
+	if len(nonces) != 3 || len(signatures) != 3 {
+		t.Errorf("got %d nonces and %d signatures for 3 requests, want a new one for each", len(nonces), len(signatures))
+	}
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/secrets"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// refreshMargin is how long before expiry a token is refreshed.
+	refreshMargin = 30 * time.Second
+	// maxRefreshRatio caps the refresh margin for short-lived tokens.
+	maxRefreshRatio = 0.2
+	tokenTimeout    = 15 * time.Second
+)
+
+// Token is an OAuth2 access token.
+type Token struct {
+	AccessToken string    // The access token
+	TokenType   string    // The token type, usually "Bearer"
+	Expiry      time.Time // When the token expires; zero if unknown
+	refreshAt   time.Time
+}
+
+// valid reports whether the token can still be used without refreshing it.
+func (t *Token) valid(now time.Time) bool {
+	return t != nil && t.AccessToken != "" && (t.refreshAt.IsZero() || now.Before(t.refreshAt))
+}
+
+// tokenResponse is the body returned by the token endpoint.
+type tokenResponse struct {
+	AccessToken string      `json:"access_token"`
+	TokenType   string      `json:"token_type"`
+	ExpiresIn   json.Number `json:"expires_in"`
+	Error       string      `json:"error"`
+	Description string      `json:"error_description"`
+}
+
+// TokenSource fetches access tokens with the OAuth2 client credentials grant
+// and caches them until shortly before they expire. Concurrent callers share
+// a single in-flight token request.
+type TokenSource struct {
+	config  *integration.OAuth
+	header  string
+	client  *http.Client
+	secrets *secrets.Resolver
+	now     func() time.Time
+
+	mu    sync.Mutex
+	token *Token
+	group singleflight.Group
+}
+
+// NewTokenSource creates a TokenSource for the given OAuth configuration. The
+// token is sent in header, "Authorization" if empty, and the client secret is
+// resolved with resolver before every token request.
+func NewTokenSource(config *integration.OAuth, header string, client *http.Client, resolver *secrets.Resolver) *TokenSource {
+	if client == nil {
+		client = &http.Client{Timeout: tokenTimeout}
+	}
+
+	return &TokenSource{
+		config:  config,
+		header:  header,
+		client:  client,
+		secrets: resolver,
+		now:     time.Now,
+	}
+}
+
+// Token returns a valid access token, fetching a new one when the cached token
+// is missing or about to expire.
+func (s *TokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	token := s.token
+	s.mu.Unlock()
+
+	if token.valid(s.now()) {
+		return token, nil
+	}
+
+	result, err, _ := s.group.Do("token", func() (interface{}, error) {
+		// Another caller may have refreshed the token while we were waiting.
+		s.mu.Lock()
+		current := s.token
+		s.mu.Unlock()
+		if current != token && current.valid(s.now()) {
+			return current, nil
+		}
+
+		fresh, err := s.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		s.token = fresh
+		s.mu.Unlock()
+
+		return fresh, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(*Token), nil
+}
+
+// Credential returns a valid access token.
+func (s *TokenSource) Credential(ctx context.Context) (string, error) {
+	token, err := s.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// Apply sends the access token in the auth header, prefixed by its type.
+func (s *TokenSource) Apply(req *http.Request, credential string, body []byte) error {
+	tokenType := "Bearer"
+	s.mu.Lock()
+	if s.token != nil && s.token.AccessToken == credential {
+		tokenType = s.token.TokenType
+	}
+	s.mu.Unlock()
+
+	header := s.header
+	if header == "" {
+		header = defaultAuthHeader
+	}
+	req.Header.Set(header, tokenType+" "+credential)
+
+	return nil
+}
+
+// Invalidate drops the cached token if it is accessToken, for instance after
+// the provider rejected it with a 401, so that the next call fetches a new one.
+func (s *TokenSource) Invalidate(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && s.token.AccessToken == accessToken {
+		s.token = nil
+	}
+}
+
+// fetch requests a new token from the token endpoint.
+func (s *TokenSource) fetch(ctx context.Context) (*Token, error) {
+	clientSecret, err := s.secrets.Resolve(ctx, s.config.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	if s.config.AuthStyle == "params" {
+		form.Set("client_id", s.config.ClientID)
+		form.Set("client_secret", clientSecret)
+	}
+
+	// The token request must not be cancelled by the caller that triggered it,
+	// since other callers may be waiting for the same request.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.config.AuthStyle != "params" {
+		req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || tr.Error != "" {
+		if tr.Error != "" {
+			return nil, fmt.Errorf("token request failed with status %d: %s %s", resp.StatusCode, tr.Error, tr.Description)
+		}
+		return nil, fmt.Errorf("token request failed with status %d", resp.StatusCode)
+	}
+	if tr.AccessToken == "" {
+		return nil, errors.New("token response did not include an access token")
+	}
+
+	token := &Token{AccessToken: tr.AccessToken, TokenType: tr.TokenType}
+	if token.TokenType == "" {
+		token.TokenType = "Bearer"
+	}
+
+	if seconds, err := tr.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		lifetime := time.Duration(seconds) * time.Second
+		margin := refreshMargin
+		if limit := time.Duration(float64(lifetime) * maxRefreshRatio); margin > limit {
+			margin = limit
+		}
+		now := s.now()
+		token.Expiry = now.Add(lifetime)
+		token.refreshAt = token.Expiry.Add(-margin)
+	}
+
+	return token, nil
+}
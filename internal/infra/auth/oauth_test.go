@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/secrets"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenServer is a token endpoint issuing the tokens "token-1", "token-2", ...
+// that expire in expiresIn seconds, and counting the requests it receives.
+type tokenServer struct {
+	*httptest.Server
+	requests  atomic.Int32
+	expiresIn int
+	delay     time.Duration // Time taken to answer, so that concurrent requests overlap
+	status    int           // Status of the responses, 200 if zero
+}
+
+// newTokenServer starts a token server closed at the end of the test.
+func newTokenServer(t *testing.T, expiresIn int) *tokenServer {
+	s := &tokenServer{expiresIn: expiresIn}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := s.requests.Add(1)
+		time.Sleep(s.delay)
+
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+			http.Error(w, `{"error": "unsupported_grant_type"}`, http.StatusBadRequest)
+			return
+		}
+		if id, secret, _ := r.BasicAuth(); (id != "client" || secret != "s3cr3t") && r.PostForm.Get("client_secret") != "s3cr3t" {
+			http.Error(w, `{"error": "invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		if s.status != 0 {
+			w.WriteHeader(s.status)
+			fmt.Fprint(w, `{"error": "temporarily_unavailable", "error_description": "try again later"}`)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": %d}`, n, s.expiresIn)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newTestTokenSource returns a token source of server whose clock is now.
+func newTestTokenSource(server *tokenServer, now *time.Time) *TokenSource {
+	oauth := &integration.OAuth{ClientID: "client", ClientSecret: "s3cr3t", TokenURL: server.URL, Scopes: []string{"payments"}}
+	s := NewTokenSource(oauth, "", server.Client(), secrets.NewResolver(&config.Config{}, nil))
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestTokenSourceSingleflight(t *testing.T) {
+	server := newTokenServer(t, 3600)
+	server.delay = 50 * time.Millisecond
+	now := time.Now()
+	s := newTestTokenSource(server, &now)
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 20)
+	errs := make([]error, len(tokens))
+	for n := range tokens {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			tokens[n], errs[n] = s.Credential(context.Background())
+		}(n)
+	}
+	wg.Wait()
+
+	for n := range tokens {
+		if errs[n] != nil || tokens[n] != "token-1" {
+			t.Errorf("Credential() = %q, %v, want token-1", tokens[n], errs[n])
+		}
+	}
+	if got := server.requests.Load(); got != 1 {
+		t.Errorf("token server received %d requests, want 1", got)
+	}
+
+	// The token is cached until it is about to expire.
+	if token, err := s.Credential(context.Background()); err != nil || token != "token-1" {
+		t.Errorf("cached Credential() = %q, %v, want token-1", token, err)
+	}
+	if got := server.requests.Load(); got != 1 {
+		t.Errorf("token server received %d requests after caching, want 1", got)
+	}
+}
+
+func TestTokenSourceRefresh(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn int
+		elapsed   time.Duration
+		want      string
+	}{
+		{name: "cached", expiresIn: 3600, elapsed: 3600*time.Second - refreshMargin - time.Second, want: "token-1"},
+		{name: "refreshed before expiry", expiresIn: 3600, elapsed: 3600*time.Second - refreshMargin, want: "token-2"},
+		{name: "short-lived token cached", expiresIn: 10, elapsed: 7 * time.Second, want: "token-1"},
+		{name: "short-lived token refreshed", expiresIn: 10, elapsed: 8 * time.Second, want: "token-2"},
+		{name: "no expiry", expiresIn: 0, elapsed: 24 * time.Hour, want: "token-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTokenServer(t, tt.expiresIn)
+			now := time.Now()
+			s := newTestTokenSource(server, &now)
+
+			if _, err := s.Credential(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			now = now.Add(tt.elapsed)
+
+			token, err := s.Credential(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != tt.want {
+				t.Errorf("Credential() after %v = %q, want %q", tt.elapsed, token, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenSourceInvalidate(t *testing.T) {
+	server := newTokenServer(t, 3600)
+	now := time.Now()
+	s := newTestTokenSource(server, &now)
+	ctx := context.Background()
+
+	if _, err := s.Credential(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalidating a token that is no longer cached keeps the current one.
+	s.Invalidate("token-0")
+	if token, err := s.Credential(ctx); err != nil || token != "token-1" {
+		t.Errorf("Credential() after invalidating another token = %q, %v, want token-1", token, err)
+	}
+
+	s.Invalidate("token-1")
+	if token, err := s.Credential(ctx); err != nil || token != "token-2" {
+		t.Errorf("Credential() after Invalidate() = %q, %v, want token-2", token, err)
+	}
+}
+
+func TestTokenSourceErrors(t *testing.T) {
+	server := newTokenServer(t, 3600)
+	server.status = http.StatusServiceUnavailable
+	now := time.Now()
+	s := newTestTokenSource(server, &now)
+	ctx := context.Background()
+
+	_, err := s.Credential(ctx)
+	if err == nil || !strings.Contains(err.Error(), "temporarily_unavailable") {
+		t.Fatalf("Credential() error = %v, want the error of the token server", err)
+	}
+
+	// Failures are not cached.
+	server.status = 0
+	if token, err := s.Credential(ctx); err != nil || token != "token-2" {
+		t.Errorf("Credential() after a failure = %q, %v, want token-2", token, err)
+	}
+}
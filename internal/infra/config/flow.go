@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"generic-integration-platform/internal/domain/flow"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FlowDefinition represents a flow declared in a file. Inputs optionally
+// lists the input fields the steps use, so that the linter can check them
+type FlowDefinition struct {
+	Name        string       `mapstructure:"name" toml:"name,omitempty" yaml:"name,omitempty"`
+	Description string       `mapstructure:"description" toml:"description,omitempty" yaml:"description,omitempty"`
+	Inputs      []string     `mapstructure:"inputs" toml:"inputs,omitempty" yaml:"inputs,omitempty"`
+	Steps       []StepConfig `mapstructure:"steps" toml:"steps,omitempty" yaml:"steps,omitempty"`
+}
+
+// StepConfig represents a step of a declared flow. Steps reference their
+// integration by name, so that files do not depend on generated IDs
+type StepConfig struct {
+	ID          string                 `mapstructure:"id" toml:"id,omitempty" yaml:"id,omitempty"`
+	Name        string                 `mapstructure:"name" toml:"name,omitempty" yaml:"name,omitempty"`
+	Type        string                 `mapstructure:"type" toml:"type,omitempty" yaml:"type,omitempty"`
+	Integration string                 `mapstructure:"integration" toml:"integration,omitempty" yaml:"integration,omitempty"`
+	Action      string                 `mapstructure:"action" toml:"action,omitempty" yaml:"action,omitempty"`
+	Params      map[string]interface{} `mapstructure:"params" toml:"params,omitempty" yaml:"params,omitempty"`
+	ParamExprs  map[string]string      `mapstructure:"param_expressions" toml:"param_expressions,omitempty" yaml:"param_expressions,omitempty"`
+	Script      string                 `mapstructure:"script" toml:"script,omitempty" yaml:"script,omitempty"`
+	Transforms  map[string]string      `mapstructure:"transforms" toml:"transforms,omitempty" yaml:"transforms,omitempty"`
+	Condition   string                 `mapstructure:"condition" toml:"condition,omitempty" yaml:"condition,omitempty"`
+	Success     string                 `mapstructure:"success" toml:"success,omitempty" yaml:"success,omitempty"`
+	Next        string                 `mapstructure:"next" toml:"next,omitempty" yaml:"next,omitempty"`
+	Retries     int                    `mapstructure:"retries" toml:"retries,omitempty" yaml:"retries,omitempty"`
+	RetryIf     string                 `mapstructure:"retry_if" toml:"retry_if,omitempty" yaml:"retry_if,omitempty"`
+}
+
+// LoadFlowDefinitions reads the flows declared in the *.yaml, *.yml and
+// *.toml files of dir, one flow per file. A missing directory declares no flows
+func LoadFlowDefinitions(dir string) ([]FlowDefinition, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isFlowFile(entry.Name()) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	definitions := make([]FlowDefinition, 0, len(files))
+	for _, file := range files {
+		definition, err := loadFlowFile(file)
+		if err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, *definition)
+	}
+
+	return definitions, nil
+}
+
+// isFlowFile reports whether name is a flow definition file
+func isFlowFile(name string) bool {
+	_, err := FormatOf(name)
+	return err == nil
+}
+
+// loadFlowFile reads the flow declared in a YAML or TOML file
+func loadFlowFile(path string) (*FlowDefinition, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var definition FlowDefinition
+	if err := Decode(content, format, &definition); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if definition.Name == "" {
+		return nil, fmt.Errorf("%s: flow name cannot be empty", path)
+	}
+
+	return &definition, nil
+}
+
+// ToDomain maps the flow definition to the Flow domain model. Steps without
+// an ID are identified by their name
+func (d FlowDefinition) ToDomain() *flow.Flow {
+	steps := make([]*flow.Step, len(d.Steps))
+	for i, s := range d.Steps {
+		id := s.ID
+		if id == "" {
+			id = s.Name
+		}
+		steps[i] = &flow.Step{
+			ID:          id,
+			Name:        s.Name,
+			Type:        s.Type,
+			Integration: s.Integration,
+			Action:      s.Action,
+			Params:      s.Params,
+			ParamExprs:  s.ParamExprs,
+			Script:      s.Script,
+			Transforms:  s.Transforms,
+			Condition:   s.Condition,
+			Success:     s.Success,
+			NextStepID:  s.Next,
+			Retries:     s.Retries,
+			RetryIf:     s.RetryIf,
+		}
+	}
+
+	return &flow.Flow{
+		Name:        d.Name,
+		Description: d.Description,
+		Steps:       steps,
+	}
+}
+
+// FromFlow maps the Flow domain model to a flow definition, the inverse of
+// ToDomain. Integrations referenced by ID are referenced by their name,
+// looked up in integrationNames
+func FromFlow(f *flow.Flow, integrationNames map[string]string) (FlowDefinition, error) {
+	steps := make([]StepConfig, len(f.Steps))
+	for i, s := range f.Steps {
+		name := s.Integration
+		if s.IntegrationID != "" {
+			var ok bool
+			if name, ok = integrationNames[s.IntegrationID]; !ok {
+				return FlowDefinition{}, fmt.Errorf("flow %s, step %s: integration %s does not exist", f.Name, s.Key(), s.IntegrationID)
+			}
+		}
+
+		id := s.ID
+		if id == s.Name {
+			id = ""
+		}
+		steps[i] = StepConfig{
+			ID:          id,
+			Name:        s.Name,
+			Type:        s.Type,
+			Integration: name,
+			Action:      s.Action,
+			Params:      s.Params,
+			ParamExprs:  s.ParamExprs,
+			Script:      s.Script,
+			Transforms:  s.Transforms,
+			Condition:   s.Condition,
+			Success:     s.Success,
+			Next:        s.NextStepID,
+			Retries:     s.Retries,
+			RetryIf:     s.RetryIf,
+		}
+	}
+
+	return FlowDefinition{
+		Name:        f.Name,
+		Description: f.Description,
+		Steps:       steps,
+	}, nil
+}
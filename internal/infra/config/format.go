@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of declaration files and bundles
+const (
+	FormatTOML = "toml"
+	FormatYAML = "yaml"
+)
+
+// FormatOf returns the format of the file at path from its extension
+func FormatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return FormatTOML, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("unsupported file format: %s", path)
+}
+
+// Decode decodes TOML or YAML content into out using its mapstructure tags.
+// Keys are kept as they are written, unlike when read through viper, and keys
+// that match no field are rejected, so that misspelled settings are not
+// silently ignored
+func Decode(content []byte, format string, out interface{}) error {
+	var raw map[string]interface{}
+	var err error
+	switch format {
+	case FormatTOML:
+		err = toml.Unmarshal(content, &raw)
+	case FormatYAML:
+		err = yaml.Unmarshal(content, &raw)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		ErrorUnused: true,
+		Result:      out,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(raw)
+}
+
+// Encode encodes in as TOML or YAML
+func Encode(in interface{}, format string) ([]byte, error) {
+	switch format {
+	case FormatTOML:
+		return toml.Marshal(in)
+	case FormatYAML:
+		return yaml.Marshal(in)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		format  string
+		want    string
+		wantErr string
+	}{
+		{name: "toml", content: "[[integrations]]\nname = \"stripe\"\n", format: FormatTOML, want: "stripe"},
+		{name: "yaml", content: "integrations:\n  - name: stripe\n", format: FormatYAML, want: "stripe"},
+		{name: "unknown key", content: "[[integrations]]\nnmae = \"stripe\"\n", format: FormatTOML, wantErr: "invalid keys: nmae"},
+		{name: "unknown nested key", content: "integrations:\n  - name: stripe\n    basic:\n      pasword: x\n", format: FormatYAML, wantErr: "invalid keys: pasword"},
+		{name: "wrong type", content: "[[integrations]]\nname = [1]\n", format: FormatTOML, wantErr: "integrations[0].name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c IntegrationConfig
+			err := Decode([]byte(tt.content), tt.format, &c)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Decode() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if len(c.Integrations) != 1 || c.Integrations[0].Name != tt.want {
+				t.Errorf("Decode() = %+v, want one integration named %q", c.Integrations, tt.want)
+			}
+		})
+	}
+}
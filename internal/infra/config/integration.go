@@ -1,45 +1,358 @@
 package config
 
 import (
-	"github.com/spf13/viper"
+	"fmt"
+	"generic-integration-platform/internal/domain/endpoint"
+	"generic-integration-platform/internal/domain/integration"
+	"os"
+	"path/filepath"
+	"sort"
 )
 
 // PaymentProvider represents a single payment provider configuration
 type PaymentProvider struct {
-	Name       string           `mapstructure:"name"`
-	Type       string           `mapstructure:"type"`
-	BaseURL    string           `mapstructure:"base_url"`
-	AuthHeader string           `mapstructure:"auth_header"`
-	AuthToken  string           `mapstructure:"auth_token"`
-	Currency   string           `mapstructure:"currency"`
-	Endpoints  []EndpointConfig `mapstructure:"endpoints"`
+	Name         string                       `mapstructure:"name" toml:"name,omitempty" yaml:"name,omitempty"`
+	Type         string                       `mapstructure:"type" toml:"type,omitempty" yaml:"type,omitempty"`
+	BaseURL      string                       `mapstructure:"base_url" toml:"base_url,omitempty" yaml:"base_url,omitempty"`
+	AuthType     string                       `mapstructure:"auth_type" toml:"auth_type,omitempty" yaml:"auth_type,omitempty"`
+	AuthHeader   string                       `mapstructure:"auth_header" toml:"auth_header,omitempty" yaml:"auth_header,omitempty"`
+	AuthToken    string                       `mapstructure:"auth_token" toml:"auth_token,omitempty" yaml:"auth_token,omitempty"`
+	AuthParam    string                       `mapstructure:"auth_param" toml:"auth_param,omitempty" yaml:"auth_param,omitempty"`
+	Basic        *BasicConfig                 `mapstructure:"basic" toml:"basic,omitempty" yaml:"basic,omitempty"`
+	OAuth        *OAuthConfig                 `mapstructure:"oauth" toml:"oauth,omitempty" yaml:"oauth,omitempty"`
+	HMAC         *HMACConfig                  `mapstructure:"hmac" toml:"hmac,omitempty" yaml:"hmac,omitempty"`
+	TLS          *TLSConfig                   `mapstructure:"tls" toml:"tls,omitempty" yaml:"tls,omitempty"`
+	Headers      map[string]string            `mapstructure:"headers" toml:"headers,omitempty" yaml:"headers,omitempty"`
+	Environments map[string]EnvironmentConfig `mapstructure:"environments" toml:"environments,omitempty" yaml:"environments,omitempty"`
+	Currency     string                       `mapstructure:"currency" toml:"currency,omitempty" yaml:"currency,omitempty"`
+	Endpoints    []EndpointConfig             `mapstructure:"endpoints" toml:"endpoints,omitempty" yaml:"endpoints,omitempty"`
+}
+
+// EnvironmentConfig represents the settings a payment provider overrides in an
+// environment, such as its sandbox or live platform
+type EnvironmentConfig struct {
+	BaseURL   string            `mapstructure:"base_url" toml:"base_url,omitempty" yaml:"base_url,omitempty"`
+	AuthToken string            `mapstructure:"auth_token" toml:"auth_token,omitempty" yaml:"auth_token,omitempty"`
+	Basic     *BasicConfig      `mapstructure:"basic" toml:"basic,omitempty" yaml:"basic,omitempty"`
+	OAuth     *OAuthConfig      `mapstructure:"oauth" toml:"oauth,omitempty" yaml:"oauth,omitempty"`
+	HMAC      *HMACConfig       `mapstructure:"hmac" toml:"hmac,omitempty" yaml:"hmac,omitempty"`
+	Headers   map[string]string `mapstructure:"headers" toml:"headers,omitempty" yaml:"headers,omitempty"`
+	TLS       *TLSConfig        `mapstructure:"tls" toml:"tls,omitempty" yaml:"tls,omitempty"`
+}
+
+// BasicConfig represents the HTTP Basic credentials of a payment provider
+type BasicConfig struct {
+	Username string `mapstructure:"username" toml:"username,omitempty" yaml:"username,omitempty"`
+	Password string `mapstructure:"password" toml:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// OAuthConfig represents the OAuth2 client credentials of a payment provider
+type OAuthConfig struct {
+	ClientID     string   `mapstructure:"client_id" toml:"client_id,omitempty" yaml:"client_id,omitempty"`
+	ClientSecret string   `mapstructure:"client_secret" toml:"client_secret,omitempty" yaml:"client_secret,omitempty"`
+	TokenURL     string   `mapstructure:"token_url" toml:"token_url,omitempty" yaml:"token_url,omitempty"`
+	Scopes       []string `mapstructure:"scopes" toml:"scopes,omitempty" yaml:"scopes,omitempty"`
+	AuthStyle    string   `mapstructure:"auth_style" toml:"auth_style,omitempty" yaml:"auth_style,omitempty"`
+}
+
+// HMACConfig represents the request signing settings of a payment provider
+type HMACConfig struct {
+	Secret          string `mapstructure:"secret" toml:"secret,omitempty" yaml:"secret,omitempty"`
+	KeyID           string `mapstructure:"key_id" toml:"key_id,omitempty" yaml:"key_id,omitempty"`
+	Algorithm       string `mapstructure:"algorithm" toml:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+	Encoding        string `mapstructure:"encoding" toml:"encoding,omitempty" yaml:"encoding,omitempty"`
+	Canonical       string `mapstructure:"canonical" toml:"canonical,omitempty" yaml:"canonical,omitempty"`
+	Header          string `mapstructure:"header" toml:"header,omitempty" yaml:"header,omitempty"`
+	HeaderFormat    string `mapstructure:"header_format" toml:"header_format,omitempty" yaml:"header_format,omitempty"`
+	TimestampHeader string `mapstructure:"timestamp_header" toml:"timestamp_header,omitempty" yaml:"timestamp_header,omitempty"`
+	TimestampFormat string `mapstructure:"timestamp_format" toml:"timestamp_format,omitempty" yaml:"timestamp_format,omitempty"`
+	NonceHeader     string `mapstructure:"nonce_header" toml:"nonce_header,omitempty" yaml:"nonce_header,omitempty"`
+}
+
+// TLSConfig represents the client certificate and trusted CAs of a payment provider
+type TLSConfig struct {
+	CertFile   string   `mapstructure:"cert_file" toml:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile    string   `mapstructure:"key_file" toml:"key_file,omitempty" yaml:"key_file,omitempty"`
+	CAFiles    []string `mapstructure:"ca_files" toml:"ca_files,omitempty" yaml:"ca_files,omitempty"`
+	MinVersion string   `mapstructure:"min_version" toml:"min_version,omitempty" yaml:"min_version,omitempty"`
+	ServerName string   `mapstructure:"server_name" toml:"server_name,omitempty" yaml:"server_name,omitempty"`
 }
 
 // EndpointConfig represents the configuration for an endpoint of a payment provider
 type EndpointConfig struct {
-	Action string            `mapstructure:"action"`
-	Method string            `mapstructure:"method"`
-	Path   string            `mapstructure:"path"`
-	Params map[string]string `mapstructure:"params"`
+	Action           string            `mapstructure:"action" toml:"action,omitempty" yaml:"action,omitempty"`
+	Method           string            `mapstructure:"method" toml:"method,omitempty" yaml:"method,omitempty"`
+	Path             string            `mapstructure:"path" toml:"path,omitempty" yaml:"path,omitempty"`
+	Description      string            `mapstructure:"description" toml:"description,omitempty" yaml:"description,omitempty"`
+	Params           map[string]string `mapstructure:"params" toml:"params,omitempty" yaml:"params,omitempty"`
+	Headers          map[string]string `mapstructure:"headers" toml:"headers,omitempty" yaml:"headers,omitempty"`
+	ResponseMappings map[string]string `mapstructure:"response_mappings" toml:"response_mappings,omitempty" yaml:"response_mappings,omitempty"`
 }
 
 // IntegrationConfig represents the entire configuration structure
 type IntegrationConfig struct {
-	PaymentProviders []PaymentProvider `mapstructure:"payment_providers"`
+	Integrations []PaymentProvider `mapstructure:"integrations" toml:"integrations,omitempty" yaml:"integrations,omitempty"`
 }
 
+// LoadIntegrationConfig reads the integrations declared in the TOML file at
+// path or, if path is a directory, in every *.toml file it contains
 func LoadIntegrationConfig(path string) (*IntegrationConfig, error) {
-	viper.SetConfigFile(path)
-	viper.SetConfigType("toml")
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return loadIntegrationFile(path)
+	}
 
-	if err := viper.ReadInConfig(); err != nil {
+	files, err := filepath.Glob(filepath.Join(path, "*.toml"))
+	if err != nil {
 		return nil, err
 	}
+	sort.Strings(files)
 
-	var config IntegrationConfig
-	if err := viper.Unmarshal(&config); err != nil {
+	config := &IntegrationConfig{}
+	for _, file := range files {
+		c, err := loadIntegrationFile(file)
+		if err != nil {
+			return nil, err
+		}
+		config.Integrations = append(config.Integrations, c.Integrations...)
+	}
+
+	return config, nil
+}
+
+// loadIntegrationFile reads the integrations declared in a single TOML file
+func loadIntegrationFile(path string) (*IntegrationConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
 		return nil, err
 	}
 
+	var config IntegrationConfig
+	if err := Decode(content, FormatTOML, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
 	return &config, nil
 }
+
+// ToDomain maps the declared integrations to Integration domain models
+func (c *IntegrationConfig) ToDomain() []*integration.Integration {
+	integrations := make([]*integration.Integration, len(c.Integrations))
+	for i, p := range c.Integrations {
+		integrations[i] = p.ToDomain()
+	}
+	return integrations
+}
+
+// ToDomain maps the payment provider to the Integration domain model
+func (p PaymentProvider) ToDomain() *integration.Integration {
+	endpoints := make([]*endpoint.Endpoint, len(p.Endpoints))
+	for i, ep := range p.Endpoints {
+		endpoints[i] = &endpoint.Endpoint{
+			Action:           ep.Action,
+			Method:           ep.Method,
+			Path:             ep.Path,
+			Params:           ep.Params,
+			Headers:          ep.Headers,
+			ResponseMappings: ep.ResponseMappings,
+		}
+	}
+
+	i := &integration.Integration{
+		Name:       p.Name,
+		Type:       p.Type,
+		BaseURL:    p.BaseURL,
+		AuthType:   p.AuthType,
+		AuthHeader: p.AuthHeader,
+		AuthToken:  p.AuthToken,
+		AuthParam:  p.AuthParam,
+		Basic:      p.Basic.toDomain(),
+		OAuth:      p.OAuth.toDomain(),
+		HMAC:       p.HMAC.toDomain(),
+		TLS:        p.TLS.toDomain(),
+		Headers:    p.Headers,
+		Currency:   p.Currency,
+		Endpoints:  endpoints,
+	}
+	if len(p.Environments) > 0 {
+		i.Environments = make(map[string]*integration.Environment, len(p.Environments))
+		for name, env := range p.Environments {
+			i.Environments[name] = &integration.Environment{
+				BaseURL:   env.BaseURL,
+				AuthToken: env.AuthToken,
+				Basic:     env.Basic.toDomain(),
+				OAuth:     env.OAuth.toDomain(),
+				HMAC:      env.HMAC.toDomain(),
+				Headers:   env.Headers,
+				TLS:       env.TLS.toDomain(),
+			}
+		}
+	}
+
+	return i
+}
+
+// FromIntegration maps the Integration domain model to a payment provider
+// declaration, the inverse of ToDomain
+func FromIntegration(i *integration.Integration) PaymentProvider {
+	endpoints := make([]EndpointConfig, len(i.Endpoints))
+	for n, ep := range i.Endpoints {
+		endpoints[n] = EndpointConfig{
+			Action:           ep.Action,
+			Method:           ep.Method,
+			Path:             ep.Path,
+			Params:           ep.Params,
+			Headers:          ep.Headers,
+			ResponseMappings: ep.ResponseMappings,
+		}
+	}
+
+	p := PaymentProvider{
+		Name:       i.Name,
+		Type:       i.Type,
+		BaseURL:    i.BaseURL,
+		AuthType:   i.AuthType,
+		AuthHeader: i.AuthHeader,
+		AuthToken:  i.AuthToken,
+		AuthParam:  i.AuthParam,
+		Basic:      fromBasic(i.Basic),
+		OAuth:      fromOAuth(i.OAuth),
+		HMAC:       fromHMAC(i.HMAC),
+		TLS:        fromTLS(i.TLS),
+		Headers:    i.Headers,
+		Currency:   i.Currency,
+		Endpoints:  endpoints,
+	}
+	if len(i.Environments) > 0 {
+		p.Environments = make(map[string]EnvironmentConfig, len(i.Environments))
+		for name, env := range i.Environments {
+			if env == nil {
+				continue
+			}
+			p.Environments[name] = EnvironmentConfig{
+				BaseURL:   env.BaseURL,
+				AuthToken: env.AuthToken,
+				Basic:     fromBasic(env.Basic),
+				OAuth:     fromOAuth(env.OAuth),
+				HMAC:      fromHMAC(env.HMAC),
+				Headers:   env.Headers,
+				TLS:       fromTLS(env.TLS),
+			}
+		}
+	}
+
+	return p
+}
+
+// toDomain maps the basic credentials to the domain model
+func (c *BasicConfig) toDomain() *integration.Basic {
+	if c == nil {
+		return nil
+	}
+	return &integration.Basic{Username: c.Username, Password: c.Password}
+}
+
+// toDomain maps the OAuth2 client credentials to the domain model
+func (c *OAuthConfig) toDomain() *integration.OAuth {
+	if c == nil {
+		return nil
+	}
+	return &integration.OAuth{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		TokenURL:     c.TokenURL,
+		Scopes:       c.Scopes,
+		AuthStyle:    c.AuthStyle,
+	}
+}
+
+// toDomain maps the request signing settings to the domain model
+func (c *HMACConfig) toDomain() *integration.HMAC {
+	if c == nil {
+		return nil
+	}
+	return &integration.HMAC{
+		Secret:          c.Secret,
+		KeyID:           c.KeyID,
+		Algorithm:       c.Algorithm,
+		Encoding:        c.Encoding,
+		Canonical:       c.Canonical,
+		Header:          c.Header,
+		HeaderFormat:    c.HeaderFormat,
+		TimestampHeader: c.TimestampHeader,
+		TimestampFormat: c.TimestampFormat,
+		NonceHeader:     c.NonceHeader,
+	}
+}
+
+// toDomain maps the transport security settings to the domain model
+func (c *TLSConfig) toDomain() *integration.TLS {
+	if c == nil {
+		return nil
+	}
+	return &integration.TLS{
+		CertFile:   c.CertFile,
+		KeyFile:    c.KeyFile,
+		CAFiles:    c.CAFiles,
+		MinVersion: c.MinVersion,
+		ServerName: c.ServerName,
+	}
+}
+
+// fromBasic maps the basic credentials of the domain model to their declaration
+func fromBasic(b *integration.Basic) *BasicConfig {
+	if b == nil {
+		return nil
+	}
+	return &BasicConfig{Username: b.Username, Password: b.Password}
+}
+
+// fromOAuth maps the OAuth2 client credentials of the domain model to their declaration
+func fromOAuth(o *integration.OAuth) *OAuthConfig {
+	if o == nil {
+		return nil
+	}
+	return &OAuthConfig{
+		ClientID:     o.ClientID,
+		ClientSecret: o.ClientSecret,
+		TokenURL:     o.TokenURL,
+		Scopes:       o.Scopes,
+		AuthStyle:    o.AuthStyle,
+	}
+}
+
+// fromHMAC maps the request signing settings of the domain model to their declaration
+func fromHMAC(h *integration.HMAC) *HMACConfig {
+	if h == nil {
+		return nil
+	}
+	return &HMACConfig{
+		Secret:          h.Secret,
+		KeyID:           h.KeyID,
+		Algorithm:       h.Algorithm,
+		Encoding:        h.Encoding,
+		Canonical:       h.Canonical,
+		Header:          h.Header,
+		HeaderFormat:    h.HeaderFormat,
+		TimestampHeader: h.TimestampHeader,
+		TimestampFormat: h.TimestampFormat,
+		NonceHeader:     h.NonceHeader,
+	}
+}
+
+// fromTLS maps the transport security settings of the domain model to their declaration
+func fromTLS(t *integration.TLS) *TLSConfig {
+	if t == nil {
+		return nil
+	}
+	return &TLSConfig{
+		CertFile:   t.CertFile,
+		KeyFile:    t.KeyFile,
+		CAFiles:    t.CAFiles,
+		MinVersion: t.MinVersion,
+		ServerName: t.ServerName,
+	}
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fileScheme prefixes settings read from a file, e.g. file:///run/secrets/api_key
+const fileScheme = "file://"
+
+// variablePattern matches ${VAR} and ${VAR:-default}, and $${ escaping a literal ${
+var variablePattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolate replaces the environment variables referenced by the string
+// values of raw, a decoded configuration file. Every unset variable without a
+// default is reported
+func interpolate(raw map[string]interface{}) error {
+	var errs []error
+	interpolateValue(raw, "", &errs)
+	return errors.Join(errs...)
+}
+
+// interpolateValue replaces the variables in value, recursively, and returns it
+func interpolateValue(value interface{}, path string, errs *[]error) interface{} {
+	switch v := value.(type) {
+	case string:
+		expanded, err := expand(v)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", path, err))
+		}
+		return expanded
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			v[key] = interpolateValue(v[key], join(path, key), errs)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = interpolateValue(v[i], fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+	return value
+}
+
+// expand replaces ${VAR} by the value of the environment variable VAR, and
+// ${VAR:-default} by default when VAR is unset or empty
+func expand(s string) (string, error) {
+	var missing []string
+	expanded := variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+		groups := variablePattern.FindStringSubmatch(match)
+		if value := os.Getenv(groups[1]); value != "" {
+			return value
+		}
+		if groups[2] != "" {
+			return groups[3]
+		}
+		missing = append(missing, groups[1])
+		return ""
+	})
+	if len(missing) > 0 {
+		return expanded, fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// resolveFiles replaces the string settings of c that start with file:// by
+// the content of the file, without its trailing new line, so that secrets can
+// be mounted as files
+func resolveFiles(c *Config) error {
+	var errs []error
+	resolveFileValues(reflect.ValueOf(c).Elem(), "", &errs)
+	return errors.Join(errs...)
+}
+
+// resolveFileValues resolves the file references of v, recursively
+func resolveFileValues(v reflect.Value, path string, errs *[]error) {
+	switch v.Kind() {
+	case reflect.String:
+		if !strings.HasPrefix(v.String(), fileScheme) {
+			return
+		}
+		content, err := os.ReadFile(strings.TrimPrefix(v.String(), fileScheme))
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", path, err))
+			return
+		}
+		v.SetString(strings.TrimRight(string(content), "\r\n"))
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			resolveFileValues(v.Field(i), join(path, settingName(v.Type().Field(i))), errs)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			resolveFileValues(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// settings returns the settings of v keyed by their names in the
+// configuration file, with durations written as in the file
+func settings(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			m[settingName(v.Type().Field(i))] = settings(v.Field(i))
+		}
+		return m
+	case reflect.Slice:
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = settings(v.Index(i))
+		}
+		return s
+	}
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return v.Interface()
+}
+
+// settingName returns the name of the setting held by field, as written in
+// the configuration file
+func settingName(field reflect.StructField) string {
+	if name := field.Tag.Get("mapstructure"); name != "" {
+		return name
+	}
+	return strings.ToLower(field.Name)
+}
+
+// join returns the dotted path of a setting
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
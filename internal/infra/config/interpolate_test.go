@@ -0,0 +1,88 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	t.Setenv("TEST_USER", "root")
+	t.Setenv("TEST_HOST", "mongo")
+	t.Setenv("TEST_EMPTY", "")
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{name: "no variable", in: "mongodb://localhost:27017", want: "mongodb://localhost:27017"},
+		{name: "set variable", in: "${TEST_USER}", want: "root"},
+		{name: "several variables", in: "mongodb://${TEST_USER}@${TEST_HOST}:27017", want: "mongodb://root@mongo:27017"},
+		{name: "default unused", in: "${TEST_HOST:-localhost}", want: "mongo"},
+		{name: "default for unset variable", in: "${TEST_UNSET:-localhost}", want: "localhost"},
+		{name: "default for empty variable", in: "${TEST_EMPTY:-localhost}", want: "localhost"},
+		{name: "empty default", in: "root:${TEST_UNSET:-}@mongo", want: "root:@mongo"},
+		{name: "escaped", in: "$${TEST_USER}", want: "${TEST_USER}"},
+		{name: "no braces", in: "$TEST_USER", want: "$TEST_USER"},
+		{name: "unset variable", in: "${TEST_UNSET}", want: "", wantErr: true},
+		{name: "empty variable", in: "${TEST_EMPTY}", want: "", wantErr: true},
+		{name: "unset among set", in: "${TEST_USER}:${TEST_UNSET}", want: "root:", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expand(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expand(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expand(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("TEST_HOST", "mongo")
+
+	tests := []struct {
+		name    string
+		raw     map[string]interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name: "nested values",
+			raw: map[string]interface{}{
+				"db":    map[string]interface{}{"host": "${TEST_HOST}", "port": int64(27017)},
+				"hosts": []interface{}{"${TEST_HOST}", "${TEST_UNSET:-localhost}"},
+			},
+			want: map[string]interface{}{
+				"db":    map[string]interface{}{"host": "mongo", "port": int64(27017)},
+				"hosts": []interface{}{"mongo", "localhost"},
+			},
+		},
+		{
+			name:    "reports the path of unset variables",
+			raw:     map[string]interface{}{"db": map[string]interface{}{"password": "${TEST_UNSET}"}},
+			want:    map[string]interface{}{"db": map[string]interface{}{"password": ""}},
+			wantErr: "db.password: environment variable TEST_UNSET is not set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := interpolate(tt.raw)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("interpolate() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("interpolate() error = %v, want %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tt.raw, tt.want) {
+				t.Errorf("interpolate() = %v, want %v", tt.raw, tt.want)
+			}
+		})
+	}
+}
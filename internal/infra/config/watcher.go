@@ -0,0 +1,79 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay groups the events of a single save: editors often write a file
+// in several steps.
+const reloadDelay = 500 * time.Millisecond
+
+// WatchIntegrationConfig reloads the integration configuration at path, a
+// file or a directory of *.toml files, every time it changes, and passes the
+// result to onChange along with any error loading it. It watches until ctx is
+// done.
+func WatchIntegrationConfig(ctx context.Context, path string, onChange func(*IntegrationConfig, error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// A single file is watched through its directory so that it is still
+	// watched after editors replace it with a new file.
+	dir := path
+	if !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	if err := watcher.Add(dir); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	relevant := func(name string) bool {
+		if info.IsDir() {
+			return filepath.Ext(name) == ".toml"
+		}
+		return filepath.Clean(name) == filepath.Clean(path)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		timer := time.NewTimer(reloadDelay)
+		timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod || !relevant(event.Name) {
+					continue
+				}
+				timer.Reset(reloadDelay)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onChange(nil, err)
+			case <-timer.C:
+				onChange(LoadIntegrationConfig(path))
+			}
+		}
+	}()
+
+	return nil
+}
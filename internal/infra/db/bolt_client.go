@@ -0,0 +1,33 @@
+package db
+
+import (
+	"fmt"
+	"generic-integration-platform/internal/infra/config"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// BoltDB struct holds an embedded bbolt database, kept in a single file.
+type BoltDB struct {
+	DB *bbolt.DB
+}
+
+// NewBoltDB opens the bbolt database file of the configuration, creating it
+// if it does not exist. The file is locked while it is open, so opening it
+// fails if another process uses it.
+func NewBoltDB(config *config.Config) (*BoltDB, error) {
+	db, err := bbolt.Open(config.Storage.Path, 0o600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", config.Storage.Path, err)
+	}
+
+	return &BoltDB{
+		DB: db,
+	}, nil
+}
+
+// Close closes the bbolt database file.
+func (b *BoltDB) Close() error {
+	return b.DB.Close()
+}
@@ -0,0 +1,450 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"generic-integration-platform/internal/domain/flow"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/encryption"
+
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The bolt repositories keep each document as JSON in a bucket of the bbolt
+// file, keyed by its ID, or by its name for secrets. Generated IDs are
+// ObjectIDs, so the documents are listed in the order they were created.
+
+var (
+	flowsBucket        = []byte("flows")
+	integrationsBucket = []byte("integrations")
+	secretsBucket      = []byte("secrets")
+)
+
+// boltStore runs the bbolt transactions of a repository: each call in its own
+// transaction, or all of them in the transaction of a Transactor.
+type boltStore struct {
+	db *bbolt.DB
+	tx *bbolt.Tx // Transaction of the Transactor, if any
+}
+
+// view runs fn in a read-only transaction.
+func (s boltStore) view(fn func(tx *bbolt.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	return s.db.View(fn)
+}
+
+// update runs fn in a read-write transaction.
+func (s boltStore) update(fn func(tx *bbolt.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	return s.db.Update(fn)
+}
+
+// boltFlowRepo implements FlowRepository interface with bbolt.
+type boltFlowRepo struct {
+	boltStore
+}
+
+// NewBoltFlowRepository creates a new flow repository stored in bbolt.
+func NewBoltFlowRepository(b *BoltDB) FlowRepository {
+	return &boltFlowRepo{
+		boltStore: boltStore{db: b.DB},
+	}
+}
+
+// Create inserts a new flow into the database.
+func (r *boltFlowRepo) Create(ctx context.Context, f *flow.Flow) error {
+	if f == nil {
+		return errors.New("flow cannot be nil")
+	}
+	if f.ID == "" {
+		f.ID = primitive.NewObjectID().Hex()
+	}
+
+	return r.update(func(tx *bbolt.Tx) error {
+		if boltExists(tx, flowsBucket, f.ID) {
+			return errors.New("a flow with this ID already exists")
+		}
+		return boltPut(tx, flowsBucket, f.ID, f)
+	})
+}
+
+// GetByID retrieves a flow by its ID.
+func (r *boltFlowRepo) GetByID(ctx context.Context, id string) (*flow.Flow, error) {
+	var f flow.Flow
+	err := r.view(func(tx *bbolt.Tx) error {
+		return boltGet(tx, flowsBucket, id, &f, ErrFlowNotFound)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// GetByName retrieves a flow by its name.
+func (r *boltFlowRepo) GetByName(ctx context.Context, name string) (*flow.Flow, error) {
+	flows, err := r.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range flows {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	return nil, ErrFlowNotFound
+}
+
+// GetAll retrieves all flows from the database.
+func (r *boltFlowRepo) GetAll(ctx context.Context) ([]*flow.Flow, error) {
+	var flows []*flow.Flow
+	err := r.view(func(tx *bbolt.Tx) (err error) {
+		flows, err = boltAll[flow.Flow](tx, flowsBucket)
+		return err
+	})
+	return flows, err
+}
+
+// Update modifies an existing flow in the database.
+func (r *boltFlowRepo) Update(ctx context.Context, f *flow.Flow) error {
+	if f == nil {
+		return errors.New("flow cannot be nil")
+	}
+
+	return r.update(func(tx *bbolt.Tx) error {
+		if !boltExists(tx, flowsBucket, f.ID) {
+			return ErrFlowNotFound
+		}
+		return boltPut(tx, flowsBucket, f.ID, f)
+	})
+}
+
+// Delete removes a flow from the database by its ID.
+func (r *boltFlowRepo) Delete(ctx context.Context, id string) error {
+	return r.update(func(tx *bbolt.Tx) error {
+		_, err := boltDelete(tx, flowsBucket, id)
+		return err
+	})
+}
+
+// boltIntegrationRepo implements IntegrationRepository interface with bbolt.
+// Credential fields are envelope-encrypted at rest when a keyring is
+// configured.
+type boltIntegrationRepo struct {
+	boltStore
+	keyring *encryption.Keyring
+}
+
+// NewBoltIntegrationRepository creates a new integration repository stored in
+// bbolt.
+func NewBoltIntegrationRepository(b *BoltDB, keyring *encryption.Keyring) IntegrationRepository {
+	return &boltIntegrationRepo{
+		boltStore: boltStore{db: b.DB},
+		keyring:   keyring,
+	}
+}
+
+// Create inserts a new integration into the database.
+func (r *boltIntegrationRepo) Create(ctx context.Context, i *integration.Integration) error {
+	if i == nil {
+		return errors.New("integration cannot be nil")
+	}
+	if i.ID == "" {
+		i.ID = primitive.NewObjectID().Hex()
+	}
+
+	sealed, err := r.seal(i)
+	if err != nil {
+		return err
+	}
+
+	return r.update(func(tx *bbolt.Tx) error {
+		if boltExists(tx, integrationsBucket, i.ID) {
+			return errors.New("an integration with this ID already exists")
+		}
+		return boltPut(tx, integrationsBucket, i.ID, sealed)
+	})
+}
+
+// GetByID retrieves an integration by its ID.
+func (r *boltIntegrationRepo) GetByID(ctx context.Context, id string) (*integration.Integration, error) {
+	var i integration.Integration
+	err := r.view(func(tx *bbolt.Tx) error {
+		return boltGet(tx, integrationsBucket, id, &i, ErrIntegrationNotFound)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := open(r.keyring, &i); err != nil {
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+// GetByName retrieves an integration by its name.
+func (r *boltIntegrationRepo) GetByName(ctx context.Context, name string) (*integration.Integration, error) {
+	integrations, err := r.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range integrations {
+		if i.Name == name {
+			return i, nil
+		}
+	}
+	return nil, ErrIntegrationNotFound
+}
+
+// GetAll retrieves all integrations from the database.
+func (r *boltIntegrationRepo) GetAll(ctx context.Context) ([]*integration.Integration, error) {
+	var integrations []*integration.Integration
+	err := r.view(func(tx *bbolt.Tx) (err error) {
+		integrations, err = boltAll[integration.Integration](tx, integrationsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range integrations {
+		if err := open(r.keyring, i); err != nil {
+			return nil, err
+		}
+	}
+
+	return integrations, nil
+}
+
+// Update modifies an existing integration in the database.
+func (r *boltIntegrationRepo) Update(ctx context.Context, i *integration.Integration) error {
+	if i == nil {
+		return errors.New("integration cannot be nil")
+	}
+
+	sealed, err := r.seal(i)
+	if err != nil {
+		return err
+	}
+
+	return r.update(func(tx *bbolt.Tx) error {
+		if !boltExists(tx, integrationsBucket, i.ID) {
+			return ErrIntegrationNotFound
+		}
+		return boltPut(tx, integrationsBucket, i.ID, sealed)
+	})
+}
+
+// Delete removes an integration from the database by its ID.
+func (r *boltIntegrationRepo) Delete(ctx context.Context, id string) error {
+	return r.update(func(tx *bbolt.Tx) error {
+		_, err := boltDelete(tx, integrationsBucket, id)
+		return err
+	})
+}
+
+// RotateKeys re-wraps the data keys of every encrypted credential with the
+// active master key, and encrypts the credentials still stored in plaintext.
+// It returns the number of integrations updated.
+func (r *boltIntegrationRepo) RotateKeys(ctx context.Context) (int, error) {
+	if r.keyring == nil {
+		return 0, errNoMasterKey
+	}
+
+	updated := 0
+	err := r.update(func(tx *bbolt.Tx) error {
+		integrations, err := boltAll[integration.Integration](tx, integrationsBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, stored := range integrations {
+			changed, err := rewrap(r.keyring, stored)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+			if err := boltPut(tx, integrationsBucket, stored.ID, stored); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		// Nothing was written: the transaction was rolled back.
+		return 0, err
+	}
+
+	return updated, nil
+}
+
+// seal returns the copy of i to store, with its credentials encrypted. The
+// environment applied to an integration is never stored.
+func (r *boltIntegrationRepo) seal(i *integration.Integration) (*integration.Integration, error) {
+	sealed, err := seal(r.keyring, i)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := *sealed
+	stored.Environment = ""
+	return &stored, nil
+}
+
+// boltTransactor implements Transactor with bbolt transactions.
+type boltTransactor struct {
+	db      *bbolt.DB
+	keyring *encryption.Keyring
+}
+
+// NewBoltTransactor creates a new Transactor for the bolt repositories of
+// flows and integrations, encrypting credentials with keyring like
+// NewBoltIntegrationRepository.
+func NewBoltTransactor(b *BoltDB, keyring *encryption.Keyring) Transactor {
+	return &boltTransactor{
+		db:      b.DB,
+		keyring: keyring,
+	}
+}
+
+// Transact runs fn in a single read-write transaction. Other writers wait
+// until it is committed or rolled back.
+func (t *boltTransactor) Transact(ctx context.Context, fn func(ctx context.Context, flows FlowRepository, integrations IntegrationRepository) error) error {
+	return t.db.Update(func(tx *bbolt.Tx) error {
+		store := boltStore{db: t.db, tx: tx}
+		return fn(ctx, &boltFlowRepo{boltStore: store}, &boltIntegrationRepo{boltStore: store, keyring: t.keyring})
+	})
+}
+
+// boltSecretRepo implements SecretRepository interface with bbolt.
+type boltSecretRepo struct {
+	boltStore
+}
+
+// NewBoltSecretRepository creates a new secret repository stored in bbolt.
+func NewBoltSecretRepository(b *BoltDB) SecretRepository {
+	return &boltSecretRepo{
+		boltStore: boltStore{db: b.DB},
+	}
+}
+
+// Get retrieves a secret by its name.
+func (r *boltSecretRepo) Get(ctx context.Context, name string) (*Secret, error) {
+	var s Secret
+	err := r.view(func(tx *bbolt.Tx) error {
+		return boltGet(tx, secretsBucket, name, &s, ErrSecretNotFound)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// GetAll retrieves all secrets, sorted by name.
+func (r *boltSecretRepo) GetAll(ctx context.Context) ([]*Secret, error) {
+	var secrets []*Secret
+	err := r.view(func(tx *bbolt.Tx) (err error) {
+		secrets, err = boltAll[Secret](tx, secretsBucket)
+		return err
+	})
+	return secrets, err
+}
+
+// Put creates or replaces a secret.
+func (r *boltSecretRepo) Put(ctx context.Context, s *Secret) error {
+	if s == nil {
+		return errors.New("secret cannot be nil")
+	}
+
+	return r.update(func(tx *bbolt.Tx) error {
+		return boltPut(tx, secretsBucket, s.Name, s)
+	})
+}
+
+// Delete removes a secret from the database by its name.
+func (r *boltSecretRepo) Delete(ctx context.Context, name string) error {
+	return r.update(func(tx *bbolt.Tx) error {
+		deleted, err := boltDelete(tx, secretsBucket, name)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return ErrSecretNotFound
+		}
+		return nil
+	})
+}
+
+// boltExists reports whether a document is stored under key in bucket.
+func boltExists(tx *bbolt.Tx, bucket []byte, key string) bool {
+	b := tx.Bucket(bucket)
+	return b != nil && b.Get([]byte(key)) != nil
+}
+
+// boltGet decodes the document stored under key in bucket into v, or returns
+// notFound when there is none.
+func boltGet(tx *bbolt.Tx, bucket []byte, key string, v any, notFound error) error {
+	b := tx.Bucket(bucket)
+	if b == nil {
+		return notFound
+	}
+	content := b.Get([]byte(key))
+	if content == nil {
+		return notFound
+	}
+	return json.Unmarshal(content, v)
+}
+
+// boltAll decodes the documents of bucket, in the order of their keys.
+func boltAll[T any](tx *bbolt.Tx, bucket []byte) ([]*T, error) {
+	b := tx.Bucket(bucket)
+	if b == nil {
+		return nil, nil
+	}
+
+	var documents []*T
+	err := b.ForEach(func(_, content []byte) error {
+		var v T
+		if err := json.Unmarshal(content, &v); err != nil {
+			return err
+		}
+		documents = append(documents, &v)
+		return nil
+	})
+	return documents, err
+}
+
+// boltPut stores v as JSON under key in bucket, creating the bucket if needed.
+func boltPut(tx *bbolt.Tx, bucket []byte, key string, v any) error {
+	b, err := tx.CreateBucketIfNotExists(bucket)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), content)
+}
+
+// boltDelete removes the document stored under key in bucket. It reports
+// whether there was one.
+func boltDelete(tx *bbolt.Tx, bucket []byte, key string) (bool, error) {
+	if !boltExists(tx, bucket, key) {
+		return false, nil
+	}
+	return true, tx.Bucket(bucket).Delete([]byte(key))
+}
@@ -1,11 +0,0 @@
-package db
-
-import "go.uber.org/fx"
-
-var Module = fx.Option(
-	fx.Provide(
-		NewMongoDB,
-		NewIntegrationRepository,
-		NewFlowRepository,
-	),
-)
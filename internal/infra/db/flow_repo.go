@@ -6,13 +6,18 @@ import (
 	"generic-integration-platform/internal/domain/flow"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrFlowNotFound is returned when a flow does not exist.
+var ErrFlowNotFound = errors.New("flow not found")
+
 // FlowRepository defines the interface for flow repository methods.
 type FlowRepository interface {
 	Create(ctx context.Context, f *flow.Flow) error
 	GetByID(ctx context.Context, id string) (*flow.Flow, error)
+	GetByName(ctx context.Context, name string) (*flow.Flow, error)
 	GetAll(ctx context.Context) ([]*flow.Flow, error)
 	Update(ctx context.Context, f *flow.Flow) error
 	Delete(ctx context.Context, id string) error
@@ -35,6 +40,9 @@ func (r *flowRepo) Create(ctx context.Context, f *flow.Flow) error {
 	if f == nil {
 		return errors.New("flow cannot be nil")
 	}
+	if f.ID == "" {
+		f.ID = primitive.NewObjectID().Hex()
+	}
 
 	_, err := r.collection.InsertOne(ctx, f)
 	return err
@@ -42,10 +50,22 @@ func (r *flowRepo) Create(ctx context.Context, f *flow.Flow) error {
 
 // GetByID retrieves a flow by its ID.
 func (r *flowRepo) GetByID(ctx context.Context, id string) (*flow.Flow, error) {
+	return r.findOne(ctx, idFilter(id))
+}
+
+// GetByName retrieves a flow by its name.
+func (r *flowRepo) GetByName(ctx context.Context, name string) (*flow.Flow, error) {
+	return r.findOne(ctx, bson.M{"name": name})
+}
+
+// findOne retrieves the flow matching filter.
+func (r *flowRepo) findOne(ctx context.Context, filter bson.M) (*flow.Flow, error) {
 	var f flow.Flow
-	filter := bson.M{"_id": id}
 
 	err := r.collection.FindOne(ctx, filter).Decode(&f)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrFlowNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -84,14 +104,22 @@ func (r *flowRepo) Update(ctx context.Context, f *flow.Flow) error {
 		return errors.New("flow cannot be nil")
 	}
 
-	filter := bson.M{"_id": f.ID}
-	_, err := r.collection.ReplaceOne(ctx, filter, f)
-	return err
+	// The key is left out of the replacement so that it stays as stored.
+	replacement := *f
+	replacement.ID = ""
+
+	result, err := r.collection.ReplaceOne(ctx, idFilter(f.ID), replacement)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrFlowNotFound
+	}
+	return nil
 }
 
 // Delete removes a flow from the database by its ID.
 func (r *flowRepo) Delete(ctx context.Context, id string) error {
-	filter := bson.M{"_id": id}
-	_, err := r.collection.DeleteOne(ctx, filter)
+	_, err := r.collection.DeleteOne(ctx, idFilter(id))
 	return err
 }
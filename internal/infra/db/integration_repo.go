@@ -3,30 +3,43 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
 	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/secret"
+	"generic-integration-platform/internal/infra/encryption"
+	"sort"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrIntegrationNotFound is returned when an integration does not exist.
+var ErrIntegrationNotFound = errors.New("integration not found")
+
 // IntegrationRepository defines the interface for integration repository methods.
 type IntegrationRepository interface {
 	Create(ctx context.Context, i *integration.Integration) error
 	GetByID(ctx context.Context, id string) (*integration.Integration, error)
+	GetByName(ctx context.Context, name string) (*integration.Integration, error)
 	GetAll(ctx context.Context) ([]*integration.Integration, error)
 	Update(ctx context.Context, i *integration.Integration) error
 	Delete(ctx context.Context, id string) error
+	RotateKeys(ctx context.Context) (int, error)
 }
 
-// integrationRepo implements IntegrationRepository interface.
+// integrationRepo implements IntegrationRepository interface. Credential
+// fields are envelope-encrypted at rest when a keyring is configured.
 type integrationRepo struct {
 	collection *mongo.Collection
+	keyring    *encryption.Keyring
 }
 
 // NewIntegrationRepository creates a new integration repository.
-func NewIntegrationRepository(mdb *MongoDB) IntegrationRepository {
+func NewIntegrationRepository(mdb *MongoDB, keyring *encryption.Keyring) IntegrationRepository {
 	return &integrationRepo{
 		collection: mdb.Database.Collection("integrations"),
+		keyring:    keyring,
 	}
 }
 
@@ -36,20 +49,45 @@ func (r *integrationRepo) Create(ctx context.Context, i *integration.Integration
 		return errors.New("integration cannot be nil")
 	}
 
-	_, err := r.collection.InsertOne(ctx, i)
+	if i.ID == "" {
+		i.ID = primitive.NewObjectID().Hex()
+	}
+
+	sealed, err := seal(r.keyring, i)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.InsertOne(ctx, sealed)
 	return err
 }
 
 // GetByID retrieves an integration by its ID.
 func (r *integrationRepo) GetByID(ctx context.Context, id string) (*integration.Integration, error) {
+	return r.findOne(ctx, idFilter(id))
+}
+
+// GetByName retrieves an integration by its name.
+func (r *integrationRepo) GetByName(ctx context.Context, name string) (*integration.Integration, error) {
+	return r.findOne(ctx, bson.M{"name": name})
+}
+
+// findOne retrieves the integration matching filter.
+func (r *integrationRepo) findOne(ctx context.Context, filter bson.M) (*integration.Integration, error) {
 	var i integration.Integration
-	filter := bson.M{"_id": id}
 
 	err := r.collection.FindOne(ctx, filter).Decode(&i)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrIntegrationNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if err := open(r.keyring, &i); err != nil {
+		return nil, err
+	}
+
 	return &i, nil
 }
 
@@ -68,6 +106,9 @@ func (r *integrationRepo) GetAll(ctx context.Context) ([]*integration.Integratio
 		if err := cursor.Decode(&i); err != nil {
 			return nil, err
 		}
+		if err := open(r.keyring, &i); err != nil {
+			return nil, err
+		}
 		integrations = append(integrations, &i)
 	}
 
@@ -84,14 +125,247 @@ func (r *integrationRepo) Update(ctx context.Context, i *integration.Integration
 		return errors.New("integration cannot be nil")
 	}
 
-	filter := bson.M{"_id": i.ID}
-	_, err := r.collection.ReplaceOne(ctx, filter, i)
-	return err
+	sealed, err := seal(r.keyring, i)
+	if err != nil {
+		return err
+	}
+
+	// The key is left out of the replacement so that it stays as stored.
+	replacement := *sealed
+	replacement.ID = ""
+
+	result, err := r.collection.ReplaceOne(ctx, idFilter(i.ID), replacement)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrIntegrationNotFound
+	}
+	return nil
 }
 
 // Delete removes an integration from the database by its ID.
 func (r *integrationRepo) Delete(ctx context.Context, id string) error {
-	filter := bson.M{"_id": id}
-	_, err := r.collection.DeleteOne(ctx, filter)
+	_, err := r.collection.DeleteOne(ctx, idFilter(id))
 	return err
 }
+
+// idFilter matches the integration with the given ID. Integrations created
+// before IDs were generated by the repository have an ObjectID key, exposed
+// as its hex string.
+func idFilter(id string) bson.M {
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		return bson.M{"_id": bson.M{"$in": bson.A{id, oid}}}
+	}
+	return bson.M{"_id": id}
+}
+
+// RotateKeys re-wraps the data keys of every encrypted credential with the
+// active master key, and encrypts the credentials still stored in plaintext.
+// It returns the number of integrations updated.
+func (r *integrationRepo) RotateKeys(ctx context.Context) (int, error) {
+	if r.keyring == nil {
+		return 0, errNoMasterKey
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	updated := 0
+	for cursor.Next(ctx) {
+		var stored integration.Integration
+		if err := cursor.Decode(&stored); err != nil {
+			return updated, err
+		}
+
+		changed, err := rewrap(r.keyring, &stored)
+		if err != nil {
+			return updated, err
+		}
+		if !changed {
+			continue
+		}
+
+		// Keep the key as stored, whatever its BSON type.
+		filter := bson.M{"_id": cursor.Current.Lookup("_id")}
+		stored.ID = ""
+		if _, err := r.collection.ReplaceOne(ctx, filter, stored); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+
+	return updated, cursor.Err()
+}
+
+// errNoMasterKey is returned when rotating keys without a keyring.
+var errNoMasterKey = errors.New("no encryption master key is configured")
+
+// rewrap re-wraps the data keys of the credentials of i in place with the
+// active master key, encrypting those stored in plaintext and binding those
+// encrypted before integration IDs were part of their additional data. It
+// reports whether any credential changed.
+func rewrap(keyring *encryption.Keyring, i *integration.Integration) (bool, error) {
+	changed := false
+	for _, field := range credentialFields(i) {
+		if !encrypts(*field.value) {
+			continue
+		}
+		if encryption.IsEncrypted(*field.value) {
+			if _, err := keyring.Decrypt(*field.value, field.aad(i)); err != nil {
+				plaintext, legacyErr := keyring.Decrypt(*field.value, field.name)
+				if legacyErr != nil {
+					return false, fmt.Errorf("integration %s, %s: %w", i.Name, field.name, err)
+				}
+				if *field.value, err = keyring.Encrypt(plaintext, field.aad(i)); err != nil {
+					return false, fmt.Errorf("integration %s, %s: %w", i.Name, field.name, err)
+				}
+				changed = true
+				continue
+			}
+		}
+		value, rewrapped, err := keyring.Rewrap(*field.value, field.aad(i))
+		if err != nil {
+			return false, fmt.Errorf("integration %s, %s: %w", i.Name, field.name, err)
+		}
+		*field.value = value
+		changed = changed || rewrapped
+	}
+	return changed, nil
+}
+
+// credentialField is a credential of an integration, named after its path.
+type credentialField struct {
+	name  string
+	value *string
+}
+
+// aad returns the additional data authenticating the field of i when it is
+// encrypted: the integration ID and the field name, so that an encrypted value
+// copied to another integration or field does not decrypt.
+func (f credentialField) aad(i *integration.Integration) string {
+	return i.ID + "/" + f.name
+}
+
+// credentialFields returns the credentials of i that are encrypted at rest.
+func credentialFields(i *integration.Integration) []credentialField {
+	fields := []credentialField{{"auth_token", &i.AuthToken}}
+	if i.Basic != nil {
+		fields = append(fields, credentialField{"basic.password", &i.Basic.Password})
+	}
+	if i.OAuth != nil {
+		fields = append(fields, credentialField{"oauth.client_secret", &i.OAuth.ClientSecret})
+	}
+	if i.HMAC != nil {
+		fields = append(fields, credentialField{"hmac.secret", &i.HMAC.Secret})
+	}
+	names := make([]string, 0, len(i.Environments))
+	for name, env := range i.Environments {
+		if env != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env, prefix := i.Environments[name], "environments."+name+"."
+		fields = append(fields, credentialField{prefix + "auth_token", &env.AuthToken})
+		if env.Basic != nil {
+			fields = append(fields, credentialField{prefix + "basic.password", &env.Basic.Password})
+		}
+		if env.OAuth != nil {
+			fields = append(fields, credentialField{prefix + "oauth.client_secret", &env.OAuth.ClientSecret})
+		}
+		if env.HMAC != nil {
+			fields = append(fields, credentialField{prefix + "hmac.secret", &env.HMAC.Secret})
+		}
+	}
+	if i.Credentials != nil {
+		if i.Credentials.Primary != nil {
+			fields = append(fields, credentialField{"credentials.primary", &i.Credentials.Primary.Value})
+		}
+		if i.Credentials.Secondary != nil {
+			fields = append(fields, credentialField{"credentials.secondary", &i.Credentials.Secondary.Value})
+		}
+	}
+	return fields
+}
+
+// encrypts reports whether value is stored encrypted. Secret references point
+// to credentials kept elsewhere and are stored as they are.
+func encrypts(value string) bool {
+	return value != "" && !secret.IsReference(value)
+}
+
+// seal returns a copy of i with its credentials encrypted with keyring, or i
+// itself when keyring is nil.
+func seal(keyring *encryption.Keyring, i *integration.Integration) (*integration.Integration, error) {
+	if keyring == nil {
+		return i, nil
+	}
+
+	sealed := *i
+	if i.Basic != nil {
+		basic := *i.Basic
+		sealed.Basic = &basic
+	}
+	if i.OAuth != nil {
+		oauth := *i.OAuth
+		sealed.OAuth = &oauth
+	}
+	if i.HMAC != nil {
+		hmac := *i.HMAC
+		sealed.HMAC = &hmac
+	}
+	if i.Environments != nil {
+		sealed.Environments = make(map[string]*integration.Environment, len(i.Environments))
+		for name, env := range i.Environments {
+			if env != nil {
+				env = env.Copy()
+			}
+			sealed.Environments[name] = env
+		}
+	}
+	if i.Credentials != nil {
+		sealed.Credentials = i.Credentials.Copy()
+	}
+
+	for _, field := range credentialFields(&sealed) {
+		if !encrypts(*field.value) || encryption.IsEncrypted(*field.value) {
+			continue
+		}
+		value, err := keyring.Encrypt(*field.value, field.aad(&sealed))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w", field.name, err)
+		}
+		*field.value = value
+	}
+
+	return &sealed, nil
+}
+
+// open decrypts the credentials of i in place with keyring.
+func open(keyring *encryption.Keyring, i *integration.Integration) error {
+	for _, field := range credentialFields(i) {
+		if !encryption.IsEncrypted(*field.value) {
+			continue
+		}
+		if keyring == nil {
+			return fmt.Errorf("integration %s has encrypted credentials but no encryption master key is configured", i.Name)
+		}
+		value, err := keyring.Decrypt(*field.value, field.aad(i))
+		if err != nil {
+			// Credentials encrypted before the integration ID was bound to
+			// them, until the keys are rotated.
+			var legacyErr error
+			if value, legacyErr = keyring.Decrypt(*field.value, field.name); legacyErr != nil {
+				return fmt.Errorf("failed to decrypt %s of integration %s: %w", field.name, i.Name, err)
+			}
+		}
+		*field.value = value
+	}
+
+	return nil
+}
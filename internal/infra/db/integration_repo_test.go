@@ -0,0 +1,207 @@
+package db
+
+import (
+	"bytes"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/encryption"
+	"testing"
+)
+
+// testKeyring returns a keyring whose keys are filled with the given bytes,
+// the active key first.
+func testKeyring(t *testing.T, keys ...byte) *encryption.Keyring {
+	t.Helper()
+	raw := make([][]byte, len(keys))
+	for i, b := range keys {
+		raw[i] = bytes.Repeat([]byte{b}, 32)
+	}
+	keyring, err := encryption.NewKeyring(raw...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+// testIntegration returns an integration with a credential in every field
+// encrypted at rest.
+func testIntegration() *integration.Integration {
+	return &integration.Integration{
+		ID:        "id1",
+		Name:      "stripe",
+		AuthToken: "sk_test_123",
+		Basic:     &integration.Basic{Username: "user", Password: "password"},
+		HMAC:      &integration.HMAC{Secret: "hmac-secret"},
+		Environments: map[string]*integration.Environment{
+			"live": {AuthToken: "sk_live_123"},
+		},
+		Credentials: &integration.Credentials{
+			Primary:   &integration.Credential{Value: "primary"},
+			Secondary: &integration.Credential{Value: "env://STRIPE_SECONDARY"},
+		},
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	keyring := testKeyring(t, 1)
+	plain := testIntegration()
+
+	sealed, err := seal(keyring, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.AuthToken != "sk_test_123" {
+		t.Fatalf("seal() modified its input: AuthToken = %q", plain.AuthToken)
+	}
+
+	tests := []struct {
+		name          string
+		value         string
+		wantEncrypted bool
+	}{
+		{name: "auth_token", value: sealed.AuthToken, wantEncrypted: true},
+		{name: "basic.password", value: sealed.Basic.Password, wantEncrypted: true},
+		{name: "basic.username", value: sealed.Basic.Username, wantEncrypted: false},
+		{name: "hmac.secret", value: sealed.HMAC.Secret, wantEncrypted: true},
+		{name: "environments.live.auth_token", value: sealed.Environments["live"].AuthToken, wantEncrypted: true},
+		{name: "credentials.primary", value: sealed.Credentials.Primary.Value, wantEncrypted: true},
+		{name: "secret reference", value: sealed.Credentials.Secondary.Value, wantEncrypted: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encryption.IsEncrypted(tt.value); got != tt.wantEncrypted {
+				t.Errorf("IsEncrypted(%q) = %v, want %v", tt.value, got, tt.wantEncrypted)
+			}
+		})
+	}
+
+	if err := open(keyring, sealed); err != nil {
+		t.Fatal(err)
+	}
+	want := testIntegration()
+	if sealed.AuthToken != want.AuthToken ||
+		sealed.Basic.Password != want.Basic.Password ||
+		sealed.HMAC.Secret != want.HMAC.Secret ||
+		sealed.Environments["live"].AuthToken != want.Environments["live"].AuthToken ||
+		sealed.Credentials.Primary.Value != want.Credentials.Primary.Value ||
+		sealed.Credentials.Secondary.Value != want.Credentials.Secondary.Value {
+		t.Errorf("open() = %+v, want the credentials of %+v", sealed, want)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	keyring := testKeyring(t, 1)
+
+	sealed, err := seal(keyring, testIntegration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := keyring.Encrypt("sk_test_123", "auth_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		integration *integration.Integration
+		keyring     *encryption.Keyring
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "same integration",
+			integration: &integration.Integration{ID: "id1", AuthToken: sealed.AuthToken},
+			keyring:     keyring,
+			want:        "sk_test_123",
+		},
+		{
+			name:        "copied to another integration",
+			integration: &integration.Integration{ID: "id2", AuthToken: sealed.AuthToken},
+			keyring:     keyring,
+			wantErr:     true,
+		},
+		{
+			name:        "copied to another field",
+			integration: &integration.Integration{ID: "id1", HMAC: &integration.HMAC{Secret: sealed.AuthToken}},
+			keyring:     keyring,
+			wantErr:     true,
+		},
+		{
+			name:        "encrypted before integration IDs were bound",
+			integration: &integration.Integration{ID: "id1", AuthToken: legacy},
+			keyring:     keyring,
+			want:        "sk_test_123",
+		},
+		{
+			name:        "plaintext",
+			integration: &integration.Integration{ID: "id1", AuthToken: "sk_test_123"},
+			want:        "sk_test_123",
+		},
+		{
+			name:        "no master key",
+			integration: &integration.Integration{ID: "id1", AuthToken: sealed.AuthToken},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := open(tt.keyring, tt.integration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("open() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tt.integration.AuthToken != tt.want {
+				t.Errorf("open() AuthToken = %q, want %q", tt.integration.AuthToken, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	old := testKeyring(t, 1)
+	rotated := testKeyring(t, 2, 1)
+
+	sealedWithOld, err := seal(old, testIntegration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealedWithNew, err := seal(rotated, testIntegration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := old.Encrypt("sk_test_123", "auth_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		integration *integration.Integration
+		wantChanged bool
+	}{
+		{name: "sealed with a previous key", integration: sealedWithOld, wantChanged: true},
+		{name: "sealed with the active key", integration: sealedWithNew, wantChanged: false},
+		{name: "plaintext", integration: testIntegration(), wantChanged: true},
+		{name: "legacy additional data", integration: &integration.Integration{ID: "id1", AuthToken: legacy}, wantChanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, err := rewrap(rotated, tt.integration)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("rewrap() changed = %v, want %v", changed, tt.wantChanged)
+			}
+
+			// Once rewrapped, the credentials open with the active key only,
+			// bound to the integration ID.
+			if err := open(testKeyring(t, 2), tt.integration); err != nil {
+				t.Fatalf("open() with the active key only: %v", err)
+			}
+			if tt.integration.AuthToken != "sk_test_123" {
+				t.Errorf("open() AuthToken = %q, want %q", tt.integration.AuthToken, "sk_test_123")
+			}
+		})
+	}
+}
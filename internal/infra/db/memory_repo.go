@@ -0,0 +1,375 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"generic-integration-platform/internal/domain/flow"
+	"generic-integration-platform/internal/domain/integration"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The memory repositories keep their documents in the process, for
+// development and tests: nothing is persisted. They behave like the Mongo
+// repositories, storing copies so that callers never share the stored values.
+
+// memoryFlowRepo implements FlowRepository interface in memory.
+type memoryFlowRepo struct {
+	mu    sync.RWMutex
+	flows map[string]*flow.Flow // Stored flows, by ID
+}
+
+// NewMemoryFlowRepository creates a new flow repository kept in memory.
+func NewMemoryFlowRepository() FlowRepository {
+	return &memoryFlowRepo{
+		flows: map[string]*flow.Flow{},
+	}
+}
+
+// Create stores a copy of a new flow.
+func (r *memoryFlowRepo) Create(ctx context.Context, f *flow.Flow) error {
+	if f == nil {
+		return errors.New("flow cannot be nil")
+	}
+	if f.ID == "" {
+		f.ID = primitive.NewObjectID().Hex()
+	}
+
+	stored, err := copyOf(f)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.flows[f.ID]; ok {
+		return errors.New("a flow with this ID already exists")
+	}
+	r.flows[f.ID] = stored
+	return nil
+}
+
+// GetByID retrieves a flow by its ID.
+func (r *memoryFlowRepo) GetByID(ctx context.Context, id string) (*flow.Flow, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	f, ok := r.flows[id]
+	if !ok {
+		return nil, ErrFlowNotFound
+	}
+	return copyOf(f)
+}
+
+// GetByName retrieves a flow by its name.
+func (r *memoryFlowRepo) GetByName(ctx context.Context, name string) (*flow.Flow, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, id := range sortedIDs(r.flows) {
+		if f := r.flows[id]; f.Name == name {
+			return copyOf(f)
+		}
+	}
+	return nil, ErrFlowNotFound
+}
+
+// GetAll retrieves all flows, in the order they were created.
+func (r *memoryFlowRepo) GetAll(ctx context.Context) ([]*flow.Flow, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var flows []*flow.Flow
+	for _, id := range sortedIDs(r.flows) {
+		f, err := copyOf(r.flows[id])
+		if err != nil {
+			return nil, err
+		}
+		flows = append(flows, f)
+	}
+	return flows, nil
+}
+
+// Update replaces an existing flow.
+func (r *memoryFlowRepo) Update(ctx context.Context, f *flow.Flow) error {
+	if f == nil {
+		return errors.New("flow cannot be nil")
+	}
+
+	stored, err := copyOf(f)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.flows[f.ID]; !ok {
+		return ErrFlowNotFound
+	}
+	r.flows[f.ID] = stored
+	return nil
+}
+
+// Delete removes a flow by its ID.
+func (r *memoryFlowRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.flows, id)
+	return nil
+}
+
+// memoryIntegrationRepo implements IntegrationRepository interface in memory.
+type memoryIntegrationRepo struct {
+	mu           sync.RWMutex
+	integrations map[string]*integration.Integration // Stored integrations, by ID
+}
+
+// NewMemoryIntegrationRepository creates a new integration repository kept
+// in memory. Credentials are not encrypted, as they are never written out.
+func NewMemoryIntegrationRepository() IntegrationRepository {
+	return &memoryIntegrationRepo{
+		integrations: map[string]*integration.Integration{},
+	}
+}
+
+// Create stores a copy of a new integration.
+func (r *memoryIntegrationRepo) Create(ctx context.Context, i *integration.Integration) error {
+	if i == nil {
+		return errors.New("integration cannot be nil")
+	}
+	if i.ID == "" {
+		i.ID = primitive.NewObjectID().Hex()
+	}
+
+	stored, err := storedIntegration(i)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.integrations[i.ID]; ok {
+		return errors.New("an integration with this ID already exists")
+	}
+	r.integrations[i.ID] = stored
+	return nil
+}
+
+// GetByID retrieves an integration by its ID.
+func (r *memoryIntegrationRepo) GetByID(ctx context.Context, id string) (*integration.Integration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i, ok := r.integrations[id]
+	if !ok {
+		return nil, ErrIntegrationNotFound
+	}
+	return copyOf(i)
+}
+
+// GetByName retrieves an integration by its name.
+func (r *memoryIntegrationRepo) GetByName(ctx context.Context, name string) (*integration.Integration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, id := range sortedIDs(r.integrations) {
+		if i := r.integrations[id]; i.Name == name {
+			return copyOf(i)
+		}
+	}
+	return nil, ErrIntegrationNotFound
+}
+
+// GetAll retrieves all integrations, in the order they were created.
+func (r *memoryIntegrationRepo) GetAll(ctx context.Context) ([]*integration.Integration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var integrations []*integration.Integration
+	for _, id := range sortedIDs(r.integrations) {
+		i, err := copyOf(r.integrations[id])
+		if err != nil {
+			return nil, err
+		}
+		integrations = append(integrations, i)
+	}
+	return integrations, nil
+}
+
+// Update replaces an existing integration.
+func (r *memoryIntegrationRepo) Update(ctx context.Context, i *integration.Integration) error {
+	if i == nil {
+		return errors.New("integration cannot be nil")
+	}
+
+	stored, err := storedIntegration(i)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.integrations[i.ID]; !ok {
+		return ErrIntegrationNotFound
+	}
+	r.integrations[i.ID] = stored
+	return nil
+}
+
+// Delete removes an integration by its ID.
+func (r *memoryIntegrationRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.integrations, id)
+	return nil
+}
+
+// RotateKeys has nothing to do: credentials kept in memory are not encrypted.
+func (r *memoryIntegrationRepo) RotateKeys(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+// storedIntegration returns the copy of i to store. The environment applied
+// to an integration is never stored.
+func storedIntegration(i *integration.Integration) (*integration.Integration, error) {
+	stored, err := copyOf(i)
+	if err != nil {
+		return nil, err
+	}
+	stored.Environment = ""
+	return stored, nil
+}
+
+// memoryTransactor implements Transactor for the memory repositories.
+type memoryTransactor struct {
+	flows        *memoryFlowRepo
+	integrations *memoryIntegrationRepo
+}
+
+// NewMemoryTransactor creates a new Transactor for repositories created by
+// NewMemoryFlowRepository and NewMemoryIntegrationRepository.
+func NewMemoryTransactor(flows FlowRepository, integrations IntegrationRepository) Transactor {
+	return &memoryTransactor{
+		flows:        flows.(*memoryFlowRepo),
+		integrations: integrations.(*memoryIntegrationRepo),
+	}
+}
+
+// Transact calls fn with repositories holding copies of the stored documents,
+// which replace them if it succeeds. Both repositories are locked meanwhile.
+func (t *memoryTransactor) Transact(ctx context.Context, fn func(ctx context.Context, flows FlowRepository, integrations IntegrationRepository) error) error {
+	t.flows.mu.Lock()
+	defer t.flows.mu.Unlock()
+	t.integrations.mu.Lock()
+	defer t.integrations.mu.Unlock()
+
+	// Stored values are replaced, never modified, so copying the maps is enough.
+	flows := &memoryFlowRepo{flows: make(map[string]*flow.Flow, len(t.flows.flows))}
+	for id, f := range t.flows.flows {
+		flows.flows[id] = f
+	}
+	integrations := &memoryIntegrationRepo{integrations: make(map[string]*integration.Integration, len(t.integrations.integrations))}
+	for id, i := range t.integrations.integrations {
+		integrations.integrations[id] = i
+	}
+
+	if err := fn(ctx, flows, integrations); err != nil {
+		return err
+	}
+
+	t.flows.flows = flows.flows
+	t.integrations.integrations = integrations.integrations
+	return nil
+}
+
+// memorySecretRepo implements SecretRepository interface in memory.
+type memorySecretRepo struct {
+	mu      sync.RWMutex
+	secrets map[string]Secret // Stored secrets, by name
+}
+
+// NewMemorySecretRepository creates a new secret repository kept in memory.
+func NewMemorySecretRepository() SecretRepository {
+	return &memorySecretRepo{
+		secrets: map[string]Secret{},
+	}
+}
+
+// Get retrieves a secret by its name.
+func (r *memorySecretRepo) Get(ctx context.Context, name string) (*Secret, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.secrets[name]
+	if !ok {
+		return nil, ErrSecretNotFound
+	}
+	return &s, nil
+}
+
+// GetAll retrieves all secrets, sorted by name.
+func (r *memorySecretRepo) GetAll(ctx context.Context) ([]*Secret, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var secrets []*Secret
+	for _, name := range sortedIDs(r.secrets) {
+		s := r.secrets[name]
+		secrets = append(secrets, &s)
+	}
+	return secrets, nil
+}
+
+// Put creates or replaces a secret.
+func (r *memorySecretRepo) Put(ctx context.Context, s *Secret) error {
+	if s == nil {
+		return errors.New("secret cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secrets[s.Name] = *s
+	return nil
+}
+
+// Delete removes a secret by its name.
+func (r *memorySecretRepo) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.secrets[name]; !ok {
+		return ErrSecretNotFound
+	}
+	delete(r.secrets, name)
+	return nil
+}
+
+// copyOf returns a deep copy of v, made by serializing it as JSON like the
+// API does, so that stored values behave like documents read from a database.
+func copyOf[T any](v *T) (*T, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var c T
+	if err := json.Unmarshal(content, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// sortedIDs returns the keys of m in order. Generated IDs are ObjectIDs, which
+// sort in the order they were created.
+func sortedIDs[V any](m map[string]V) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migration is a versioned change of the PostgreSQL schema. Migrations are
+// applied once, in order, and never edited once released: change the schema
+// by appending a new one.
+type migration struct {
+	version     int
+	description string
+	sql         string
+}
+
+// migrations is the history of the PostgreSQL schema.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create flows, integrations and secrets",
+		sql: `
+CREATE TABLE flows (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	steps       JSONB NOT NULL,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX flows_name ON flows (name);
+
+CREATE TABLE integrations (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	type       TEXT NOT NULL,
+	endpoints  JSONB NOT NULL,
+	settings   JSONB NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX integrations_name ON integrations (name);
+
+CREATE TABLE secrets (
+	name       TEXT PRIMARY KEY,
+	value      TEXT NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);`,
+	},
+	{
+		version:     2,
+		description: "create the append-only events table",
+		sql: `
+CREATE TABLE events (
+	position   BIGSERIAL PRIMARY KEY,
+	stream_id  TEXT NOT NULL,
+	version    BIGINT NOT NULL,
+	type       TEXT NOT NULL,
+	data       JSONB NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	UNIQUE (stream_id, version)
+);
+
+CREATE FUNCTION events_append_only() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+	RAISE EXCEPTION 'events are append-only';
+END;
+$$;
+CREATE TRIGGER events_append_only BEFORE UPDATE OR DELETE ON events
+	FOR EACH ROW EXECUTE FUNCTION events_append_only();`,
+	},
+}
+
+// migrationLock is the key of the advisory lock held while migrating, so that
+// instances starting together do not apply the same migration twice.
+const migrationLock = 7240113
+
+// migrate applies the migrations newer than the version of the schema, each
+// in its own transaction.
+func migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLock); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLock)
+
+	_, err = conn.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version     INTEGER PRIMARY KEY,
+	description TEXT NOT NULL,
+	applied_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+)`)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	if err := conn.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, m.sql); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, description) VALUES ($1, $2)", m.version, m.description)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.description, err)
+		}
+		log.Printf("Applied postgres migration %d: %s", m.version, m.description)
+	}
+
+	return nil
+}
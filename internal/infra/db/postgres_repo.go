@@ -0,0 +1,458 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"generic-integration-platform/internal/domain/flow"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/encryption"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The Postgres repositories keep the fields that are looked up in columns and
+// the nested ones as JSONB: the steps of flows, the endpoints of integrations
+// and their other settings. The tables are created by the migrations run by
+// NewPostgres.
+
+// postgresConn runs the queries of a repository: the pool, or the
+// transaction of a Transactor.
+type postgresConn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// postgresFlowRepo implements FlowRepository interface with PostgreSQL.
+type postgresFlowRepo struct {
+	conn postgresConn
+}
+
+// NewPostgresFlowRepository creates a new flow repository stored in
+// PostgreSQL.
+func NewPostgresFlowRepository(pg *Postgres) FlowRepository {
+	return &postgresFlowRepo{
+		conn: pg.Pool,
+	}
+}
+
+// flowColumns are the columns scanned by scanFlow.
+const flowColumns = "id, name, description, steps"
+
+// Create inserts a new flow into the database.
+func (r *postgresFlowRepo) Create(ctx context.Context, f *flow.Flow) error {
+	if f == nil {
+		return errors.New("flow cannot be nil")
+	}
+	if f.ID == "" {
+		f.ID = primitive.NewObjectID().Hex()
+	}
+
+	steps, err := json.Marshal(f.Steps)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.conn.Exec(ctx,
+		"INSERT INTO flows (id, name, description, steps) VALUES ($1, $2, $3, $4)",
+		f.ID, f.Name, f.Description, steps)
+	return err
+}
+
+// GetByID retrieves a flow by its ID.
+func (r *postgresFlowRepo) GetByID(ctx context.Context, id string) (*flow.Flow, error) {
+	return r.findOne(ctx, "SELECT "+flowColumns+" FROM flows WHERE id = $1", id)
+}
+
+// GetByName retrieves a flow by its name.
+func (r *postgresFlowRepo) GetByName(ctx context.Context, name string) (*flow.Flow, error) {
+	return r.findOne(ctx, "SELECT "+flowColumns+" FROM flows WHERE name = $1 ORDER BY created_at, id LIMIT 1", name)
+}
+
+// findOne retrieves the flow selected by query.
+func (r *postgresFlowRepo) findOne(ctx context.Context, query string, args ...any) (*flow.Flow, error) {
+	f, err := scanFlow(r.conn.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrFlowNotFound
+	}
+	return f, err
+}
+
+// GetAll retrieves all flows from the database, in the order they were
+// created.
+func (r *postgresFlowRepo) GetAll(ctx context.Context) ([]*flow.Flow, error) {
+	rows, err := r.conn.Query(ctx, "SELECT "+flowColumns+" FROM flows ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flows []*flow.Flow
+	for rows.Next() {
+		f, err := scanFlow(rows)
+		if err != nil {
+			return nil, err
+		}
+		flows = append(flows, f)
+	}
+
+	return flows, rows.Err()
+}
+
+// Update modifies an existing flow in the database.
+func (r *postgresFlowRepo) Update(ctx context.Context, f *flow.Flow) error {
+	if f == nil {
+		return errors.New("flow cannot be nil")
+	}
+
+	steps, err := json.Marshal(f.Steps)
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.conn.Exec(ctx,
+		"UPDATE flows SET name = $2, description = $3, steps = $4 WHERE id = $1",
+		f.ID, f.Name, f.Description, steps)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrFlowNotFound
+	}
+	return nil
+}
+
+// Delete removes a flow from the database by its ID.
+func (r *postgresFlowRepo) Delete(ctx context.Context, id string) error {
+	_, err := r.conn.Exec(ctx, "DELETE FROM flows WHERE id = $1", id)
+	return err
+}
+
+// scanFlow reads a flow from the flowColumns of row.
+func scanFlow(row pgx.Row) (*flow.Flow, error) {
+	var f flow.Flow
+	var steps []byte
+	if err := row.Scan(&f.ID, &f.Name, &f.Description, &steps); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(steps, &f.Steps); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// postgresIntegrationRepo implements IntegrationRepository interface with
+// PostgreSQL. Credential fields are envelope-encrypted at rest when a keyring
+// is configured.
+type postgresIntegrationRepo struct {
+	conn    postgresConn
+	keyring *encryption.Keyring
+}
+
+// NewPostgresIntegrationRepository creates a new integration repository
+// stored in PostgreSQL.
+func NewPostgresIntegrationRepository(pg *Postgres, keyring *encryption.Keyring) IntegrationRepository {
+	return &postgresIntegrationRepo{
+		conn:    pg.Pool,
+		keyring: keyring,
+	}
+}
+
+// integrationColumns are the columns scanned by scanIntegration.
+const integrationColumns = "id, name, type, endpoints, settings"
+
+// Create inserts a new integration into the database.
+func (r *postgresIntegrationRepo) Create(ctx context.Context, i *integration.Integration) error {
+	if i == nil {
+		return errors.New("integration cannot be nil")
+	}
+	if i.ID == "" {
+		i.ID = primitive.NewObjectID().Hex()
+	}
+
+	endpoints, settings, err := r.columns(i)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.conn.Exec(ctx,
+		"INSERT INTO integrations (id, name, type, endpoints, settings) VALUES ($1, $2, $3, $4, $5)",
+		i.ID, i.Name, i.Type, endpoints, settings)
+	return err
+}
+
+// GetByID retrieves an integration by its ID.
+func (r *postgresIntegrationRepo) GetByID(ctx context.Context, id string) (*integration.Integration, error) {
+	return r.findOne(ctx, "SELECT "+integrationColumns+" FROM integrations WHERE id = $1", id)
+}
+
+// GetByName retrieves an integration by its name.
+func (r *postgresIntegrationRepo) GetByName(ctx context.Context, name string) (*integration.Integration, error) {
+	return r.findOne(ctx, "SELECT "+integrationColumns+" FROM integrations WHERE name = $1 ORDER BY created_at, id LIMIT 1", name)
+}
+
+// findOne retrieves the integration selected by query.
+func (r *postgresIntegrationRepo) findOne(ctx context.Context, query string, args ...any) (*integration.Integration, error) {
+	i, err := scanIntegration(r.conn.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrIntegrationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := open(r.keyring, i); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+// GetAll retrieves all integrations from the database, in the order they
+// were created.
+func (r *postgresIntegrationRepo) GetAll(ctx context.Context) ([]*integration.Integration, error) {
+	rows, err := r.conn.Query(ctx, "SELECT "+integrationColumns+" FROM integrations ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var integrations []*integration.Integration
+	for rows.Next() {
+		i, err := scanIntegration(rows)
+		if err != nil {
+			return nil, err
+		}
+		if err := open(r.keyring, i); err != nil {
+			return nil, err
+		}
+		integrations = append(integrations, i)
+	}
+
+	return integrations, rows.Err()
+}
+
+// Update modifies an existing integration in the database.
+func (r *postgresIntegrationRepo) Update(ctx context.Context, i *integration.Integration) error {
+	if i == nil {
+		return errors.New("integration cannot be nil")
+	}
+
+	endpoints, settings, err := r.columns(i)
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.conn.Exec(ctx,
+		"UPDATE integrations SET name = $2, type = $3, endpoints = $4, settings = $5 WHERE id = $1",
+		i.ID, i.Name, i.Type, endpoints, settings)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrIntegrationNotFound
+	}
+	return nil
+}
+
+// Delete removes an integration from the database by its ID.
+func (r *postgresIntegrationRepo) Delete(ctx context.Context, id string) error {
+	_, err := r.conn.Exec(ctx, "DELETE FROM integrations WHERE id = $1", id)
+	return err
+}
+
+// RotateKeys re-wraps the data keys of every encrypted credential with the
+// active master key, and encrypts the credentials still stored in plaintext.
+// It returns the number of integrations updated.
+func (r *postgresIntegrationRepo) RotateKeys(ctx context.Context) (int, error) {
+	if r.keyring == nil {
+		return 0, errNoMasterKey
+	}
+
+	updated := 0
+	err := pgx.BeginFunc(ctx, r.conn, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, "SELECT "+integrationColumns+" FROM integrations FOR UPDATE")
+		if err != nil {
+			return err
+		}
+		integrations, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*integration.Integration, error) {
+			return scanIntegration(row)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, stored := range integrations {
+			changed, err := rewrap(r.keyring, stored)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+			settings, err := json.Marshal(settingsOf(stored))
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, "UPDATE integrations SET settings = $2 WHERE id = $1", stored.ID, settings); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		// Nothing was written: the transaction was rolled back.
+		return 0, err
+	}
+
+	return updated, nil
+}
+
+// columns returns the JSONB columns of i, with its credentials encrypted.
+func (r *postgresIntegrationRepo) columns(i *integration.Integration) (endpoints, settings []byte, err error) {
+	sealed, err := seal(r.keyring, i)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if endpoints, err = json.Marshal(sealed.Endpoints); err != nil {
+		return nil, nil, err
+	}
+	if settings, err = json.Marshal(settingsOf(sealed)); err != nil {
+		return nil, nil, err
+	}
+	return endpoints, settings, nil
+}
+
+// settingsOf returns the settings of i stored in the settings column: all
+// but its ID and endpoints, kept in their own columns, and the environment
+// applied to it, which is never stored.
+func settingsOf(i *integration.Integration) *integration.Integration {
+	settings := *i
+	settings.ID = ""
+	settings.Endpoints = nil
+	settings.Environment = ""
+	return &settings
+}
+
+// scanIntegration reads an integration from the integrationColumns of row.
+// Its credentials are left as stored.
+func scanIntegration(row pgx.Row) (*integration.Integration, error) {
+	var id, name, typ string
+	var endpoints, settings []byte
+	if err := row.Scan(&id, &name, &typ, &endpoints, &settings); err != nil {
+		return nil, err
+	}
+
+	var i integration.Integration
+	if err := json.Unmarshal(settings, &i); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(endpoints, &i.Endpoints); err != nil {
+		return nil, err
+	}
+	i.ID, i.Name, i.Type = id, name, typ
+	return &i, nil
+}
+
+// postgresTransactor implements Transactor with PostgreSQL transactions.
+type postgresTransactor struct {
+	pool    *pgxpool.Pool
+	keyring *encryption.Keyring
+}
+
+// NewPostgresTransactor creates a new Transactor for the Postgres
+// repositories of flows and integrations, encrypting credentials with keyring
+// like NewPostgresIntegrationRepository.
+func NewPostgresTransactor(pg *Postgres, keyring *encryption.Keyring) Transactor {
+	return &postgresTransactor{
+		pool:    pg.Pool,
+		keyring: keyring,
+	}
+}
+
+// Transact runs fn in a transaction of a connection of the pool.
+func (t *postgresTransactor) Transact(ctx context.Context, fn func(ctx context.Context, flows FlowRepository, integrations IntegrationRepository) error) error {
+	return pgx.BeginFunc(ctx, t.pool, func(tx pgx.Tx) error {
+		return fn(ctx, &postgresFlowRepo{conn: tx}, &postgresIntegrationRepo{conn: tx, keyring: t.keyring})
+	})
+}
+
+// postgresSecretRepo implements SecretRepository interface with PostgreSQL.
+type postgresSecretRepo struct {
+	conn postgresConn
+}
+
+// NewPostgresSecretRepository creates a new secret repository stored in
+// PostgreSQL.
+func NewPostgresSecretRepository(pg *Postgres) SecretRepository {
+	return &postgresSecretRepo{
+		conn: pg.Pool,
+	}
+}
+
+// Get retrieves a secret by its name.
+func (r *postgresSecretRepo) Get(ctx context.Context, name string) (*Secret, error) {
+	var s Secret
+	err := r.conn.QueryRow(ctx, "SELECT name, value, updated_at FROM secrets WHERE name = $1", name).
+		Scan(&s.Name, &s.Value, &s.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSecretNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// GetAll retrieves all secrets, sorted by name.
+func (r *postgresSecretRepo) GetAll(ctx context.Context) ([]*Secret, error) {
+	rows, err := r.conn.Query(ctx, "SELECT name, value, updated_at FROM secrets ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secrets []*Secret
+	for rows.Next() {
+		var s Secret
+		if err := rows.Scan(&s.Name, &s.Value, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, &s)
+	}
+
+	return secrets, rows.Err()
+}
+
+// Put creates or replaces a secret.
+func (r *postgresSecretRepo) Put(ctx context.Context, s *Secret) error {
+	if s == nil {
+		return errors.New("secret cannot be nil")
+	}
+
+	_, err := r.conn.Exec(ctx, `
+INSERT INTO secrets (name, value, updated_at) VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		s.Name, s.Value, s.UpdatedAt)
+	return err
+}
+
+// Delete removes a secret from the database by its name.
+func (r *postgresSecretRepo) Delete(ctx context.Context, name string) error {
+	result, err := r.conn.Exec(ctx, "DELETE FROM secrets WHERE name = $1", name)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrSecretNotFound
+	}
+	return nil
+}
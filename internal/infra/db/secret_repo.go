@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrSecretNotFound is returned when no secret is stored under a name.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// Secret is a secret value stored encrypted by the platform.
+type Secret struct {
+	Name      string    `bson:"_id"`        // Name of the secret, e.g. "stripe/live-key"
+	Value     string    `bson:"value"`      // Encrypted value
+	UpdatedAt time.Time `bson:"updated_at"` // When the value was last written
+}
+
+// SecretRepository defines the interface for secret repository methods.
+type SecretRepository interface {
+	Get(ctx context.Context, name string) (*Secret, error)
+	GetAll(ctx context.Context) ([]*Secret, error)
+	Put(ctx context.Context, s *Secret) error
+	Delete(ctx context.Context, name string) error
+}
+
+// secretRepo implements SecretRepository interface.
+type secretRepo struct {
+	collection *mongo.Collection
+}
+
+// NewSecretRepository creates a new secret repository.
+func NewSecretRepository(mdb *MongoDB) SecretRepository {
+	return &secretRepo{
+		collection: mdb.Database.Collection("secrets"),
+	}
+}
+
+// Get retrieves a secret by its name.
+func (r *secretRepo) Get(ctx context.Context, name string) (*Secret, error) {
+	var s Secret
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSecretNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// GetAll retrieves all secrets from the database.
+func (r *secretRepo) GetAll(ctx context.Context) ([]*Secret, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var secrets []*Secret
+	if err := cursor.All(ctx, &secrets); err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// Put creates or replaces a secret.
+func (r *secretRepo) Put(ctx context.Context, s *Secret) error {
+	if s == nil {
+		return errors.New("secret cannot be nil")
+	}
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": s.Name}, s, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete removes a secret from the database by its name.
+func (r *secretRepo) Delete(ctx context.Context, name string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrSecretNotFound
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrTransactionsUnsupported is returned by Transact when the database cannot
+// run transactions, such as a MongoDB server outside a replica set.
+var ErrTransactionsUnsupported = errors.New("the database does not support transactions")
+
+// Transactor runs writes to several flows and integrations as one transaction.
+type Transactor interface {
+	// Transact calls fn with repositories whose writes are committed together
+	// when it returns nil, and discarded otherwise. fn must only use the
+	// repositories and the context it is given.
+	Transact(ctx context.Context, fn func(ctx context.Context, flows FlowRepository, integrations IntegrationRepository) error) error
+}
+
+// mongoTransactor implements Transactor with MongoDB sessions.
+type mongoTransactor struct {
+	client       *mongo.Client
+	flows        FlowRepository
+	integrations IntegrationRepository
+}
+
+// NewMongoTransactor creates a new Transactor for the Mongo repositories of
+// flows and integrations. MongoDB only runs transactions on replica sets and
+// sharded clusters: on a standalone server, Transact returns
+// ErrTransactionsUnsupported without calling fn.
+func NewMongoTransactor(mdb *MongoDB, flows FlowRepository, integrations IntegrationRepository) Transactor {
+	return &mongoTransactor{
+		client:       mdb.Client,
+		flows:        flows,
+		integrations: integrations,
+	}
+}
+
+// Transact runs fn in a session transaction. The Mongo repositories take part
+// in the transaction through the session context passed to fn, which may be
+// called again when the transaction hits a transient error.
+func (t *mongoTransactor) Transact(ctx context.Context, fn func(ctx context.Context, flows FlowRepository, integrations IntegrationRepository) error) error {
+	supported, err := t.supportsTransactions(ctx)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return ErrTransactionsUnsupported
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc, t.flows, t.integrations)
+	})
+	return err
+}
+
+// supportsTransactions reports whether the server is a replica set member or
+// a mongos router.
+func (t *mongoTransactor) supportsTransactions(ctx context.Context) (bool, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := t.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, err
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid", nil
+}
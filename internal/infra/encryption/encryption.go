@@ -0,0 +1,273 @@
+// Package encryption implements envelope encryption of the credentials stored
+// by the platform.
+//
+// Every value is encrypted with its own random data key, and the data key is
+// wrapped by a master key. Values are stored as strings of the form
+//
+//	enc:v1:<master key id>:<wrapped data key>:<encrypted value>
+//
+// so that rotating the master key only requires re-wrapping the data keys.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/infra/config"
+	"os"
+	"strings"
+
+	"go.uber.org/fx"
+)
+
+const (
+	prefix      = "enc:v1:"
+	keySize     = 32
+	dataKeySize = 32
+)
+
+var (
+	// ErrUnknownKey is returned when a value was wrapped by a master key that is
+	// not in the keyring.
+	ErrUnknownKey = errors.New("value was encrypted with an unknown master key")
+	// ErrMalformed is returned when a value looks encrypted but cannot be parsed.
+	ErrMalformed = errors.New("malformed encrypted value")
+)
+
+// masterKey is a key wrapping data keys.
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// Keyring holds the master keys. The first key wraps new data keys; the others
+// are only used to unwrap values encrypted before a rotation.
+type Keyring struct {
+	keys []masterKey
+}
+
+// LoadKeyring loads the master keys from the ENCRYPTION_MASTER_KEY variable or
+// the file at ENCRYPTION_MASTER_KEY_FILE. Keys are base64-encoded 32-byte
+// keys, separated by commas or new lines, the active key first. It returns
+// nil when no key is configured.
+func LoadKeyring(cfg *config.Config) (*Keyring, error) {
+	source := cfg.Encryption.MasterKey
+	if cfg.Encryption.MasterKeyFile != "" {
+		content, err := os.ReadFile(cfg.Encryption.MasterKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master key file: %w", err)
+		}
+		source = string(content)
+	}
+
+	encoded := strings.FieldsFunc(source, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' '
+	})
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	keys := make([][]byte, 0, len(encoded))
+	for _, e := range encoded {
+		key, err := base64.StdEncoding.DecodeString(e)
+		if err != nil || len(key) != keySize {
+			return nil, errors.New("master keys must be base64-encoded 32-byte keys")
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeyring(keys...)
+}
+
+// NewKeyring creates a Keyring from raw 32-byte keys, the active key first.
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one master key is required")
+	}
+
+	k := &Keyring{}
+	for _, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(key)
+		k.keys = append(k.keys, masterKey{id: hex.EncodeToString(sum[:4]), aead: aead})
+	}
+
+	return k, nil
+}
+
+// ActiveKeyID returns the identifier of the key wrapping new data keys.
+func (k *Keyring) ActiveKeyID() string {
+	return k.keys[0].id
+}
+
+// IsEncrypted reports whether value is an encrypted value.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt encrypts plaintext with a new data key. aad is authenticated with the
+// value, so it cannot be decrypted in another context, e.g. another field.
+func (k *Keyring) Encrypt(plaintext, aad string) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(aead, []byte(plaintext), []byte(aad))
+	if err != nil {
+		return "", err
+	}
+
+	active := k.keys[0]
+	wrapped, err := seal(active.aead, dataKey, []byte(active.id))
+	if err != nil {
+		return "", err
+	}
+
+	return format(active.id, wrapped, ciphertext), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt with the same aad. Values that
+// are not encrypted are returned as they are, so that credentials stored
+// before encryption was enabled keep working.
+func (k *Keyring) Decrypt(value, aad string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	dataKey, ciphertext, err := k.unwrap(value)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, ciphertext, []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// Rewrap wraps the data key of value with the active master key, leaving the
+// encrypted value untouched. Values that are not encrypted yet are encrypted.
+// It reports whether the value changed.
+func (k *Keyring) Rewrap(value, aad string) (string, bool, error) {
+	if !IsEncrypted(value) {
+		encrypted, err := k.Encrypt(value, aad)
+		return encrypted, err == nil, err
+	}
+
+	id, _, _, err := parse(value)
+	if err != nil {
+		return "", false, err
+	}
+	active := k.keys[0]
+	if id == active.id {
+		return value, false, nil
+	}
+
+	dataKey, ciphertext, err := k.unwrap(value)
+	if err != nil {
+		return "", false, err
+	}
+	wrapped, err := seal(active.aead, dataKey, []byte(active.id))
+	if err != nil {
+		return "", false, err
+	}
+
+	return format(active.id, wrapped, ciphertext), true, nil
+}
+
+// unwrap returns the data key and the encrypted value of value.
+func (k *Keyring) unwrap(value string) ([]byte, []byte, error) {
+	id, wrapped, ciphertext, err := parse(value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, key := range k.keys {
+		if key.id != id {
+			continue
+		}
+		dataKey, err := open(key.aead, wrapped, []byte(id))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to unwrap data key: %w", err)
+		}
+		return dataKey, ciphertext, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+}
+
+// format serializes an encrypted value.
+func format(id string, wrapped, ciphertext []byte) string {
+	return prefix + id + ":" + base64.RawStdEncoding.EncodeToString(wrapped) + ":" + base64.RawStdEncoding.EncodeToString(ciphertext)
+}
+
+// parse splits an encrypted value into its master key id, wrapped data key and
+// encrypted value.
+func parse(value string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformed
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+
+	return parts[0], wrapped, ciphertext, nil
+}
+
+// newAEAD creates an AES-256-GCM cipher.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext, prefixing the result with a random nonce.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open decrypts a value produced by seal.
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	size := aead.NonceSize()
+	if len(sealed) < size {
+		return nil, ErrMalformed
+	}
+	return aead.Open(nil, sealed[:size], sealed[size:], aad)
+}
+
+// Module provides the master keyring for Uber Fx.
+var Module = fx.Options(
+	fx.Provide(LoadKeyring),
+)
@@ -0,0 +1,184 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testKey returns a 32-byte key filled with b.
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, keySize)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	keyring, err := NewKeyring(testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		plaintext  string
+		encryptAAD string
+		decryptAAD string
+		wantErr    bool
+	}{
+		{name: "same aad", plaintext: "sk_test_123", encryptAAD: "id1/auth_token", decryptAAD: "id1/auth_token"},
+		{name: "empty value", plaintext: "", encryptAAD: "id1/auth_token", decryptAAD: "id1/auth_token"},
+		{name: "other field", plaintext: "sk_test_123", encryptAAD: "id1/auth_token", decryptAAD: "id1/hmac.secret", wantErr: true},
+		{name: "other integration", plaintext: "sk_test_123", encryptAAD: "id1/auth_token", decryptAAD: "id2/auth_token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := keyring.Encrypt(tt.plaintext, tt.encryptAAD)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !IsEncrypted(encrypted) {
+				t.Fatalf("Encrypt() = %q, want an encrypted value", encrypted)
+			}
+			if tt.plaintext != "" && strings.Contains(encrypted, tt.plaintext) {
+				t.Fatalf("Encrypt() = %q contains the plaintext", encrypted)
+			}
+
+			decrypted, err := keyring.Decrypt(encrypted, tt.decryptAAD)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && decrypted != tt.plaintext {
+				t.Errorf("Decrypt() = %q, want %q", decrypted, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	keyring, err := NewKeyring(testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewKeyring(testKey(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := other.Encrypt("secret", "aad")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr error
+	}{
+		{name: "plaintext", value: "not encrypted", want: "not encrypted"},
+		{name: "unknown key", value: foreign, wantErr: ErrUnknownKey},
+		{name: "missing parts", value: prefix + "abcd:efgh", wantErr: ErrMalformed},
+		{name: "invalid base64", value: prefix + "abcd:!!!:efgh", wantErr: ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := keyring.Decrypt(tt.value, "aad")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Decrypt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	old, err := NewKeyring(testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := NewKeyring(testKey(2), testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryptedWithOld, err := old.Encrypt("secret", "aad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedWithNew, err := rotated.Encrypt("secret", "aad")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		value       string
+		wantChanged bool
+	}{
+		{name: "wrapped by a previous key", value: encryptedWithOld, wantChanged: true},
+		{name: "wrapped by the active key", value: encryptedWithNew, wantChanged: false},
+		{name: "plaintext", value: "secret", wantChanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, changed, err := rotated.Rewrap(tt.value, "aad")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("Rewrap() changed = %v, want %v", changed, tt.wantChanged)
+			}
+
+			id, _, _, err := parse(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != rotated.ActiveKeyID() {
+				t.Errorf("Rewrap() key = %s, want the active key %s", id, rotated.ActiveKeyID())
+			}
+
+			decrypted, err := rotated.Decrypt(value, "aad")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decrypted != "secret" {
+				t.Errorf("Decrypt() = %q, want %q", decrypted, "secret")
+			}
+
+			// Once rewrapped, the previous key is no longer needed.
+			active, err := NewKeyring(testKey(2))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := active.Decrypt(value, "aad"); err != nil {
+				t.Errorf("Decrypt() with the active key only: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    [][]byte
+		wantErr bool
+	}{
+		{name: "one key", keys: [][]byte{testKey(1)}},
+		{name: "several keys", keys: [][]byte{testKey(1), testKey(2)}},
+		{name: "no key", wantErr: true},
+		{name: "short key", keys: [][]byte{[]byte("short")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.keys...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewKeyring() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
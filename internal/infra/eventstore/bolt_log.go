@@ -0,0 +1,118 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// eventsBucket holds a nested bucket per stream, whose events are keyed by
+// their version as a big-endian integer so that they are kept in order.
+var eventsBucket = []byte("events")
+
+// boltLog implements Log with bbolt. Appends are serialized by the write
+// transactions of bbolt, so checking the expected version cannot race.
+type boltLog struct {
+	db *bbolt.DB
+}
+
+// boltEvent is a recorded event, as stored in bbolt.
+type boltEvent struct {
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// NewBoltLog creates a new event log stored in bbolt.
+func NewBoltLog(db *bbolt.DB) Log {
+	return &boltLog{
+		db: db,
+	}
+}
+
+// Append adds events to the end of the stream in a single transaction.
+func (l *boltLog) Append(ctx context.Context, streamID string, expected ExpectedVersion, events ...EventData) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	return l.db.Update(func(tx *bbolt.Tx) error {
+		root, err := tx.CreateBucketIfNotExists(eventsBucket)
+		if err != nil {
+			return err
+		}
+		stream, err := root.CreateBucketIfNotExists([]byte(streamID))
+		if err != nil {
+			return err
+		}
+
+		// The sequence of the stream counts its events.
+		if err := expected.check(stream.Sequence()); err != nil {
+			return err
+		}
+		for _, e := range events {
+			content, err := json.Marshal(boltEvent{Type: e.Type, Data: e.Data, CreatedAt: now})
+			if err != nil {
+				return err
+			}
+			length, err := stream.NextSequence()
+			if err != nil {
+				return err
+			}
+			if err := stream.Put(versionKey(length-1), content); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Read returns the events of the stream stored in bbolt.
+func (l *boltLog) Read(ctx context.Context, streamID string, from, count uint64) ([]RecordedEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var events []RecordedEvent
+	err := l.db.View(func(tx *bbolt.Tx) error {
+		root := tx.Bucket(eventsBucket)
+		if root == nil {
+			return nil
+		}
+		stream := root.Bucket([]byte(streamID))
+		if stream == nil {
+			return nil
+		}
+
+		c := stream.Cursor()
+		for k, v := c.Seek(versionKey(from)); k != nil && uint64(len(events)) < count; k, v = c.Next() {
+			var e boltEvent
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			events = append(events, RecordedEvent{
+				Version:   binary.BigEndian.Uint64(k),
+				Type:      e.Type,
+				Data:      e.Data,
+				CreatedAt: e.CreatedAt,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// versionKey returns the key of the event at version in its stream bucket.
+func versionKey(version uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, version)
+	return key
+}
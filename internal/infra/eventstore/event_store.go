@@ -1,7 +1,13 @@
 package eventstore
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"generic-integration-platform/internal/infra/config"
+	"io"
+	"time"
 
 	"github.com/EventStore/EventStore-Client-Go/esdb"
 	"go.uber.org/fx"
@@ -28,9 +34,153 @@ func NewEventStoreClient(config *config.Config) *EventStore {
 	}
 }
 
+// Client exposes the underlying EventStoreDB client.
+func (es *EventStore) Client() *esdb.Client {
+	return es.DB
+}
+
+// Log is an append-only log of events, split in streams such as the events of
+// a flow. Events are read back in the order they were appended to their stream.
+type Log interface {
+	// Append appends events to the end of a stream, creating it if needed. It
+	// fails with ErrWrongVersion when the stream is not at the expected version.
+	Append(ctx context.Context, streamID string, expected ExpectedVersion, events ...EventData) error
+	// Read returns up to count events of a stream, starting at version from.
+	// Streams that do not exist yet have no events.
+	Read(ctx context.Context, streamID string, from, count uint64) ([]RecordedEvent, error)
+}
+
+// ExpectedVersion is the version of the last event of a stream expected by an
+// append, or one of AnyVersion and NoStream.
+type ExpectedVersion int64
+
+const (
+	AnyVersion ExpectedVersion = -2 // Append whatever the version of the stream
+	NoStream   ExpectedVersion = -1 // Append only to a stream without events
+)
+
+// ErrWrongVersion is returned when a stream is not at the version expected by
+// an append, because other events were appended concurrently.
+var ErrWrongVersion = errors.New("stream is not at the expected version")
+
+// check returns ErrWrongVersion unless a stream of length events is at the
+// expected version.
+func (expected ExpectedVersion) check(length uint64) error {
+	if expected == AnyVersion || int64(length)-1 == int64(expected) {
+		return nil
+	}
+	return fmt.Errorf("%w: expected %d, stream has %d event(s)", ErrWrongVersion, expected, length)
+}
+
+// EventData is an event to append to a stream.
+type EventData struct {
+	Type string          // Type of the event, e.g. FlowStepCompletedEvent
+	Data json.RawMessage // The event, serialized as JSON
+}
+
+// RecordedEvent is an event read back from a stream.
+type RecordedEvent struct {
+	Version   uint64          // Position of the event in its stream, starting at 0
+	Type      string          // Type of the event, e.g. FlowStepCompletedEvent
+	Data      json.RawMessage // The event, as appended
+	CreatedAt time.Time       // When the event was stored
+}
+
+// appendEvent serializes event and appends it to a stream of log.
+func appendEvent(ctx context.Context, log Log, streamID string, event interface{}, eventType string) error {
+	eventData, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize event: %w", err)
+	}
+
+	if err := log.Append(ctx, streamID, AnyVersion, EventData{Type: eventType, Data: eventData}); err != nil {
+		return fmt.Errorf("failed to append event to stream: %w", err)
+	}
+
+	return nil
+}
+
+// eventStoreDBLog implements Log with EventStoreDB.
+type eventStoreDBLog struct {
+	client *esdb.Client
+}
+
+// NewEventStoreDBLog creates a new event log stored in EventStoreDB.
+func NewEventStoreDBLog(client *esdb.Client) Log {
+	return &eventStoreDBLog{
+		client: client,
+	}
+}
+
+// Append writes events to the stream in EventStoreDB.
+func (l *eventStoreDBLog) Append(ctx context.Context, streamID string, expected ExpectedVersion, events ...EventData) error {
+	data := make([]esdb.EventData, len(events))
+	for i, e := range events {
+		data[i] = esdb.EventData{
+			ContentType: esdb.JsonContentType,
+			EventType:   e.Type,
+			Data:        e.Data,
+		}
+	}
+
+	var revision esdb.ExpectedRevision
+	switch expected {
+	case AnyVersion:
+		revision = esdb.Any{}
+	case NoStream:
+		revision = esdb.NoStream{}
+	default:
+		revision = esdb.Revision(uint64(expected))
+	}
+
+	_, err := l.client.AppendToStream(ctx, streamID, esdb.AppendToStreamOptions{ExpectedRevision: revision}, data...)
+	if errors.Is(err, esdb.ErrWrongExpectedStreamRevision) {
+		return fmt.Errorf("%w: %v", ErrWrongVersion, err)
+	}
+	return err
+}
+
+// Read reads the events of a stream from EventStoreDB.
+func (l *eventStoreDBLog) Read(ctx context.Context, streamID string, from, count uint64) ([]RecordedEvent, error) {
+	stream, err := l.client.ReadStream(ctx, streamID, esdb.ReadStreamOptions{
+		Direction: esdb.Forwards,
+		From:      esdb.Revision(from),
+	}, count)
+	if errors.Is(err, esdb.ErrStreamNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	var events []RecordedEvent
+	for {
+		resolved, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if errors.Is(err, esdb.ErrStreamNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		e := resolved.OriginalEvent()
+		events = append(events, RecordedEvent{
+			Version:   e.EventNumber,
+			Type:      e.EventType,
+			Data:      e.Data,
+			CreatedAt: e.CreatedDate,
+		})
+	}
+}
+
+// Module provides the flow and integration event stores, recorded in the
+// event log of the storage backend.
 var Module = fx.Option(
 	fx.Provide(
-		NewEventStoreClient,
 		NewFlowEventStore,
 		NewIntegrationEventStore,
 	),
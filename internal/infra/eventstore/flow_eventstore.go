@@ -2,78 +2,82 @@ package eventstore
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"generic-integration-platform/internal/domain/flow"
 	"time"
-
-	esdb "github.com/EventStore/EventStore-Client-Go/esdb"
 )
 
-// FlowEventStore handles storing flow events in EventStoreDB.
-type FlowEventStore struct {
-	client *esdb.Client
+// FlowEventStore records the events of flows, one stream per flow.
+type FlowEventStore interface {
+	AppendFlowCreatedEvent(ctx context.Context, event FlowCreatedEvent) error
+	AppendFlowStepCompletedEvent(ctx context.Context, event FlowStepCompletedEvent) error
+	AppendFlowUpdatedEvent(ctx context.Context, event FlowUpdatedEvent) error
+	AppendFlowDeletedEvent(ctx context.Context, event FlowDeletedEvent) error
+	AppendFlowStepFailedEvent(ctx context.Context, event FlowStepFailedEvent) error
+	AppendFlowStepSkippedEvent(ctx context.Context, event FlowStepSkippedEvent) error
+	AppendFlowExecutedEvent(ctx context.Context, event FlowExecutedEvent) error
+	ReadFlowEvents(ctx context.Context, flowID string, from, limit uint64) ([]RecordedEvent, error)
+}
+
+// flowEventStore implements FlowEventStore interface.
+type flowEventStore struct {
+	log Log
 }
 
-// NewFlowEventStore creates a new instance of FlowEventStore.
-func NewFlowEventStore(client *esdb.Client) *FlowEventStore {
-	return &FlowEventStore{
-		client: client,
+// NewFlowEventStore creates a new flow event store recording to log.
+func NewFlowEventStore(log Log) FlowEventStore {
+	return &flowEventStore{
+		log: log,
 	}
 }
 
-// AppendFlowCreatedEvent stores the FlowCreated event in EventStore.
-func (store *FlowEventStore) AppendFlowCreatedEvent(ctx context.Context, event FlowCreatedEvent) error {
+// AppendFlowCreatedEvent stores the FlowCreated event in the event log.
+func (store *flowEventStore) AppendFlowCreatedEvent(ctx context.Context, event FlowCreatedEvent) error {
 	return store.appendEvent(ctx, "flow-"+event.FlowID, event, "FlowCreatedEvent")
 }
 
-// AppendFlowStepCompletedEvent stores the FlowStepCompleted event in EventStore.
-func (store *FlowEventStore) AppendFlowStepCompletedEvent(ctx context.Context, event FlowStepCompletedEvent) error {
+// AppendFlowStepCompletedEvent stores the FlowStepCompleted event in the event log.
+func (store *flowEventStore) AppendFlowStepCompletedEvent(ctx context.Context, event FlowStepCompletedEvent) error {
 	return store.appendEvent(ctx, "flow-"+event.FlowID, event, "FlowStepCompletedEvent")
 }
 
-// AppendFlowUpdatedEvent stores the FlowUpdated event in EventStore.
-func (store *FlowEventStore) AppendFlowUpdatedEvent(ctx context.Context, event FlowUpdatedEvent) error {
+// AppendFlowUpdatedEvent stores the FlowUpdated event in the event log.
+func (store *flowEventStore) AppendFlowUpdatedEvent(ctx context.Context, event FlowUpdatedEvent) error {
 	return store.appendEvent(ctx, "flow-"+event.FlowID, event, "FlowUpdatedEvent")
 }
 
-// AppendFlowDeletedEvent stores the FlowDeleted event in EventStore.
-func (store *FlowEventStore) AppendFlowDeletedEvent(ctx context.Context, event FlowDeletedEvent) error {
+// AppendFlowDeletedEvent stores the FlowDeleted event in the event log.
+func (store *flowEventStore) AppendFlowDeletedEvent(ctx context.Context, event FlowDeletedEvent) error {
 	return store.appendEvent(ctx, "flow-"+event.FlowID, event, "FlowDeletedEvent")
 }
 
-// AppendFlowStepFailedEvent stores the FlowStepFailed event in EventStore.
-func (store *FlowEventStore) AppendFlowStepFailedEvent(ctx context.Context, event FlowStepFailedEvent) error {
+// AppendFlowStepFailedEvent stores the FlowStepFailed event in the event log.
+func (store *flowEventStore) AppendFlowStepFailedEvent(ctx context.Context, event FlowStepFailedEvent) error {
 	return store.appendEvent(ctx, "flow-"+event.FlowID, event, "FlowStepFailedEvent")
 }
 
-// AppendFlowExecutedEvent stores the FlowExecuted event in EventStore.
-func (store *FlowEventStore) AppendFlowExecutedEvent(ctx context.Context, event FlowExecutedEvent) error {
-	return store.appendEvent(ctx, "flow-"+event.FlowID, event, "FlowExecutedEvent")
+// AppendFlowStepSkippedEvent stores the FlowStepSkipped event in the event log.
+func (store *flowEventStore) AppendFlowStepSkippedEvent(ctx context.Context, event FlowStepSkippedEvent) error {
+	return store.appendEvent(ctx, "flow-"+event.FlowID, event, "FlowStepSkippedEvent")
 }
 
-// appendEvent serializes and stores a generic event in EventStoreDB.
-func (store *FlowEventStore) appendEvent(ctx context.Context, streamID string, event interface{}, eventType string) error {
-	// Serialize the event to JSON
-	eventData, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to serialize event: %w", err)
-	}
-
-	// Create a new event
-	esEvent := esdb.EventData{
-		ContentType: esdb.JsonContentType,
-		EventType:   eventType,
-		Data:        eventData,
-	}
+// AppendFlowExecutedEvent stores the FlowExecuted event in the event log.
+func (store *flowEventStore) AppendFlowExecutedEvent(ctx context.Context, event FlowExecutedEvent) error {
+	return store.appendEvent(ctx, "flow-"+event.FlowID, event, "FlowExecutedEvent")
+}
 
-	// Write the event to the stream
-	_, err = store.client.AppendToStream(ctx, streamID, esdb.AppendToStreamOptions{}, esEvent)
+// ReadFlowEvents returns up to limit events of a flow, starting at version from.
+func (store *flowEventStore) ReadFlowEvents(ctx context.Context, flowID string, from, limit uint64) ([]RecordedEvent, error) {
+	events, err := store.log.Read(ctx, "flow-"+flowID, from, limit)
 	if err != nil {
-		return fmt.Errorf("failed to append event to stream: %w", err)
+		return nil, fmt.Errorf("failed to read flow events: %w", err)
 	}
+	return events, nil
+}
 
-	return nil
+// appendEvent serializes and stores a generic event in the event log.
+func (store *flowEventStore) appendEvent(ctx context.Context, streamID string, event interface{}, eventType string) error {
+	return appendEvent(ctx, store.log, streamID, event, eventType)
 }
 
 // FlowCreatedEvent defines the structure of the event when a flow is created.
@@ -87,22 +91,32 @@ type FlowCreatedEvent struct {
 
 // FlowStepCompletedEvent defines the structure of the event when a step in the flow is completed.
 type FlowStepCompletedEvent struct {
-	FlowID     string                 `json:"flow_id"`
-	StepID     string                 `json:"step_id"`
-	StepName   string                 `json:"step_name"`
-	Params     map[string]interface{} `json:"params"`
-	NextStepID string                 `json:"next_step_id"`
-	Timestamp  time.Time              `json:"timestamp"`
+	FlowID      string                 `json:"flow_id"`
+	StepID      string                 `json:"step_id"`
+	StepName    string                 `json:"step_name"`
+	Params      map[string]interface{} `json:"params"`
+	NextStepID  string                 `json:"next_step_id"`
+	Environment string                 `json:"environment,omitempty"`
+	Timestamp   time.Time              `json:"timestamp"`
 }
 
 // StepConfig contains the configuration of a specific step within the flow.
 type StepConfig struct {
 	ID            string                 `json:"id"`
 	Name          string                 `json:"name"`
+	Type          string                 `json:"type,omitempty"`
 	IntegrationID string                 `json:"integration_id"`
+	Integration   string                 `json:"integration,omitempty"`
 	Action        string                 `json:"action"`
 	Params        map[string]interface{} `json:"params"`
+	ParamExprs    map[string]string      `json:"param_expressions,omitempty"`
+	Script        string                 `json:"script,omitempty"`
+	Transforms    map[string]string      `json:"transforms,omitempty"`
+	Condition     string                 `json:"condition,omitempty"`
+	Success       string                 `json:"success,omitempty"`
 	NextStepID    string                 `json:"next_step_id"`
+	Retries       int                    `json:"retries,omitempty"`
+	RetryIf       string                 `json:"retry_if,omitempty"`
 }
 
 // FromFlow converts a Flow entity to a FlowCreatedEvent.
@@ -112,10 +126,19 @@ func FromFlow(f *flow.Flow) FlowCreatedEvent {
 		steps[i] = StepConfig{
 			ID:            step.ID,
 			Name:          step.Name,
+			Type:          step.Type,
 			IntegrationID: step.IntegrationID,
+			Integration:   step.Integration,
 			Action:        step.Action,
 			Params:        step.Params,
+			ParamExprs:    step.ParamExprs,
+			Script:        step.Script,
+			Transforms:    step.Transforms,
+			Condition:     step.Condition,
+			Success:       step.Success,
 			NextStepID:    step.NextStepID,
+			Retries:       step.Retries,
+			RetryIf:       step.RetryIf,
 		}
 	}
 
@@ -144,10 +167,19 @@ func FromUpdatedFlow(f *flow.Flow) FlowUpdatedEvent {
 		steps[i] = StepConfig{
 			ID:            step.ID,
 			Name:          step.Name,
+			Type:          step.Type,
 			IntegrationID: step.IntegrationID,
+			Integration:   step.Integration,
 			Action:        step.Action,
 			Params:        step.Params,
+			ParamExprs:    step.ParamExprs,
+			Script:        step.Script,
+			Transforms:    step.Transforms,
+			Condition:     step.Condition,
+			Success:       step.Success,
 			NextStepID:    step.NextStepID,
+			Retries:       step.Retries,
+			RetryIf:       step.RetryIf,
 		}
 	}
 
@@ -176,12 +208,13 @@ func FromDeletedFlow(f *flow.Flow) FlowDeletedEvent {
 
 // FlowStepFailedEvent defines the structure of the event when a step in the flow fails.
 type FlowStepFailedEvent struct {
-	FlowID    string                 `json:"flow_id"`
-	StepID    string                 `json:"step_id"`
-	StepName  string                 `json:"step_name"`
-	Error     string                 `json:"error"`
-	Params    map[string]interface{} `json:"params"`
-	Timestamp time.Time              `json:"timestamp"`
+	FlowID      string                 `json:"flow_id"`
+	StepID      string                 `json:"step_id"`
+	StepName    string                 `json:"step_name"`
+	Error       string                 `json:"error"`
+	Params      map[string]interface{} `json:"params"`
+	Environment string                 `json:"environment,omitempty"`
+	Timestamp   time.Time              `json:"timestamp"`
 }
 
 // FromFailedStep converts a Flow.Step entity to a FlowStepFailedEvent.
@@ -196,9 +229,30 @@ func FromFailedStep(flowID string, step *flow.Step, err error) FlowStepFailedEve
 	}
 }
 
-// FlowExecutedEvent defines the structure of the event when a flow has been executed successfully.
-type FlowExecutedEvent struct {
+// FlowStepSkippedEvent defines the structure of the event when a step is skipped because its condition is false.
+type FlowStepSkippedEvent struct {
 	FlowID    string    `json:"flow_id"`
-	Name      string    `json:"name"`
+	StepID    string    `json:"step_id"`
+	StepName  string    `json:"step_name"`
+	Condition string    `json:"condition"`
 	Timestamp time.Time `json:"timestamp"`
 }
+
+// FromSkippedStep converts a Flow.Step entity to a FlowStepSkippedEvent.
+func FromSkippedStep(flowID string, step *flow.Step) FlowStepSkippedEvent {
+	return FlowStepSkippedEvent{
+		FlowID:    flowID,
+		StepID:    step.ID,
+		StepName:  step.Name,
+		Condition: step.Condition,
+		Timestamp: time.Now(),
+	}
+}
+
+// FlowExecutedEvent defines the structure of the event when a flow has been executed successfully.
+type FlowExecutedEvent struct {
+	FlowID      string    `json:"flow_id"`
+	Name        string    `json:"name"`
+	Environment string    `json:"environment,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
@@ -2,71 +2,101 @@ package eventstore
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/secret"
 	"time"
-
-	esdb "github.com/EventStore/EventStore-Client-Go/esdb"
 )
 
-// IntegrationEventStore handles storing integration events in EventStoreDB.
-type IntegrationEventStore struct {
-	client *esdb.Client
+// IntegrationEventStore records the events of integrations, one stream per integration.
+type IntegrationEventStore interface {
+	AppendIntegrationCreatedEvent(ctx context.Context, event IntegrationEvent) error
+	AppendIntegrationUpdatedEvent(ctx context.Context, event IntegrationEvent) error
+	AppendIntegrationCredentialsRotatedEvent(ctx context.Context, event IntegrationCredentialsRotatedEvent) error
+}
+
+// integrationEventStore implements IntegrationEventStore interface.
+type integrationEventStore struct {
+	log Log
 }
 
-// NewIntegrationEventStore creates a new instance of IntegrationEventStore.
-func NewIntegrationEventStore(client *esdb.Client) *IntegrationEventStore {
-	return &IntegrationEventStore{
-		client: client,
+// NewIntegrationEventStore creates a new integration event store recording to log.
+func NewIntegrationEventStore(log Log) IntegrationEventStore {
+	return &integrationEventStore{
+		log: log,
 	}
 }
 
-// AppendIntegrationCreatedEvent stores the IntegrationCreated event in EventStore.
-func (store *IntegrationEventStore) AppendIntegrationCreatedEvent(ctx context.Context, event IntegrationEvent) error {
+// AppendIntegrationCreatedEvent stores the IntegrationCreated event in the event log.
+func (store *integrationEventStore) AppendIntegrationCreatedEvent(ctx context.Context, event IntegrationEvent) error {
 	return store.appendEvent(ctx, "integration-"+event.IntegrationID, event, "IntegrationCreatedEvent")
 }
 
-// AppendIntegrationUpdatedEvent stores the IntegrationUpdated event in EventStore.
-func (store *IntegrationEventStore) AppendIntegrationUpdatedEvent(ctx context.Context, event IntegrationEvent) error {
+// AppendIntegrationUpdatedEvent stores the IntegrationUpdated event in the event log.
+func (store *integrationEventStore) AppendIntegrationUpdatedEvent(ctx context.Context, event IntegrationEvent) error {
 	return store.appendEvent(ctx, "integration-"+event.IntegrationID, event, "IntegrationUpdatedEvent")
 }
 
-// appendEvent serializes and stores a generic event in EventStoreDB.
-func (store *IntegrationEventStore) appendEvent(ctx context.Context, streamID string, event interface{}, eventType string) error {
-	// Serialize the event to JSON
-	eventData, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to serialize event: %w", err)
-	}
-
-	// Create a new event
-	esEvent := esdb.EventData{
-		ContentType: esdb.JsonContentType,
-		EventType:   eventType,
-		Data:        eventData,
-	}
-
-	// Write the event to the stream
-	_, err = store.client.AppendToStream(ctx, streamID, esdb.AppendToStreamOptions{}, esEvent)
-	if err != nil {
-		return fmt.Errorf("failed to append event to stream: %w", err)
-	}
+// AppendIntegrationCredentialsRotatedEvent stores the IntegrationCredentialsRotated event in the event log.
+func (store *integrationEventStore) AppendIntegrationCredentialsRotatedEvent(ctx context.Context, event IntegrationCredentialsRotatedEvent) error {
+	return store.appendEvent(ctx, "integration-"+event.IntegrationID, event, "IntegrationCredentialsRotatedEvent")
+}
 
-	return nil
+// appendEvent serializes and stores a generic event in the event log.
+func (store *integrationEventStore) appendEvent(ctx context.Context, streamID string, event interface{}, eventType string) error {
+	return appendEvent(ctx, store.log, streamID, event, eventType)
 }
 
 // IntegrationEvent defines the structure of the event when an integration is created.
 type IntegrationEvent struct {
-	IntegrationID string           `json:"integration_id"`
-	Name          string           `json:"name"`
-	Type          string           `json:"type"`
-	BaseURL       string           `json:"base_url"`
-	AuthType      string           `json:"auth_type"`
-	AuthToken     string           `json:"auth_token"`
-	Currency      string           `json:"currency"`
-	Endpoints     []EndpointConfig `json:"endpoints"`
-	Timestamp     time.Time        `json:"timestamp"`
+	IntegrationID string                       `json:"integration_id"`
+	Name          string                       `json:"name"`
+	Type          string                       `json:"type"`
+	BaseURL       string                       `json:"base_url"`
+	AuthType      string                       `json:"auth_type"`
+	AuthToken     string                       `json:"auth_token,omitempty"` // Secret reference, never a credential value
+	AuthParam     string                       `json:"auth_param,omitempty"`
+	BasicUsername string                       `json:"basic_username,omitempty"`
+	OAuth         *OAuthConfig                 `json:"oauth,omitempty"`
+	HMAC          *HMACConfig                  `json:"hmac,omitempty"`
+	TLS           *TLSConfig                   `json:"tls,omitempty"`
+	Headers       map[string]string            `json:"headers,omitempty"`
+	Environments  map[string]EnvironmentConfig `json:"environments,omitempty"`
+	Currency      string                       `json:"currency"`
+	Endpoints     []EndpointConfig             `json:"endpoints"`
+	Timestamp     time.Time                    `json:"timestamp"`
+}
+
+// IntegrationCredentialsRotatedEvent defines the structure of the event when the
+// credential of an integration is rotated. It never carries credential values.
+type IntegrationCredentialsRotatedEvent struct {
+	IntegrationID       string    `json:"integration_id"`
+	Name                string    `json:"name"`
+	Reference           string    `json:"reference,omitempty"` // Secret reference of the new credential, if any
+	ActiveFrom          time.Time `json:"active_from"`
+	PreviousActiveUntil time.Time `json:"previous_active_until"`
+	Timestamp           time.Time `json:"timestamp"`
+}
+
+// OAuthConfig contains the OAuth2 client configuration of an integration, without its secret.
+type OAuthConfig struct {
+	ClientID string   `json:"client_id"`
+	TokenURL string   `json:"token_url"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+// HMACConfig contains the request signing settings of an integration, without its secret.
+type HMACConfig struct {
+	KeyID     string `json:"key_id,omitempty"`
+	Algorithm string `json:"algorithm,omitempty"`
+	Header    string `json:"header,omitempty"`
+}
+
+// TLSConfig contains the transport security settings of an integration.
+type TLSConfig struct {
+	CertFile   string   `json:"cert_file,omitempty"`
+	CAFiles    []string `json:"ca_files,omitempty"`
+	MinVersion string   `json:"min_version,omitempty"`
+	ServerName string   `json:"server_name,omitempty"`
 }
 
 // EndpointConfig contains the configuration of a specific endpoint within the integration.
@@ -77,6 +107,18 @@ type EndpointConfig struct {
 	Params map[string]string `json:"params"`
 }
 
+// EnvironmentConfig contains the settings an integration overrides in an
+// environment, without its secrets.
+type EnvironmentConfig struct {
+	BaseURL       string            `json:"base_url,omitempty"`
+	AuthToken     string            `json:"auth_token,omitempty"` // Secret reference, never a credential value
+	BasicUsername string            `json:"basic_username,omitempty"`
+	OAuth         *OAuthConfig      `json:"oauth,omitempty"`
+	HMAC          *HMACConfig       `json:"hmac,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	TLS           *TLSConfig        `json:"tls,omitempty"`
+}
+
 // FromIntegration converts an Integration entity to an IntegrationEvent.
 func FromIntegration(integration *integration.Integration) IntegrationEvent {
 	endpoints := make([]EndpointConfig, len(integration.Endpoints))
@@ -89,15 +131,86 @@ func FromIntegration(integration *integration.Integration) IntegrationEvent {
 		}
 	}
 
+	var environments map[string]EnvironmentConfig
+	if len(integration.Environments) > 0 {
+		environments = make(map[string]EnvironmentConfig, len(integration.Environments))
+		for name, env := range integration.Environments {
+			if env == nil {
+				continue
+			}
+			environments[name] = EnvironmentConfig{
+				BaseURL:       env.BaseURL,
+				AuthToken:     secret.Redact(env.AuthToken),
+				BasicUsername: basicUsername(env.Basic),
+				OAuth:         oauthConfig(env.OAuth),
+				HMAC:          hmacConfig(env.HMAC),
+				Headers:       env.Headers,
+				TLS:           tlsConfig(env.TLS),
+			}
+		}
+	}
+
 	return IntegrationEvent{
-		IntegrationID: integration.Name, // If you have a specific ID field, use it here
+		IntegrationID: integration.ID,
 		Name:          integration.Name,
 		Type:          integration.Type,
 		BaseURL:       integration.BaseURL,
 		AuthType:      integration.AuthType,
-		AuthToken:     integration.AuthToken,
+		AuthToken:     secret.Redact(integration.AuthToken),
+		AuthParam:     integration.AuthParam,
+		BasicUsername: basicUsername(integration.Basic),
+		OAuth:         oauthConfig(integration.OAuth),
+		HMAC:          hmacConfig(integration.HMAC),
+		TLS:           tlsConfig(integration.TLS),
+		Headers:       integration.Headers,
+		Environments:  environments,
 		Currency:      integration.Currency,
 		Endpoints:     endpoints,
 		Timestamp:     time.Now(),
 	}
 }
+
+// basicUsername returns the username of the basic credentials, if any.
+func basicUsername(basic *integration.Basic) string {
+	if basic == nil {
+		return ""
+	}
+	return basic.Username
+}
+
+// oauthConfig maps OAuth2 client credentials to their event form, without the secret.
+func oauthConfig(oauth *integration.OAuth) *OAuthConfig {
+	if oauth == nil {
+		return nil
+	}
+	return &OAuthConfig{
+		ClientID: oauth.ClientID,
+		TokenURL: oauth.TokenURL,
+		Scopes:   oauth.Scopes,
+	}
+}
+
+// hmacConfig maps request signing settings to their event form, without the secret.
+func hmacConfig(hmac *integration.HMAC) *HMACConfig {
+	if hmac == nil {
+		return nil
+	}
+	return &HMACConfig{
+		KeyID:     hmac.KeyID,
+		Algorithm: hmac.Algorithm,
+		Header:    hmac.Header,
+	}
+}
+
+// tlsConfig maps transport security settings to their event form.
+func tlsConfig(tls *integration.TLS) *TLSConfig {
+	if tls == nil {
+		return nil
+	}
+	return &TLSConfig{
+		CertFile:   tls.CertFile,
+		CAFiles:    tls.CAFiles,
+		MinVersion: tls.MinVersion,
+		ServerName: tls.ServerName,
+	}
+}
@@ -0,0 +1,222 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/infra/db"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// logs create the event logs checked against the Log contract, empty.
+// Postgres is only checked when POSTGRES_TEST_URL names a database, whose
+// events are deleted; EventStoreDB needs a server and is left out.
+var logs = []struct {
+	name string
+	open func(t *testing.T) Log
+}{
+	{
+		name: "memory",
+		open: func(t *testing.T) Log {
+			return NewMemoryLog()
+		},
+	},
+	{
+		name: "bolt",
+		open: func(t *testing.T) Log {
+			db, err := bbolt.Open(filepath.Join(t.TempDir(), "events.db"), 0o600, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Close() })
+			return NewBoltLog(db)
+		},
+	},
+	{
+		name: "postgres",
+		open: func(t *testing.T) Log {
+			url := os.Getenv("POSTGRES_TEST_URL")
+			if url == "" {
+				t.Skip("POSTGRES_TEST_URL is not set")
+			}
+			pg, err := db.NewPostgresFromURL(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(pg.Close)
+			// Events are append-only: only TRUNCATE removes them.
+			if _, err := pg.Pool.Exec(context.Background(), "TRUNCATE events"); err != nil {
+				t.Fatal(err)
+			}
+			return NewPostgresLog(pg.Pool)
+		},
+	},
+}
+
+// events returns n events of type t, numbered from first.
+func events(t string, first, n int) []EventData {
+	data := make([]EventData, n)
+	for i := range data {
+		data[i] = EventData{Type: t, Data: json.RawMessage(fmt.Sprintf(`{"n":%d}`, first+i))}
+	}
+	return data
+}
+
+func TestLogAppendRead(t *testing.T) {
+	ctx := context.Background()
+	for _, l := range logs {
+		t.Run(l.name, func(t *testing.T) {
+			log := l.open(t)
+
+			before := time.Now().UTC().Add(-time.Second)
+			if err := log.Append(ctx, "flow-1", NoStream, events("FlowCreatedEvent", 0, 1)...); err != nil {
+				t.Fatal(err)
+			}
+			if err := log.Append(ctx, "flow-1", 0, events("FlowUpdatedEvent", 1, 3)...); err != nil {
+				t.Fatal(err)
+			}
+			if err := log.Append(ctx, "flow-2", AnyVersion, events("FlowCreatedEvent", 100, 1)...); err != nil {
+				t.Fatal(err)
+			}
+
+			tests := []struct {
+				name     string
+				stream   string
+				from     uint64
+				count    uint64
+				versions []uint64
+			}{
+				{name: "whole stream", stream: "flow-1", from: 0, count: 100, versions: []uint64{0, 1, 2, 3}},
+				{name: "from a version", stream: "flow-1", from: 2, count: 100, versions: []uint64{2, 3}},
+				{name: "count", stream: "flow-1", from: 1, count: 2, versions: []uint64{1, 2}},
+				{name: "past the end", stream: "flow-1", from: 4, count: 100},
+				{name: "other stream", stream: "flow-2", from: 0, count: 100, versions: []uint64{0}},
+				{name: "missing stream", stream: "flow-3", from: 0, count: 100},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := log.Read(ctx, tt.stream, tt.from, tt.count)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(got) != len(tt.versions) {
+						t.Fatalf("Read() returned %d events, want %d", len(got), len(tt.versions))
+					}
+					for n, e := range got {
+						if e.Version != tt.versions[n] {
+							t.Errorf("event %d version = %d, want %d", n, e.Version, tt.versions[n])
+						}
+						if e.CreatedAt.Before(before) {
+							t.Errorf("event %d CreatedAt = %v, want the time it was appended", n, e.CreatedAt)
+						}
+					}
+				})
+			}
+
+			got, err := log.Read(ctx, "flow-1", 0, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := append(events("FlowCreatedEvent", 0, 1), events("FlowUpdatedEvent", 1, 3)...)
+			for n, e := range got {
+				if e.Type != want[n].Type || string(e.Data) != string(want[n].Data) {
+					t.Errorf("event %d = %s %s, want %s %s", n, e.Type, e.Data, want[n].Type, want[n].Data)
+				}
+			}
+		})
+	}
+}
+
+func TestLogExpectedVersion(t *testing.T) {
+	ctx := context.Background()
+	for _, l := range logs {
+		t.Run(l.name, func(t *testing.T) {
+			tests := []struct {
+				name     string
+				existing int // Events in the stream before the append
+				expected ExpectedVersion
+				wantErr  bool
+			}{
+				{name: "no stream", existing: 0, expected: NoStream},
+				{name: "no stream when it exists", existing: 2, expected: NoStream, wantErr: true},
+				{name: "last version", existing: 2, expected: 1},
+				{name: "stale version", existing: 2, expected: 0, wantErr: true},
+				{name: "future version", existing: 2, expected: 5, wantErr: true},
+				{name: "version of a missing stream", existing: 0, expected: 0, wantErr: true},
+				{name: "any version", existing: 2, expected: AnyVersion},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					log := l.open(t)
+					if tt.existing > 0 {
+						if err := log.Append(ctx, "stream", AnyVersion, events("Event", 0, tt.existing)...); err != nil {
+							t.Fatal(err)
+						}
+					}
+
+					err := log.Append(ctx, "stream", tt.expected, events("Event", tt.existing, 2)...)
+					if tt.wantErr != errors.Is(err, ErrWrongVersion) {
+						t.Fatalf("Append() error = %v, want ErrWrongVersion: %v", err, tt.wantErr)
+					}
+
+					// A rejected append writes none of its events.
+					want := tt.existing + 2
+					if tt.wantErr {
+						want = tt.existing
+					}
+					got, err := log.Read(ctx, "stream", 0, 100)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(got) != want {
+						t.Errorf("stream has %d events, want %d", len(got), want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestLogConcurrentAppends(t *testing.T) {
+	ctx := context.Background()
+	for _, l := range logs {
+		t.Run(l.name, func(t *testing.T) {
+			log := l.open(t)
+
+			// Writers racing from the same version: exactly one succeeds.
+			const writers = 8
+			var wg sync.WaitGroup
+			results := make(chan error, writers)
+			for n := 0; n < writers; n++ {
+				wg.Add(1)
+				go func(n int) {
+					defer wg.Done()
+					results <- log.Append(ctx, "stream", NoStream, events("Event", n, 1)...)
+				}(n)
+			}
+			wg.Wait()
+			close(results)
+
+			succeeded := 0
+			for err := range results {
+				switch {
+				case err == nil:
+					succeeded++
+				case !errors.Is(err, ErrWrongVersion):
+					t.Errorf("Append() error = %v, want nil or ErrWrongVersion", err)
+				}
+			}
+			if succeeded != 1 {
+				t.Errorf("%d appends succeeded, want 1", succeeded)
+			}
+		})
+	}
+}
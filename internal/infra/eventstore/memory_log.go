@@ -0,0 +1,70 @@
+package eventstore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryLog implements Log in memory. Events are lost when the process stops,
+// so it suits development and tests.
+type memoryLog struct {
+	mu      sync.RWMutex
+	streams map[string][]RecordedEvent // Events of each stream, in order
+}
+
+// NewMemoryLog creates a new event log kept in memory.
+func NewMemoryLog() Log {
+	return &memoryLog{
+		streams: map[string][]RecordedEvent{},
+	}
+}
+
+// Append adds events to the end of the stream.
+func (l *memoryLog) Append(ctx context.Context, streamID string, expected ExpectedVersion, events ...EventData) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now().UTC()
+	stream := l.streams[streamID]
+	if err := expected.check(uint64(len(stream))); err != nil {
+		return err
+	}
+	for _, e := range events {
+		stream = append(stream, RecordedEvent{
+			Version:   uint64(len(stream)),
+			Type:      e.Type,
+			Data:      append([]byte(nil), e.Data...),
+			CreatedAt: now,
+		})
+	}
+	l.streams[streamID] = stream
+
+	return nil
+}
+
+// Read returns a copy of the events of the stream.
+func (l *memoryLog) Read(ctx context.Context, streamID string, from, count uint64) ([]RecordedEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	stream := l.streams[streamID]
+	if from >= uint64(len(stream)) {
+		return nil, nil
+	}
+	end := uint64(len(stream))
+	if count < end-from {
+		end = from + count
+	}
+
+	// Recorded events are never modified, so their data can be shared.
+	return append([]RecordedEvent(nil), stream[from:end]...), nil
+}
@@ -0,0 +1,92 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// uniqueViolation is the PostgreSQL error code of a unique constraint
+// violation.
+const uniqueViolation = "23505"
+
+// postgresLog implements Log with the append-only events table of
+// PostgreSQL, created by the migrations of db.NewPostgres. Each event is a row
+// of its stream, unique by stream and version.
+type postgresLog struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresLog creates a new event log stored in PostgreSQL.
+func NewPostgresLog(pool *pgxpool.Pool) Log {
+	return &postgresLog{
+		pool: pool,
+	}
+}
+
+// Append inserts events at the end of the stream in a single transaction.
+// Appends to a stream are serialized by a transaction-level advisory lock, so
+// concurrent appends that do not expect a version never conflict.
+func (l *postgresLog) Append(ctx context.Context, streamID string, expected ExpectedVersion, events ...EventData) error {
+	err := pgx.BeginFunc(ctx, l.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1, 0))", streamID); err != nil {
+			return err
+		}
+
+		var length uint64
+		if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM events WHERE stream_id = $1", streamID).Scan(&length); err != nil {
+			return err
+		}
+		if err := expected.check(length); err != nil {
+			return err
+		}
+
+		batch := &pgx.Batch{}
+		for n, e := range events {
+			batch.Queue("INSERT INTO events (stream_id, version, type, data) VALUES ($1, $2, $3, $4)",
+				streamID, length+uint64(n), e.Type, []byte(e.Data))
+		}
+		return tx.SendBatch(ctx, batch).Close()
+	})
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: %v", ErrWrongVersion, err)
+	}
+	return err
+}
+
+// Read returns the events of the stream stored in PostgreSQL.
+func (l *postgresLog) Read(ctx context.Context, streamID string, from, count uint64) ([]RecordedEvent, error) {
+	// Versions and limits are BIGINT: no event is past the largest one.
+	if from > math.MaxInt64 {
+		return nil, nil
+	}
+	count = min(count, math.MaxInt64)
+
+	rows, err := l.pool.Query(ctx, `
+SELECT version, type, data, created_at FROM events
+WHERE stream_id = $1 AND version >= $2
+ORDER BY version
+LIMIT $3`, streamID, from, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []RecordedEvent
+	for rows.Next() {
+		var e RecordedEvent
+		if err := rows.Scan(&e.Version, &e.Type, &e.Data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,393 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/extender"
+	"generic-integration-platform/internal/infra/secrets"
+	"log"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	healthCheckTimeout         = 5 * time.Second
+	shutdownTimeout            = 5 * time.Second
+	minRestartDelay            = 500 * time.Millisecond
+	maxRestartDelay            = 30 * time.Second
+	// stableRunDuration resets the restart backoff once a process has been
+	// running without crashing for that long.
+	stableRunDuration = time.Minute
+)
+
+// ErrNotRunning is returned when the plugin process is not available, for
+// instance while it is being restarted after a crash.
+var ErrNotRunning = errors.New("plugin is not running")
+
+// errClosed is returned when a process is started after the client was closed.
+var errClosed = errors.New("plugin client is closed")
+
+// process is a running plugin executable and the RPC connection to it.
+type process struct {
+	cmd       *exec.Cmd
+	rpc       *rpc.Client
+	startedAt time.Time
+	exited    chan struct{}
+
+	integration *integration.Integration // The integration sent to the process, with its secrets resolved
+}
+
+// Client implements extender.IntegrationExtender by forwarding every call to a
+// plugin executable. It spawns the process on Initialize, checks its health
+// periodically and restarts it when it crashes, until Close is called.
+type Client struct {
+	config  config.PluginConfig
+	secrets *secrets.Resolver
+
+	mu          sync.Mutex
+	current     *process
+	integration *integration.Integration // The integration, with its secret references
+	closing     bool
+	done        chan struct{}
+	supervised  chan struct{} // Closed when the supervisor returns, nil until it runs
+}
+
+// NewClient creates a new plugin client for the given plugin configuration.
+// Plugins receive their integration with the secret references resolved by resolver.
+func NewClient(cfg config.PluginConfig, resolver *secrets.Resolver) *Client {
+	if cfg.HealthCheckInterval <= 0 {
+		cfg.HealthCheckInterval = defaultHealthCheckInterval
+	}
+
+	return &Client{
+		config:  cfg,
+		secrets: resolver,
+		done:    make(chan struct{}),
+	}
+}
+
+// Factory returns an extender.Factory that spawns a dedicated plugin process
+// for every integration.
+func Factory(cfg config.PluginConfig) extender.Factory {
+	return func(resolver *secrets.Resolver) extender.IntegrationExtender {
+		return NewClient(cfg, resolver)
+	}
+}
+
+// Initialize spawns the plugin process and sends it the integration
+// configuration. Plugins run out of process, so the secret references are
+// resolved before sending it, again on every restart, and on every health
+// check so that rotated credentials reach a running process.
+func (c *Client) Initialize(ctx context.Context, config *integration.Integration) error {
+	c.mu.Lock()
+	c.integration = config
+	c.mu.Unlock()
+
+	if err := c.start(ctx); err != nil {
+		return err
+	}
+
+	supervised := make(chan struct{})
+	c.mu.Lock()
+	c.supervised = supervised
+	c.mu.Unlock()
+
+	go func() {
+		defer close(supervised)
+		c.supervise()
+	}()
+	go c.healthCheck()
+
+	return nil
+}
+
+// Execute performs the action in the plugin process.
+func (c *Client) Execute(ctx context.Context, action string, params map[string]interface{}) (interface{}, error) {
+	var reply ExecuteReply
+	if err := c.call(ctx, "Execute", ExecuteArgs{Action: action, Params: params}, &reply); err != nil {
+		return nil, err
+	}
+
+	return reply.Result, nil
+}
+
+// Validate asks the plugin to validate the integration configuration.
+func (c *Client) Validate(ctx context.Context) error {
+	return c.call(ctx, "Validate", Empty{}, &Empty{})
+}
+
+// Close asks the plugin to release its resources and stops the process.
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closing {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closing = true
+	close(c.done)
+	supervised := c.supervised
+	c.mu.Unlock()
+
+	// Wait for a restart in progress, so that its process is stopped too.
+	if supervised != nil {
+		<-supervised
+	}
+
+	c.mu.Lock()
+	p := c.current
+	c.mu.Unlock()
+
+	if p == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancel()
+
+	err := c.call(ctx, "Close", Empty{}, &Empty{})
+	_ = p.rpc.Close()
+
+	// Give the process a chance to exit on its own before killing it.
+	select {
+	case <-p.exited:
+	case <-ctx.Done():
+		_ = p.cmd.Process.Kill()
+		<-p.exited
+	}
+
+	return err
+}
+
+// start spawns the plugin process and initializes it with the integration,
+// its secret references resolved.
+func (c *Client) start(ctx context.Context) error {
+	c.mu.Lock()
+	config := c.integration
+	c.mu.Unlock()
+
+	resolved, err := c.secrets.ResolveIntegration(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(c.config.Path, c.config.Args...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open plugin stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin %s: %w", c.config.Path, err)
+	}
+
+	p := &process{
+		cmd:         cmd,
+		rpc:         rpc.NewClientWithCodec(jsonrpc.NewClientCodec(stdio{Reader: stdout, WriteCloser: stdin})),
+		startedAt:   time.Now(),
+		exited:      make(chan struct{}),
+		integration: resolved,
+	}
+
+	go func() {
+		_ = cmd.Wait()
+		close(p.exited)
+	}()
+
+	// Calls only reach the process once it is initialized.
+	if err := p.call(ctx, "Initialize", InitializeArgs{Integration: resolved}, &Empty{}); err != nil {
+		_ = cmd.Process.Kill()
+		<-p.exited
+		return fmt.Errorf("failed to initialize plugin %s: %w", c.config.Type, err)
+	}
+
+	// Close may have run while the process was starting: nothing would stop it.
+	c.mu.Lock()
+	if c.closing {
+		c.mu.Unlock()
+		_ = cmd.Process.Kill()
+		<-p.exited
+		return errClosed
+	}
+	c.current = p
+	c.mu.Unlock()
+
+	return nil
+}
+
+// supervise restarts the plugin process every time it exits, backing off when
+// it keeps crashing, until the client is closed.
+func (c *Client) supervise() {
+	delay := minRestartDelay
+
+	// Restarts are cancelled by Close, which waits for them.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		c.mu.Lock()
+		p := c.current
+		c.mu.Unlock()
+
+		select {
+		case <-c.done:
+			return
+		case <-p.exited:
+		}
+
+		c.mu.Lock()
+		if c.closing {
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Unlock()
+
+		log.Printf("Plugin %s exited unexpectedly: %v", c.config.Type, p.cmd.ProcessState)
+
+		if time.Since(p.startedAt) >= stableRunDuration {
+			delay = minRestartDelay
+		}
+
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-time.After(delay):
+			}
+
+			delay *= 2
+			if delay > maxRestartDelay {
+				delay = maxRestartDelay
+			}
+
+			err := c.start(ctx)
+			if err == nil {
+				log.Printf("Plugin %s restarted", c.config.Type)
+				break
+			}
+			if errors.Is(err, errClosed) || ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to restart plugin %s: %v", c.config.Type, err)
+		}
+	}
+}
+
+// healthCheck pings the plugin periodically and kills it when it stops
+// answering so that the supervisor restarts it.
+func (c *Client) healthCheck() {
+	ticker := time.NewTicker(c.config.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		err := c.call(ctx, "Ping", Empty{}, &Empty{})
+		cancel()
+
+		if errors.Is(err, ErrNotRunning) {
+			continue
+		}
+		if err == nil {
+			if err := c.refresh(); err != nil {
+				log.Printf("Failed to refresh the credentials of plugin %s: %v", c.config.Type, err)
+			}
+			continue
+		}
+
+		log.Printf("Plugin %s failed its health check: %v", c.config.Type, err)
+
+		c.mu.Lock()
+		p := c.current
+		c.mu.Unlock()
+		_ = p.cmd.Process.Kill()
+	}
+}
+
+// refresh resolves the secret references of the integration again and
+// initializes the running process again when their values changed, e.g.
+// after a credential rotation.
+func (c *Client) refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	c.mu.Lock()
+	p, config := c.current, c.integration
+	sent := p.integration
+	c.mu.Unlock()
+
+	resolved, err := c.secrets.ResolveIntegration(ctx, config)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(resolved, sent) {
+		return nil
+	}
+
+	if err := c.call(ctx, "Initialize", InitializeArgs{Integration: resolved}, &Empty{}); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	p.integration = resolved
+	c.mu.Unlock()
+	return nil
+}
+
+// call invokes an RPC method on the running process, honoring ctx.
+func (c *Client) call(ctx context.Context, method string, args, reply interface{}) error {
+	c.mu.Lock()
+	p := c.current
+	c.mu.Unlock()
+
+	if p == nil {
+		return ErrNotRunning
+	}
+	return p.call(ctx, method, args, reply)
+}
+
+// call invokes an RPC method on the process, honoring ctx.
+func (p *process) call(ctx context.Context, method string, args, reply interface{}) error {
+	select {
+	case <-p.exited:
+		return ErrNotRunning
+	default:
+	}
+
+	call := p.rpc.Go(serviceName+"."+method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		if errors.Is(call.Error, rpc.ErrShutdown) {
+			return ErrNotRunning
+		}
+		return call.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,169 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/secrets"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakePlugin is the path of the plugin built from testdata/fakeplugin.
+var fakePlugin string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "fakeplugin")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fakePlugin = filepath.Join(dir, "fakeplugin")
+	build := exec.Command("go", "build", "-o", fakePlugin, "./testdata/fakeplugin")
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to build the fake plugin:", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestClient returns a client of the fake plugin, which creates the
+// returned file when it is closed, resolving the variables prefixed with BANK_.
+func newTestClient(t *testing.T) (*Client, string) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Secrets.EnvPrefixes = []string{"BANK_"}
+	closed := filepath.Join(t.TempDir(), "closed")
+
+	c := NewClient(config.PluginConfig{Type: "fake", Path: fakePlugin, Args: []string{"-closed", closed}}, secrets.NewResolver(cfg, nil))
+	t.Cleanup(func() { _ = c.Close(context.Background()) })
+	return c, closed
+}
+
+// testIntegration returns an integration whose token references BANK_TOKEN.
+func testIntegration() *integration.Integration {
+	return &integration.Integration{Name: "bank", Type: "fake", BaseURL: "https://api.bank.com", AuthType: integration.AuthTypeToken, AuthToken: "env://BANK_TOKEN"}
+}
+
+func TestClientInitialize(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		baseURL string
+		wantErr bool
+	}{
+		{name: "started", baseURL: "https://api.bank.com"},
+		{name: "missing executable", path: "/nonexistent/plugin", baseURL: "https://api.bank.com", wantErr: true},
+		{name: "rejected integration", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BANK_TOKEN", "s3cr3t")
+			ctx := context.Background()
+			c, _ := newTestClient(t)
+			if tt.path != "" {
+				c.config.Path = tt.path
+			}
+			i := testIntegration()
+			i.BaseURL = tt.baseURL
+
+			err := c.Initialize(ctx, i)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Initialize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// The plugin receives the integration with its secrets resolved.
+			token, err := c.Execute(ctx, "token", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != "s3cr3t" {
+				t.Errorf("Execute(token) = %v, want the resolved token", token)
+			}
+		})
+	}
+}
+
+func TestClientRestart(t *testing.T) {
+	t.Setenv("BANK_TOKEN", "s3cr3t")
+	ctx := context.Background()
+	c, _ := newTestClient(t)
+	if err := c.Initialize(ctx, testIntegration()); err != nil {
+		t.Fatal(err)
+	}
+
+	crashed, err := c.Execute(ctx, "pid", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Execute(ctx, "crash", nil); err == nil {
+		t.Fatal("Execute(crash) succeeded, want an error")
+	}
+
+	// The supervisor restarts the process after minRestartDelay.
+	deadline := time.Now().Add(10 * minRestartDelay)
+	for {
+		pid, err := c.Execute(ctx, "pid", nil)
+		if err == nil {
+			if pid == crashed {
+				t.Fatalf("Execute(pid) = %v after a crash, want a new process", pid)
+			}
+			break
+		}
+		if !errors.Is(err, ErrNotRunning) {
+			t.Fatalf("Execute(pid) error = %v while restarting, want %v", err, ErrNotRunning)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the plugin was not restarted after it crashed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if token, err := c.Execute(ctx, "token", nil); err != nil || token != "s3cr3t" {
+		t.Errorf("Execute(token) after a restart = %v, %v, want the resolved token", token, err)
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	t.Setenv("BANK_TOKEN", "s3cr3t")
+	ctx := context.Background()
+	c, closed := newTestClient(t)
+	if err := c.Initialize(ctx, testIntegration()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(closed); err != nil {
+		t.Errorf("the plugin was not closed: %v", err)
+	}
+	select {
+	case <-c.current.exited:
+	default:
+		t.Error("the plugin process is still running after Close()")
+	}
+
+	if _, err := c.Execute(ctx, "pid", nil); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Execute() after Close() error = %v, want %v", err, ErrNotRunning)
+	}
+	if err := c.Close(ctx); err != nil {
+		t.Errorf("second Close() error = %v, want nil", err)
+	}
+}
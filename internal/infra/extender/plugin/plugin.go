@@ -0,0 +1,20 @@
+package plugin
+
+import (
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/extender"
+
+	"go.uber.org/fx"
+)
+
+// RegisterPlugins registers an extender for every plugin declared in the configuration.
+func RegisterPlugins(cfg *config.Config, registry *extender.Registry) {
+	for _, p := range cfg.Plugins {
+		registry.Register(p.Type, Factory(p))
+	}
+}
+
+// Module registers the configured plugins for Uber Fx.
+var Module = fx.Options(
+	fx.Invoke(RegisterPlugins),
+)
@@ -0,0 +1,42 @@
+package plugin
+
+import (
+	"generic-integration-platform/internal/domain/integration"
+	"io"
+)
+
+// serviceName is the RPC service every plugin exposes. The wire protocol is
+// JSON-RPC 1.0 over the plugin's stdin and stdout, so plugins can be written in
+// any language as long as they answer the methods below.
+const serviceName = "Plugin"
+
+// InitializeArgs carries the configuration of the integration handled by the plugin.
+type InitializeArgs struct {
+	Integration *integration.Integration `json:"integration"`
+}
+
+// ExecuteArgs carries the action to perform and its parameters.
+type ExecuteArgs struct {
+	Action string                 `json:"action"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// ExecuteReply carries the result of an executed action.
+type ExecuteReply struct {
+	Result interface{} `json:"result"`
+}
+
+// Empty is used by the methods that take or return no data.
+type Empty struct{}
+
+// stdio joins the two pipes of a process into a single connection.
+type stdio struct {
+	io.Reader
+	io.WriteCloser
+}
+
+// Close closes the write side of the connection; the read side is closed by
+// the process exiting.
+func (s stdio) Close() error {
+	return s.WriteCloser.Close()
+}
@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"context"
+	"generic-integration-platform/internal/infra/extender"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+)
+
+// server adapts an IntegrationExtender to the RPC methods of the plugin protocol.
+type server struct {
+	impl extender.IntegrationExtender
+}
+
+// Initialize forwards the integration configuration to the implementation.
+func (s *server) Initialize(args InitializeArgs, _ *Empty) error {
+	return s.impl.Initialize(context.Background(), args.Integration)
+}
+
+// Execute forwards the action to the implementation.
+func (s *server) Execute(args ExecuteArgs, reply *ExecuteReply) error {
+	result, err := s.impl.Execute(context.Background(), args.Action, args.Params)
+	if err != nil {
+		return err
+	}
+	reply.Result = result
+	return nil
+}
+
+// Validate forwards the validation to the implementation.
+func (s *server) Validate(_ Empty, _ *Empty) error {
+	return s.impl.Validate(context.Background())
+}
+
+// Close forwards the shutdown to the implementation.
+func (s *server) Close(_ Empty, _ *Empty) error {
+	return s.impl.Close(context.Background())
+}
+
+// Ping answers the health checks sent by the platform.
+func (s *server) Ping(_ Empty, _ *Empty) error {
+	return nil
+}
+
+// Serve exposes impl over stdin and stdout and blocks until the platform closes
+// the connection. It is meant to be called from the main function of a plugin.
+// Plugins must not write anything else to stdout; logs belong on stderr.
+func Serve(impl extender.IntegrationExtender) error {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(serviceName, &server{impl: impl}); err != nil {
+		return err
+	}
+
+	srv.ServeCodec(jsonrpc.NewServerCodec(stdio{Reader: os.Stdin, WriteCloser: os.Stdout}))
+	return nil
+}
@@ -0,0 +1,56 @@
+// Command fakeplugin is the plugin run by the tests of the plugin client. Its
+// actions report the process and the integration it received, or crash it.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/extender/plugin"
+	"log"
+	"os"
+)
+
+// fake answers the actions of the tests.
+type fake struct {
+	closed      string // File created when the plugin is closed
+	integration *integration.Integration
+}
+
+func (f *fake) Initialize(ctx context.Context, config *integration.Integration) error {
+	if config.BaseURL == "" {
+		return fmt.Errorf("base URL is required")
+	}
+	f.integration = config
+	return nil
+}
+
+func (f *fake) Execute(ctx context.Context, action string, params map[string]interface{}) (interface{}, error) {
+	switch action {
+	case "pid":
+		return os.Getpid(), nil
+	case "token":
+		return f.integration.AuthToken, nil
+	case "crash":
+		os.Exit(3)
+	}
+	return nil, fmt.Errorf("unknown action %s", action)
+}
+
+func (f *fake) Validate(ctx context.Context) error {
+	return nil
+}
+
+func (f *fake) Close(ctx context.Context) error {
+	return os.WriteFile(f.closed, nil, 0o600)
+}
+
+func main() {
+	closed := flag.String("closed", os.DevNull, "file created when the plugin is closed")
+	flag.Parse()
+
+	if err := plugin.Serve(&fake{closed: *closed}); err != nil {
+		log.Fatal(err)
+	}
+}
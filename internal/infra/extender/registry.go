@@ -0,0 +1,241 @@
+package extender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/secrets"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/fx"
+)
+
+// ErrUnsupportedType is returned when no extender is registered for an integration type.
+var ErrUnsupportedType = errors.New("unsupported integration type")
+
+// Factory builds a new, uninitialized IntegrationExtender. The extender
+// resolves the secret references of its integration with secrets.
+type Factory func(secrets *secrets.Resolver) IntegrationExtender
+
+// Registry keeps the extender factories keyed by integration type and the
+// extenders already initialized for each integration.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+	active    map[string]IntegrationExtender
+	secrets   *secrets.Resolver
+}
+
+// NewRegistry creates an empty Registry whose extenders resolve secrets with resolver.
+func NewRegistry(resolver *secrets.Resolver) *Registry {
+	return &Registry{
+		factories: make(map[string]Factory),
+		active:    make(map[string]IntegrationExtender),
+		secrets:   resolver,
+	}
+}
+
+// Register adds a factory for the given integration type, replacing any previous one.
+func (r *Registry) Register(integrationType string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.factories[normalizeType(integrationType)] = factory
+}
+
+// Types returns the registered integration types sorted alphabetically.
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]string, 0, len(r.factories))
+	for t := range r.factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// Supports reports whether an extender is registered for integrationType.
+func (r *Registry) Supports(integrationType string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.factories[normalizeType(integrationType)]
+	return ok
+}
+
+// Build builds, initializes and validates a new extender for the integration
+// without using it for the integration yet. The caller either activates it
+// with Activate or closes it. Integrations reading variables or files outside
+// the allowlist of the resolver are rejected.
+func (r *Registry) Build(ctx context.Context, i *integration.Integration) (IntegrationExtender, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[normalizeType(i.Type)]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, i.Type)
+	}
+
+	// Checked before initializing, which reads the TLS files.
+	if err := r.secrets.Check(i); err != nil {
+		return nil, fmt.Errorf("invalid integration %s: %w", i.Name, err)
+	}
+
+	ext := factory(r.secrets)
+	if err := ext.Initialize(ctx, i); err != nil {
+		return nil, fmt.Errorf("failed to initialize integration %s: %w", i.Name, err)
+	}
+
+	if err := ext.Validate(ctx); err != nil {
+		_ = ext.Close(ctx)
+		return nil, fmt.Errorf("invalid integration %s: %w", i.Name, err)
+	}
+
+	return ext, nil
+}
+
+// Activate makes ext the extender of the integration, closing the one
+// previously set up, if any. Extenders set up for the integration in other
+// environments are closed as well, as they may use outdated settings; they
+// are set up again on their next use.
+func (r *Registry) Activate(ctx context.Context, i *integration.Integration, ext IntegrationExtender) {
+	r.mu.Lock()
+	previous := r.take(i)
+	r.active[key(i)] = ext
+	r.mu.Unlock()
+
+	for _, p := range previous {
+		if p != ext {
+			_ = p.Close(ctx)
+		}
+	}
+}
+
+// Get returns the extender set up for the integration, setting it up on first
+// use. Unlike Activate, it leaves the extenders of other environments in place.
+func (r *Registry) Get(ctx context.Context, i *integration.Integration) (IntegrationExtender, error) {
+	r.mu.RLock()
+	ext, ok := r.active[key(i)]
+	r.mu.RUnlock()
+	if ok {
+		return ext, nil
+	}
+
+	ext, err := r.Build(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	existing, ok := r.active[key(i)]
+	if !ok {
+		r.active[key(i)] = ext
+	}
+	r.mu.Unlock()
+
+	// Another caller set it up first.
+	if ok {
+		_ = ext.Close(ctx)
+		return existing, nil
+	}
+	return ext, nil
+}
+
+// Remove closes and forgets the extenders set up for the integration, in
+// every environment, if any.
+func (r *Registry) Remove(ctx context.Context, i *integration.Integration) error {
+	r.mu.Lock()
+	previous := r.take(i)
+	r.mu.Unlock()
+
+	var errs []error
+	for _, ext := range previous {
+		if err := ext.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// take removes the extenders of the integration, in every environment, from
+// the active ones and returns them. The caller must hold the lock.
+func (r *Registry) take(i *integration.Integration) []IntegrationExtender {
+	var taken []IntegrationExtender
+	for k, ext := range r.active {
+		if k == baseKey(i) || strings.HasPrefix(k, baseKey(i)+environmentSeparator) {
+			taken = append(taken, ext)
+			delete(r.active, k)
+		}
+	}
+	return taken
+}
+
+// Close closes every active extender.
+func (r *Registry) Close(ctx context.Context) error {
+	r.mu.Lock()
+	active := r.active
+	r.active = make(map[string]IntegrationExtender)
+	r.mu.Unlock()
+
+	var errs []error
+	for _, ext := range active {
+		if err := ext.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// environmentSeparator separates the integration from its environment in keys.
+const environmentSeparator = "@"
+
+// key identifies an integration in an environment inside the registry.
+func key(i *integration.Integration) string {
+	if i.Environment != "" {
+		return baseKey(i) + environmentSeparator + i.Environment
+	}
+	return baseKey(i)
+}
+
+// baseKey identifies an integration inside the registry, falling back to its
+// name when it has not been persisted yet.
+func baseKey(i *integration.Integration) string {
+	if i.ID != "" {
+		return i.ID
+	}
+	return i.Name
+}
+
+func normalizeType(integrationType string) string {
+	return strings.ToLower(strings.TrimSpace(integrationType))
+}
+
+// RegisterLifecycle closes the active extenders when the application stops.
+func RegisterLifecycle(lc fx.Lifecycle, r *Registry) {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return r.Close(ctx)
+		},
+	})
+}
+
+// Register returns an fx option that registers factory for integrationType
+// when the application starts.
+func Register(integrationType string, factory Factory) fx.Option {
+	return fx.Invoke(func(r *Registry) {
+		r.Register(integrationType, factory)
+	})
+}
+
+// Module provides the extender registry for Uber Fx.
+var Module = fx.Options(
+	fx.Provide(NewRegistry),
+	fx.Invoke(RegisterLifecycle),
+)
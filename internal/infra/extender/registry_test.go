@@ -0,0 +1,235 @@
+package extender
+
+import (
+	"context"
+	"errors"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/secrets"
+	"testing"
+)
+
+var errInvalid = errors.New("invalid settings")
+
+// stubExtender records its lifecycle and fails as configured.
+type stubExtender struct {
+	initialized *integration.Integration
+	closed      bool
+	initErr     error
+	validateErr error
+}
+
+func (e *stubExtender) Initialize(ctx context.Context, config *integration.Integration) error {
+	e.initialized = config
+	return e.initErr
+}
+
+func (e *stubExtender) Execute(ctx context.Context, action string, params map[string]interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func (e *stubExtender) Validate(ctx context.Context) error {
+	return e.validateErr
+}
+
+func (e *stubExtender) Close(ctx context.Context) error {
+	e.closed = true
+	return nil
+}
+
+// newStubRegistry returns a registry building stub extenders for the "stub"
+// type, allowing the environment variables prefixed with BANK_, and the
+// extenders it built.
+func newStubRegistry(configure func(e *stubExtender)) (*Registry, *[]*stubExtender) {
+	cfg := &config.Config{}
+	cfg.Secrets.EnvPrefixes = []string{"BANK_"}
+
+	var built []*stubExtender
+	r := NewRegistry(secrets.NewResolver(cfg, nil))
+	r.Register("stub", func(*secrets.Resolver) IntegrationExtender {
+		e := &stubExtender{}
+		if configure != nil {
+			configure(e)
+		}
+		built = append(built, e)
+		return e
+	})
+	return r, &built
+}
+
+// stubIntegration returns an integration of the stub type with a sandbox
+// environment.
+func stubIntegration(id string) *integration.Integration {
+	return &integration.Integration{
+		ID:           id,
+		Name:         "bank",
+		Type:         "stub",
+		BaseURL:      "https://api.bank.com",
+		AuthType:     integration.AuthTypeToken,
+		AuthToken:    "env://BANK_TOKEN",
+		Environments: map[string]*integration.Environment{"sandbox": {BaseURL: "https://sandbox.bank.com"}},
+	}
+}
+
+func TestRegistryBuild(t *testing.T) {
+	tests := []struct {
+		name        string
+		integration func(i *integration.Integration)
+		configure   func(e *stubExtender)
+		wantErr     bool
+		wantErrIs   error
+		wantBuilt   bool
+		wantClosed  bool
+	}{
+		{name: "built", integration: func(i *integration.Integration) {}, wantBuilt: true},
+		{name: "type in any case", integration: func(i *integration.Integration) { i.Type = " Stub " }, wantBuilt: true},
+		{name: "unsupported type", integration: func(i *integration.Integration) { i.Type = "soap" }, wantErr: true, wantErrIs: ErrUnsupportedType},
+		{name: "reference outside the allowlist", integration: func(i *integration.Integration) { i.AuthToken = "env://ENCRYPTION_MASTER_KEY" }, wantErr: true},
+		{name: "initialization failed", integration: func(i *integration.Integration) {}, configure: func(e *stubExtender) { e.initErr = errInvalid }, wantErr: true, wantErrIs: errInvalid, wantBuilt: true},
+		{name: "validation failed", integration: func(i *integration.Integration) {}, configure: func(e *stubExtender) { e.validateErr = errInvalid }, wantErr: true, wantErrIs: errInvalid, wantBuilt: true, wantClosed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, built := newStubRegistry(tt.configure)
+			i := stubIntegration("1")
+			tt.integration(i)
+
+			ext, err := r.Build(context.Background(), i)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("Build() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if got := len(*built) == 1; got != tt.wantBuilt {
+				t.Fatalf("Build() built an extender = %v, want %v", got, tt.wantBuilt)
+			}
+			if !tt.wantErr && (ext != (*built)[0] || (*built)[0].initialized != i) {
+				t.Errorf("Build() = %v, want the extender initialized with the integration", ext)
+			}
+			if tt.wantBuilt && (*built)[0].closed != tt.wantClosed {
+				t.Errorf("extender closed = %v, want %v", (*built)[0].closed, tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestRegistryGet(t *testing.T) {
+	ctx := context.Background()
+	r, built := newStubRegistry(nil)
+	i := stubIntegration("1")
+
+	first, err := r.Get(ctx, i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := r.Get(ctx, stubIntegration("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sandbox, err := r.Get(ctx, i.ForEnvironment("sandbox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first != again || len(*built) != 2 {
+		t.Errorf("Get() built %d extenders, want one per environment", len(*built))
+	}
+	if sandbox == first || (*built)[1].initialized.BaseURL != "https://sandbox.bank.com" {
+		t.Errorf("Get() in the sandbox = %v, want an extender with the sandbox settings", sandbox)
+	}
+}
+
+func TestRegistryActivateRemove(t *testing.T) {
+	tests := []struct {
+		name  string
+		apply func(ctx context.Context, r *Registry, i *integration.Integration) error
+	}{
+		{
+			name: "activate",
+			apply: func(ctx context.Context, r *Registry, i *integration.Integration) error {
+				ext, err := r.Build(ctx, i)
+				if err != nil {
+					return err
+				}
+				r.Activate(ctx, i, ext)
+				if got, err := r.Get(ctx, i); err != nil || got != ext {
+					t.Errorf("Get() after Activate() = %v, %v, want the activated extender", got, err)
+				}
+				return nil
+			},
+		},
+		{
+			name: "remove",
+			apply: func(ctx context.Context, r *Registry, i *integration.Integration) error {
+				return r.Remove(ctx, i)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			r, built := newStubRegistry(nil)
+			i, other := stubIntegration("1"), stubIntegration("2")
+
+			for _, integration := range []*integration.Integration{i, i.ForEnvironment("sandbox"), other} {
+				if _, err := r.Get(ctx, integration); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tt.apply(ctx, r, i); err != nil {
+				t.Fatal(err)
+			}
+
+			// The extenders of the integration, in every environment, are
+			// closed; those of the other integration are left alone.
+			if !(*built)[0].closed || !(*built)[1].closed || (*built)[2].closed {
+				t.Errorf("closed extenders = %v, %v, %v, want true, true, false", (*built)[0].closed, (*built)[1].closed, (*built)[2].closed)
+			}
+			if ext, err := r.Get(ctx, other); err != nil || ext != (*built)[2] {
+				t.Errorf("Get() of the other integration = %v, %v, want its extender", ext, err)
+			}
+		})
+	}
+}
+
+func TestRegistryClose(t *testing.T) {
+	ctx := context.Background()
+	r, built := newStubRegistry(nil)
+	for _, id := range []string{"1", "2"} {
+		if _, err := r.Get(ctx, stubIntegration(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range *built {
+		if !e.closed {
+			t.Error("Close() left an extender open")
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		integration *integration.Integration
+		want        string
+	}{
+		{name: "ID", integration: &integration.Integration{ID: "1", Name: "bank"}, want: "1"},
+		{name: "name when not persisted", integration: &integration.Integration{Name: "bank"}, want: "bank"},
+		{name: "environment", integration: &integration.Integration{ID: "1", Name: "bank", Environment: "sandbox"}, want: "1@sandbox"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := key(tt.integration); got != tt.want {
+				t.Errorf("key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
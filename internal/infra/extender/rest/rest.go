@@ -0,0 +1,340 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/domain/endpoint"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/template"
+	"generic-integration-platform/internal/infra/auth"
+	"generic-integration-platform/internal/infra/extender"
+	"generic-integration-platform/internal/infra/secrets"
+	"generic-integration-platform/internal/infra/tlsconfig"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Type is the integration type handled by this extender.
+const Type = "rest"
+
+// defaultTimeout bounds every request sent to the provider.
+const defaultTimeout = 30 * time.Second
+
+// Extender executes integration actions as JSON requests against a REST API.
+type Extender struct {
+	integration *integration.Integration
+	client      *http.Client
+	auths       []credentialAuth
+	secrets     *secrets.Resolver
+}
+
+// credentialAuth is the authenticator of one of the credentials of the integration.
+type credentialAuth struct {
+	credential *integration.Credential
+	auth       auth.Authenticator
+}
+
+// New creates a new REST extender resolving credentials with resolver.
+func New(resolver *secrets.Resolver) extender.IntegrationExtender {
+	return &Extender{secrets: resolver}
+}
+
+// Initialize sets up the HTTP client for the integration.
+func (e *Extender) Initialize(ctx context.Context, config *integration.Integration) error {
+	if config == nil {
+		return errors.New("integration cannot be nil")
+	}
+
+	// HTTP methods are case-sensitive: "get" would not be recognized as GET.
+	for _, ep := range config.Endpoints {
+		if ep != nil {
+			ep.Method = strings.ToUpper(ep.Method)
+		}
+	}
+
+	e.integration = config
+	e.client = &http.Client{Timeout: defaultTimeout}
+
+	// Providers requiring client certificates get a dedicated transport, also
+	// used to reach their token endpoint.
+	var authClient *http.Client
+	if config.TLS != nil {
+		transport, err := tlsconfig.NewTransport(config.TLS)
+		if err != nil {
+			return err
+		}
+		e.client.Transport = transport
+		authClient = &http.Client{Timeout: defaultTimeout, Transport: transport}
+	}
+
+	// During a key rotation every credential gets its own authenticator.
+	credentials := []*integration.Credential{{Value: config.Secret()}}
+	if config.Credentials != nil && config.Credentials.Primary != nil {
+		credentials = []*integration.Credential{config.Credentials.Primary}
+		if config.Credentials.Secondary != nil {
+			credentials = append(credentials, config.Credentials.Secondary)
+		}
+	}
+
+	e.auths = nil
+	for _, credential := range credentials {
+		authenticator, err := auth.New(config.WithSecret(credential.Value), authClient, e.secrets)
+		if err != nil {
+			return err
+		}
+		e.auths = append(e.auths, credentialAuth{credential: credential, auth: authenticator})
+	}
+
+	return nil
+}
+
+// Validate checks that the integration can be called over REST.
+func (e *Extender) Validate(ctx context.Context) error {
+	if err := e.integration.Validate(); err != nil {
+		return err
+	}
+
+	base, err := url.Parse(e.integration.BaseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return fmt.Errorf("invalid base URL %q", e.integration.BaseURL)
+	}
+
+	for _, ep := range e.integration.Endpoints {
+		if err := ep.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Execute sends the request defined by the endpoint bound to action and
+// returns the mapped response.
+func (e *Extender) Execute(ctx context.Context, action string, params map[string]interface{}) (interface{}, error) {
+	ep := e.endpoint(action)
+	if ep == nil {
+		return nil, fmt.Errorf("action %s is not defined for integration %s", action, e.integration.Name)
+	}
+
+	// Try the active credentials in order, falling back to the next one when
+	// the provider rejects a credential, e.g. while it rotates keys.
+	var (
+		status int
+		body   []byte
+		err    error
+		tried  bool
+	)
+	now := time.Now()
+	for _, ca := range e.auths {
+		if !ca.credential.Active(now) {
+			continue
+		}
+		tried = true
+		status, body, err = e.attempt(ctx, ep, params, ca.auth)
+		if err != nil || status != http.StatusUnauthorized {
+			break
+		}
+	}
+	if !tried {
+		return nil, fmt.Errorf("no credential of integration %s is active", e.integration.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("%s returned status %d: %s", ep.Action, status, strings.TrimSpace(string(body)))
+	}
+
+	response := map[string]interface{}{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return mapResponse(ep, response), nil
+}
+
+// Close releases the idle connections kept by the client.
+func (e *Extender) Close(ctx context.Context) error {
+	if e.client != nil {
+		e.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// attempt sends the request for ep with authenticator. When the credential
+// is rejected and can be renewed, such as an OAuth token revoked before it
+// expired, the request is retried once with a fresh one.
+func (e *Extender) attempt(ctx context.Context, ep *endpoint.Endpoint, params map[string]interface{}, authenticator auth.Authenticator) (int, []byte, error) {
+	status, body, credential, err := e.send(ctx, ep, params, authenticator)
+	if refresher, ok := authenticator.(auth.Refresher); ok && err == nil && status == http.StatusUnauthorized {
+		refresher.Invalidate(credential)
+		status, body, _, err = e.send(ctx, ep, params, authenticator)
+	}
+	return status, body, err
+}
+
+// send renders and sends the request for ep, returning the response status,
+// its body and the credential used, if any.
+func (e *Extender) send(ctx context.Context, ep *endpoint.Endpoint, params map[string]interface{}, authenticator auth.Authenticator) (int, []byte, string, error) {
+	data := templateData(params)
+
+	var credential string
+	if authenticator != nil {
+		var err error
+		if credential, err = authenticator.Credential(ctx); err != nil {
+			return 0, nil, "", err
+		}
+		data["auth_token"] = credential
+	}
+
+	req, err := e.newRequest(ctx, ep, data)
+	if err != nil {
+		return 0, nil, "", err
+	}
+
+	// Apply the credentials to the rendered request unless the endpoint places
+	// them itself with {{auth_token}}.
+	if authenticator != nil && (credential == "" || !usesAuthToken(ep)) {
+		payload, err := requestBody(req)
+		if err != nil {
+			return 0, nil, "", err
+		}
+		if err := authenticator.Apply(req, credential, payload); err != nil {
+			return 0, nil, "", fmt.Errorf("failed to authenticate request: %w", err)
+		}
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return 0, nil, "", fmt.Errorf("failed to call %s: %w", ep.Action, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return resp.StatusCode, body, credential, nil
+}
+
+// requestBody returns a copy of the request body without consuming it.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return nil, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
+
+// usesAuthToken reports whether the endpoint references {{auth_token}} in its
+// path, params or headers.
+func usesAuthToken(ep *endpoint.Endpoint) bool {
+	if strings.Contains(ep.Path, "auth_token") {
+		return true
+	}
+	for _, values := range []map[string]string{ep.Params, ep.Headers} {
+		for _, value := range values {
+			if strings.Contains(value, "auth_token") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// endpoint returns the endpoint bound to action.
+func (e *Extender) endpoint(action string) *endpoint.Endpoint {
+	for _, ep := range e.integration.Endpoints {
+		if ep.Action == action {
+			return ep
+		}
+	}
+	return nil
+}
+
+// newRequest renders the endpoint templates and builds the HTTP request.
+func (e *Extender) newRequest(ctx context.Context, ep *endpoint.Endpoint, data map[string]interface{}) (*http.Request, error) {
+	target := strings.TrimRight(e.integration.BaseURL, "/") + "/" + strings.TrimLeft(template.Render(ep.Path, data), "/")
+
+	var body io.Reader
+	if len(ep.Params) > 0 && ep.Method != http.MethodGet {
+		payload := make(map[string]interface{}, len(ep.Params))
+		for key, tpl := range ep.Params {
+			payload[key] = template.RenderValue(tpl, data)
+		}
+
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, ep.Method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	} else if len(ep.Params) > 0 {
+		query := req.URL.Query()
+		for key, tpl := range ep.Params {
+			query.Set(key, template.Render(tpl, data))
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+	req.Header.Set("Accept", "application/json")
+
+	for key, value := range e.integration.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, tpl := range ep.Headers {
+		req.Header.Set(key, template.Render(tpl, data))
+	}
+
+	return req, nil
+}
+
+// templateData exposes the step params both at the top level and under "input".
+func templateData(params map[string]interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, len(params)+1)
+	for key, value := range params {
+		data[key] = value
+	}
+	data["input"] = params
+
+	return data
+}
+
+// mapResponse applies the endpoint response mappings, returning the raw
+// response when none are defined.
+func mapResponse(ep *endpoint.Endpoint, response map[string]interface{}) map[string]interface{} {
+	if len(ep.ResponseMappings) == 0 {
+		return response
+	}
+
+	data := map[string]interface{}{"response": response}
+	result := make(map[string]interface{}, len(ep.ResponseMappings))
+	for key, tpl := range ep.ResponseMappings {
+		result[key] = template.RenderValue(tpl, data)
+	}
+
+	return result
+}
@@ -0,0 +1,172 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"generic-integration-platform/internal/domain/endpoint"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/secrets"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// apiServer is a provider API recording the Authorization header of every
+// request and answering with the status returned by respond.
+type apiServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	headers []string
+}
+
+// newAPIServer starts an API server closed at the end of the test. respond is
+// given the Authorization header and the number of the request, from 1.
+func newAPIServer(t *testing.T, respond func(header string, n int) int) *apiServer {
+	s := &apiServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.headers = append(s.headers, r.Header.Get("Authorization"))
+		n := len(s.headers)
+		s.mu.Unlock()
+
+		status := respond(r.Header.Get("Authorization"), n)
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			fmt.Fprint(w, `{"id": "pay_1"}`)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newTestExtender returns an extender of the integration, which calls the
+// charge action of server.
+func newTestExtender(t *testing.T, server *apiServer, i *integration.Integration) *Extender {
+	t.Helper()
+
+	i.Name, i.Type, i.BaseURL, i.Currency = "bank", Type, server.URL, "USD"
+	i.Endpoints = []*endpoint.Endpoint{{Action: "charge", Method: "POST", Path: "/charges"}}
+
+	e := New(secrets.NewResolver(&config.Config{}, nil)).(*Extender)
+	if err := e.Initialize(context.Background(), i); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = e.Close(context.Background()) })
+	return e
+}
+
+func TestExecuteCredentials(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		credentials *integration.Credentials
+		accepted    string // The only credential accepted by the provider
+		wantHeaders []string
+		wantErr     bool
+	}{
+		{
+			name:        "primary accepted",
+			credentials: &integration.Credentials{Primary: &integration.Credential{Value: "new-key"}, Secondary: &integration.Credential{Value: "old-key"}},
+			accepted:    "new-key",
+			wantHeaders: []string{"new-key"},
+		},
+		{
+			name:        "primary rejected",
+			credentials: &integration.Credentials{Primary: &integration.Credential{Value: "new-key"}, Secondary: &integration.Credential{Value: "old-key"}},
+			accepted:    "old-key",
+			wantHeaders: []string{"new-key", "old-key"},
+		},
+		{
+			name:        "primary not active yet",
+			credentials: &integration.Credentials{Primary: &integration.Credential{Value: "new-key", ActiveFrom: future}, Secondary: &integration.Credential{Value: "old-key"}},
+			accepted:    "old-key",
+			wantHeaders: []string{"old-key"},
+		},
+		{
+			name:        "both rejected",
+			credentials: &integration.Credentials{Primary: &integration.Credential{Value: "new-key"}, Secondary: &integration.Credential{Value: "old-key"}},
+			accepted:    "other-key",
+			wantHeaders: []string{"new-key", "old-key"},
+			wantErr:     true,
+		},
+		{
+			name:        "no secondary",
+			credentials: &integration.Credentials{Primary: &integration.Credential{Value: "new-key"}},
+			accepted:    "old-key",
+			wantHeaders: []string{"new-key"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newAPIServer(t, func(header string, n int) int {
+				if header != tt.accepted {
+					return http.StatusUnauthorized
+				}
+				return http.StatusOK
+			})
+			e := newTestExtender(t, server, &integration.Integration{AuthType: integration.AuthTypeToken, AuthToken: "new-key", Credentials: tt.credentials})
+
+			result, err := e.Execute(context.Background(), "charge", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "401") {
+				t.Errorf("Execute() error = %v, want the 401 of the provider", err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(result, map[string]interface{}{"id": "pay_1"}) {
+				t.Errorf("Execute() = %v, want the response of the provider", result)
+			}
+			if !reflect.DeepEqual(server.headers, tt.wantHeaders) {
+				t.Errorf("credentials sent = %v, want %v", server.headers, tt.wantHeaders)
+			}
+		})
+	}
+}
+
+func TestExecuteRefreshedToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []int // Statuses of the successive API responses
+		wantHeaders []string
+		wantErr     bool
+	}{
+		{name: "accepted", statuses: []int{200}, wantHeaders: []string{"Bearer token-1"}},
+		{name: "revoked token", statuses: []int{401, 200}, wantHeaders: []string{"Bearer token-1", "Bearer token-2"}},
+		{name: "fresh token rejected", statuses: []int{401, 401}, wantHeaders: []string{"Bearer token-1", "Bearer token-2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := 0
+			tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tokens++
+				fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 3600}`, tokens)
+			}))
+			defer tokenServer.Close()
+
+			server := newAPIServer(t, func(header string, n int) int {
+				return tt.statuses[n-1]
+			})
+			e := newTestExtender(t, server, &integration.Integration{
+				AuthType: integration.AuthTypeOAuth,
+				OAuth:    &integration.OAuth{ClientID: "client", ClientSecret: "s3cr3t", TokenURL: tokenServer.URL},
+			})
+
+			_, err := e.Execute(context.Background(), "charge", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(server.headers, tt.wantHeaders) {
+				t.Errorf("tokens sent = %v, want %v", server.headers, tt.wantHeaders)
+			}
+		})
+	}
+}
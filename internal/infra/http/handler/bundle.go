@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"generic-integration-platform/internal/application/bundle"
+	"generic-integration-platform/internal/application/services"
+	"generic-integration-platform/internal/infra/config"
+	errorDTO "generic-integration-platform/internal/infra/http/dto"
+	"strings"
+
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contentTypes maps bundle formats to their content type.
+var contentTypes = map[string]string{
+	config.FormatYAML: "application/yaml",
+	config.FormatTOML: "application/toml",
+}
+
+// BundleHandler exports and imports configuration bundles.
+type BundleHandler struct {
+	service services.IBundleService
+}
+
+// NewBundleHandler creates a new BundleHandler.
+func NewBundleHandler(s services.IBundleService) *BundleHandler {
+	return &BundleHandler{
+		service: s,
+	}
+}
+
+// @Summary Export integrations and flows
+// @Description Export every integration and flow as a versioned bundle. Flows reference integrations by name and credentials are replaced by secret references
+// @Tags Bundles
+// @Produce application/yaml,application/toml
+// @Param format query string false "Bundle format, yaml (default) or toml"
+// @Success 200 {string} string "Bundle"
+// @Failure 400 {object} errorDTO.ErrorResponseDTO
+// @Failure 500 {object} errorDTO.ErrorResponseDTO
+// @Router /export [get]
+func (h *BundleHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", config.FormatYAML)
+	contentType, ok := contentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, errorDTO.ErrorResponseDTO{Message: "Unsupported bundle format " + format})
+		return
+	}
+
+	b, err := h.service.Export(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		return
+	}
+
+	content, err := b.Encode(format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=bundle."+format)
+	c.Data(http.StatusOK, contentType, content)
+}
+
+// @Summary Import integrations and flows
+// @Description Import a bundle produced by the export. The import is applied fully or not at all; existing items, matched by name, are resolved with the conflict strategy
+// @Tags Bundles
+// @Accept application/yaml,application/toml
+// @Produce json
+// @Param format query string false "Bundle format, yaml (default) or toml; taken from the content type when omitted"
+// @Param conflict query string false "Conflict strategy: fail (default), skip or overwrite"
+// @Param bundle body string true "Bundle"
+// @Success 200 {object} dto.ImportResultDTO
+// @Failure 400 {object} errorDTO.ErrorResponseDTO
+// @Failure 409 {object} errorDTO.ErrorResponseDTO
+// @Failure 500 {object} errorDTO.ErrorResponseDTO
+// @Router /import [post]
+func (h *BundleHandler) Import(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		format = config.FormatYAML
+		if strings.Contains(c.ContentType(), config.FormatTOML) {
+			format = config.FormatTOML
+		}
+	}
+
+	content, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorDTO.ErrorResponseDTO{Message: "Invalid request payload"})
+		return
+	}
+
+	b, err := bundle.Decode(content, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		return
+	}
+
+	result, err := h.service.Import(context.Background(), b, c.DefaultQuery("conflict", services.ConflictFail))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidImport):
+			c.JSON(http.StatusBadRequest, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		case errors.Is(err, services.ErrImportConflict):
+			c.JSON(http.StatusConflict, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
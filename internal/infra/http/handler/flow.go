@@ -2,14 +2,21 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"generic-integration-platform/internal/application/dto"
 	"generic-integration-platform/internal/application/services"
+	"generic-integration-platform/internal/infra/db"
 	errorDTO "generic-integration-platform/internal/infra/http/dto"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxFlowEvents is the maximum number of flow events returned by a request.
+const maxFlowEvents = 1000
+
 type FlowHandler struct {
 	service services.IFlowService
 }
@@ -133,15 +140,26 @@ func (h *FlowHandler) DeleteFlow(c *gin.Context) {
 // @Summary Execute a flow by ID
 // @Description Execute a specific flow by its ID
 // @Tags Flows
+// @Accept json
 // @Produce json
 // @Param id path string true "Flow ID"
+// @Param execution body dto.ExecuteFlowDTO false "Execution input"
 // @Success 200 {object} dto.FlowDTO
 // @Failure 404 {object} errorDTO.ErrorResponseDTO
 // @Failure 500 {object} errorDTO.ErrorResponseDTO
 // @Router /flows/{id}/execute [post]
 func (h *FlowHandler) ExecuteFlow(c *gin.Context) {
 	id := c.Param("id")
-	result, err := h.service.ExecuteFlow(context.Background(), id)
+
+	var execution dto.ExecuteFlowDTO
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&execution); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.service.ExecuteFlow(context.Background(), id, execution.Input, execution.Environment)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -149,3 +167,42 @@ func (h *FlowHandler) ExecuteFlow(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+// GetFlowEvents handles the GET request to retrieve the events of a flow.
+// @Summary Get flow events
+// @Description Retrieve the events of a flow, such as the steps completed, failed or skipped by its executions, in order. Poll with from set to the version after the last event received to follow an execution
+// @Tags Flows
+// @Produce json
+// @Param id path string true "Flow ID"
+// @Param from query int false "Version of the first event to return (default 0)"
+// @Param limit query int false "Maximum number of events to return (default 100, at most 1000)"
+// @Success 200 {array} dto.FlowEventDTO
+// @Failure 400 {object} errorDTO.ErrorResponseDTO
+// @Failure 404 {object} errorDTO.ErrorResponseDTO
+// @Failure 500 {object} errorDTO.ErrorResponseDTO
+// @Router /flows/{id}/events [get]
+func (h *FlowHandler) GetFlowEvents(c *gin.Context) {
+	id := c.Param("id")
+
+	from, err := strconv.ParseUint(c.DefaultQuery("from", "0"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from: " + err.Error()})
+		return
+	}
+	limit, err := strconv.ParseUint(c.DefaultQuery("limit", "100"), 10, 64)
+	if err != nil || limit == 0 || limit > maxFlowEvents {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxFlowEvents)})
+		return
+	}
+
+	events, err := h.service.GetFlowEvents(context.Background(), id, from, limit)
+	if errors.Is(err, db.ErrFlowNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
@@ -6,5 +6,7 @@ var Module = fx.Options(
 	fx.Provide(
 		NewIntegrationHandler,
 		NewFlowHandler,
+		NewSecretHandler,
+		NewBundleHandler,
 	),
 )
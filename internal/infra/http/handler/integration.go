@@ -2,9 +2,12 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"generic-integration-platform/internal/application/dto"
+	"generic-integration-platform/internal/application/importer"
 	"generic-integration-platform/internal/application/services"
 	errorDTO "generic-integration-platform/internal/infra/http/dto"
+	"strings"
 
 	"net/http"
 
@@ -125,6 +128,44 @@ func (h *IntegrationHandler) UpdateIntegration(c *gin.Context) {
 	c.JSON(http.StatusOK, integration)
 }
 
+// @Summary Rotate the credential of an integration
+// @Description Make a new credential primary while the previous one stays active until previous_active_until
+// @Tags Integrations
+// @Accept json
+// @Produce json
+// @Param id path string true "Integration ID"
+// @Param rotation body dto.RotateCredentialsDTO true "New credential and validity windows"
+// @Success 200 {object} dto.IntegrationResponseDTO
+// @Failure 400 {object} errorDTO.ErrorResponseDTO
+// @Failure 404 {object} errorDTO.ErrorResponseDTO
+// @Failure 500 {object} errorDTO.ErrorResponseDTO
+// @Router /integrations/{id}/credentials/rotate [post]
+func (h *IntegrationHandler) RotateCredentials(c *gin.Context) {
+	id := c.Param("id")
+
+	var input dto.RotateCredentialsDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorDTO.ErrorResponseDTO{Message: "Invalid request payload"})
+		return
+	}
+
+	integration, err := h.service.RotateCredentials(context.Background(), id, input)
+	if err != nil {
+		if err == services.ErrIntegrationNotFound {
+			c.JSON(http.StatusNotFound, errorDTO.ErrorResponseDTO{Message: "Integration not found"})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidRotation) {
+			c.JSON(http.StatusBadRequest, errorDTO.ErrorResponseDTO{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, integration)
+}
+
 // @Summary Delete an integration by ID
 // @Description Remove a specific integration by its ID
 // @Tags Integrations
@@ -148,3 +189,112 @@ func (h *IntegrationHandler) DeleteIntegration(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// @Summary Import an integration from an OpenAPI document
+// @Description Create a REST integration whose endpoints are the operations of an OpenAPI 3 document, in YAML or JSON. Credentials are referenced as secret://{name}/{field}
+// @Tags Integrations
+// @Accept application/yaml,json
+// @Produce json
+// @Param name query string false "Integration name, the title of the document by default"
+// @Param base_url query string false "Base URL, the first server of the document by default"
+// @Param currency query string false "Currency, USD by default"
+// @Param operations query string false "Comma-separated operations to import, all by default"
+// @Param scheme query string false "Security scheme to authenticate with, the first one required by default"
+// @Param username query string false "Basic username or OAuth client ID, for the schemes requiring one"
+// @Param document body string true "OpenAPI document"
+// @Success 201 {object} dto.IntegrationResponseDTO
+// @Failure 400 {object} errorDTO.ErrorResponseDTO
+// @Failure 500 {object} errorDTO.ErrorResponseDTO
+// @Router /integrations/import/openapi [post]
+func (h *IntegrationHandler) ImportOpenAPI(c *gin.Context) {
+	content, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorDTO.ErrorResponseDTO{Message: "Invalid request payload"})
+		return
+	}
+
+	opts := importer.OpenAPIOptions{
+		Name:     c.Query("name"),
+		BaseURL:  c.Query("base_url"),
+		Currency: c.Query("currency"),
+		Scheme:   c.Query("scheme"),
+		Username: c.Query("username"),
+	}
+	if operations := c.Query("operations"); operations != "" {
+		opts.Operations = strings.Split(operations, ",")
+	}
+
+	integration, err := h.service.ImportOpenAPI(context.Background(), content, opts)
+	if err != nil {
+		if errors.Is(err, importer.ErrInvalidDocument) {
+			c.JSON(http.StatusBadRequest, errorDTO.ErrorResponseDTO{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, integration)
+}
+
+// @Summary Import an integration from a Postman collection
+// @Description Create a REST integration whose endpoints are the requests of a Postman collection (v2.x). Literal values become {{input.*}} placeholders and credential headers are stripped
+// @Tags Integrations
+// @Accept json
+// @Produce json
+// @Param name query string false "Integration name, the collection name by default"
+// @Param base_url query string false "Base URL, the scheme and host of the first request by default"
+// @Param currency query string false "Currency, USD by default"
+// @Param collection body string true "Postman collection"
+// @Success 201 {object} dto.IntegrationResponseDTO
+// @Failure 400 {object} errorDTO.ErrorResponseDTO
+// @Failure 500 {object} errorDTO.ErrorResponseDTO
+// @Router /integrations/import/postman [post]
+func (h *IntegrationHandler) ImportPostman(c *gin.Context) {
+	h.importCapture(c, h.service.ImportPostman)
+}
+
+// @Summary Import an integration from a HAR capture
+// @Description Create a REST integration whose endpoints are the API calls recorded in a HAR file. Literal values become {{input.*}} placeholders and credential headers are stripped
+// @Tags Integrations
+// @Accept json
+// @Produce json
+// @Param name query string false "Integration name, the host by default"
+// @Param base_url query string false "Base URL, the scheme and host of the first API call by default; calls to other hosts are skipped"
+// @Param currency query string false "Currency, USD by default"
+// @Param har body string true "HAR file"
+// @Success 201 {object} dto.IntegrationResponseDTO
+// @Failure 400 {object} errorDTO.ErrorResponseDTO
+// @Failure 500 {object} errorDTO.ErrorResponseDTO
+// @Router /integrations/import/har [post]
+func (h *IntegrationHandler) ImportHAR(c *gin.Context) {
+	h.importCapture(c, h.service.ImportHAR)
+}
+
+// importCapture creates an integration from the captured requests in the
+// request body with the given importer.
+func (h *IntegrationHandler) importCapture(c *gin.Context, importCapture func(context.Context, []byte, importer.CaptureOptions) (dto.IntegrationResponseDTO, error)) {
+	content, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorDTO.ErrorResponseDTO{Message: "Invalid request payload"})
+		return
+	}
+
+	opts := importer.CaptureOptions{
+		Name:     c.Query("name"),
+		BaseURL:  c.Query("base_url"),
+		Currency: c.Query("currency"),
+	}
+
+	integration, err := importCapture(context.Background(), content, opts)
+	if err != nil {
+		if errors.Is(err, importer.ErrInvalidDocument) {
+			c.JSON(http.StatusBadRequest, errorDTO.ErrorResponseDTO{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, integration)
+}
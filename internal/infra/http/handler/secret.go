@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"generic-integration-platform/internal/application/dto"
+	"generic-integration-platform/internal/application/services"
+	errorDTO "generic-integration-platform/internal/infra/http/dto"
+
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecretHandler manages the secrets of the platform secret store.
+type SecretHandler struct {
+	service services.ISecretService
+}
+
+// NewSecretHandler creates a new SecretHandler.
+func NewSecretHandler(s services.ISecretService) *SecretHandler {
+	return &SecretHandler{
+		service: s,
+	}
+}
+
+// @Summary List all secrets
+// @Description Get the names and references of the stored secrets, never their values
+// @Tags Secrets
+// @Produce json
+// @Success 200 {array} dto.SecretResponseDTO
+// @Failure 500 {object} errorDTO.ErrorResponseDTO
+// @Router /secrets [get]
+func (h *SecretHandler) GetSecrets(c *gin.Context) {
+	secrets, err := h.service.ListSecrets(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, secrets)
+}
+
+// @Summary Store a secret
+// @Description Encrypt and store a secret, referenced in integrations as secret://{name}
+// @Tags Secrets
+// @Accept json
+// @Produce json
+// @Param name path string true "Secret name, e.g. stripe/live-key"
+// @Param secret body dto.SecretRequestDTO true "Secret value"
+// @Success 200 {object} dto.SecretResponseDTO
+// @Failure 400 {object} errorDTO.ErrorResponseDTO
+// @Failure 500 {object} errorDTO.ErrorResponseDTO
+// @Router /secrets/{name} [put]
+func (h *SecretHandler) PutSecret(c *gin.Context) {
+	var input dto.SecretRequestDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorDTO.ErrorResponseDTO{Message: "Invalid request payload"})
+		return
+	}
+
+	secret, err := h.service.PutSecret(context.Background(), c.Param("name"), input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, secret)
+}
+
+// @Summary Delete a secret
+// @Description Remove a secret from the store
+// @Tags Secrets
+// @Produce json
+// @Param name path string true "Secret name"
+// @Success 204
+// @Failure 404 {object} errorDTO.ErrorResponseDTO
+// @Failure 500 {object} errorDTO.ErrorResponseDTO
+// @Router /secrets/{name} [delete]
+func (h *SecretHandler) DeleteSecret(c *gin.Context) {
+	if err := h.service.DeleteSecret(context.Background(), c.Param("name")); err != nil {
+		if err == services.ErrSecretNotFound {
+			c.JSON(http.StatusNotFound, errorDTO.ErrorResponseDTO{Message: "Secret not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorDTO.ErrorResponseDTO{Message: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
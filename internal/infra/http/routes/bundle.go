@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/http/handler"
+	"generic-integration-platform/internal/infra/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BundleRouter struct {
+	handler handler.BundleHandler
+	engine  *gin.Engine
+	config  *config.Config
+}
+
+func NewBundleRouter(handler *handler.BundleHandler, engine *gin.Engine, config *config.Config) *BundleRouter {
+	return &BundleRouter{
+		handler: *handler,
+		engine:  engine,
+		config:  config,
+	}
+}
+
+func (br *BundleRouter) Load() {
+	group := br.engine.Group("/")
+	group.Use(middleware.APIKeyMiddleware(*br.config))
+
+	group.GET("/export", br.handler.Export)  // Export integrations and flows as a bundle
+	group.POST("/import", br.handler.Import) // Import a bundle
+}
@@ -32,4 +32,5 @@ func (fr *FlowRouter) Load() {
 	group.PUT("/:id", fr.handler.UpdateFlow)           // Update an existing flow by ID
 	group.DELETE("/:id", fr.handler.DeleteFlow)        // Delete an existing flow by ID
 	group.POST("/:id/execute", fr.handler.ExecuteFlow) // Execute a specific flow
+	group.GET("/:id/events", fr.handler.GetFlowEvents) // List the events of a flow, e.g. to follow an execution
 }
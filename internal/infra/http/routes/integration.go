@@ -31,4 +31,9 @@ func (ir *IntegrationRouter) Load() {
 	group.GET("/:id", ir.handler.GetIntegrationDetails) // Get a specific integration by ID
 	group.PUT("/:id", ir.handler.UpdateIntegration)     // Update an existing integration by ID
 	group.DELETE("/:id", ir.handler.DeleteIntegration)  // Delete an existing integration by ID
+
+	group.POST("/:id/credentials/rotate", ir.handler.RotateCredentials) // Rotate the credential of an integration
+	group.POST("/import/openapi", ir.handler.ImportOpenAPI)             // Create an integration from an OpenAPI document
+	group.POST("/import/postman", ir.handler.ImportPostman)             // Create an integration from a Postman collection
+	group.POST("/import/har", ir.handler.ImportHAR)                     // Create an integration from a HAR capture
 }
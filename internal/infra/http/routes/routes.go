@@ -14,12 +14,20 @@ type Route interface {
 
 type NewRoutesParams struct {
 	fx.In
-	HealthRouter *GeneralRouter
+	HealthRouter      *GeneralRouter
+	IntegrationRouter *IntegrationRouter
+	FlowRouter        *FlowRouter
+	SecretRouter      *SecretRouter
+	BundleRouter      *BundleRouter
 }
 
 func NewRoutes(rp NewRoutesParams) Routes {
 	return Routes{
 		rp.HealthRouter,
+		rp.IntegrationRouter,
+		rp.FlowRouter,
+		rp.SecretRouter,
+		rp.BundleRouter,
 	}
 }
 
@@ -34,5 +42,9 @@ var Module = fx.Options(
 	fx.Provide(
 		NewRoutes,
 		NewHealthRouter,
+		NewIntegrationRouter,
+		NewFlowRouter,
+		NewSecretRouter,
+		NewBundleRouter,
 	),
 )
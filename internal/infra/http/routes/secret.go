@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/http/handler"
+	"generic-integration-platform/internal/infra/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SecretRouter struct {
+	handler handler.SecretHandler
+	engine  *gin.Engine
+	config  *config.Config
+}
+
+func NewSecretRouter(handler *handler.SecretHandler, engine *gin.Engine, config *config.Config) *SecretRouter {
+	return &SecretRouter{
+		handler: *handler,
+		engine:  engine,
+		config:  config,
+	}
+}
+
+func (sr *SecretRouter) Load() {
+	group := sr.engine.Group("/secrets")
+	group.Use(middleware.APIKeyMiddleware(*sr.config))
+
+	group.GET("/", sr.handler.GetSecrets)           // List the stored secrets, without their values
+	group.PUT("/*name", sr.handler.PutSecret)       // Store a secret; names may contain slashes
+	group.DELETE("/*name", sr.handler.DeleteSecret) // Delete a secret
+}
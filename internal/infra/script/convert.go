@@ -0,0 +1,117 @@
+package script
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// errTooDeep is returned when a value is nested deeper than maxDepth.
+var errTooDeep = errors.New("value is nested too deeply")
+
+// toLua converts a Go value decoded from JSON into a Lua value.
+func toLua(L *lua.LState, value interface{}, depth int) (lua.LValue, error) {
+	if depth > maxDepth {
+		return lua.LNil, errTooDeep
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return lua.LNil, nil
+	case bool:
+		return lua.LBool(v), nil
+	case string:
+		return lua.LString(v), nil
+	case int:
+		return lua.LNumber(v), nil
+	case int64:
+		return lua.LNumber(v), nil
+	case float64:
+		return lua.LNumber(v), nil
+	case map[string]interface{}:
+		table := L.NewTable()
+		for key, item := range v {
+			lv, err := toLua(L, item, depth+1)
+			if err != nil {
+				return lua.LNil, err
+			}
+			table.RawSetString(key, lv)
+		}
+		return table, nil
+	case []interface{}:
+		table := L.NewTable()
+		for _, item := range v {
+			lv, err := toLua(L, item, depth+1)
+			if err != nil {
+				return lua.LNil, err
+			}
+			table.Append(lv)
+		}
+		return table, nil
+	}
+
+	// Fall back to reflection for typed maps and slices such as map[string]string.
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map:
+		m := make(map[string]interface{}, rv.Len())
+		for _, key := range rv.MapKeys() {
+			m[fmt.Sprint(key.Interface())] = rv.MapIndex(key).Interface()
+		}
+		return toLua(L, m, depth)
+	case reflect.Slice, reflect.Array:
+		s := make([]interface{}, rv.Len())
+		for i := range s {
+			s[i] = rv.Index(i).Interface()
+		}
+		return toLua(L, s, depth)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return lua.LNumber(rv.Convert(reflect.TypeOf(float64(0))).Float()), nil
+	case reflect.Float32:
+		return lua.LNumber(rv.Float()), nil
+	}
+
+	return lua.LString(fmt.Sprint(value)), nil
+}
+
+// fromLua converts a Lua value into a Go value that can be encoded as JSON.
+// Tables with consecutive integer keys starting at 1 become slices.
+func fromLua(value lua.LValue, depth int) interface{} {
+	if depth > maxDepth {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case lua.LBool:
+		return bool(v)
+	case lua.LString:
+		return string(v)
+	case lua.LNumber:
+		return float64(v)
+	case *lua.LTable:
+		if n := v.MaxN(); n > 0 && n == countKeys(v) {
+			items := make([]interface{}, 0, n)
+			for i := 1; i <= n; i++ {
+				items = append(items, fromLua(v.RawGetInt(i), depth+1))
+			}
+			return items
+		}
+
+		m := map[string]interface{}{}
+		v.ForEach(func(key, item lua.LValue) {
+			m[key.String()] = fromLua(item, depth+1)
+		})
+		return m
+	}
+
+	return nil
+}
+
+// countKeys returns the number of keys in a table.
+func countKeys(table *lua.LTable) int {
+	n := 0
+	table.ForEach(func(lua.LValue, lua.LValue) { n++ })
+	return n
+}
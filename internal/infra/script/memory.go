@@ -0,0 +1,452 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/ast"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Local variables holding the functions scripts are compiled to call instead of
+// the VM instructions building values. They are not valid Lua names, so
+// scripts cannot reference or shadow them.
+const (
+	concatLocal   = "(concat)"   // The concatenation operator
+	setTableLocal = "(settable)" // Assignments to table fields
+	newTableLocal = "(newtable)" // Table constructors
+)
+
+// Estimated sizes of tables, charged to the budget as they grow.
+const (
+	tableSize = 128 // An empty table
+	slotSize  = 32  // A field of a table, with its boxed value
+)
+
+// budget is the number of bytes a script may still allocate for the strings
+// and tables it builds. The Go runtime does not account allocations per
+// goroutine, so the limit is enforced by the builtins that build strings (the
+// concatenation operator, string.rep, string.format, string.gsub, string.upper,
+// string.lower, string.reverse, string.char and table.concat) and by those that
+// create tables or add fields to them: table constructors, assignments to new
+// fields, table.insert and rawset. Each Lua state gets its own budget,
+// unaffected by concurrent scripts.
+type budget struct {
+	remaining int
+	cancel    context.CancelCauseFunc
+}
+
+// newBudget creates a budget of limit bytes. Exceeding it cancels the script
+// with ErrMemoryLimit.
+func newBudget(limit uint64, cancel context.CancelCauseFunc) *budget {
+	return &budget{
+		remaining: int(min(limit, math.MaxInt)),
+		cancel:    cancel,
+	}
+}
+
+// charge takes size bytes from the budget before they are allocated, raising
+// an error when the budget is exhausted. The script is cancelled as well, so
+// that pcall cannot recover from it.
+func (b *budget) charge(L *lua.LState, size int) {
+	if size <= b.remaining {
+		b.remaining -= size
+		return
+	}
+
+	b.remaining = 0
+	b.cancel(ErrMemoryLimit)
+	L.RaiseError("%s", ErrMemoryLimit)
+}
+
+// install replaces the builtins of L building strings or growing tables with
+// versions charging their result to the budget.
+func (b *budget) install(L *lua.LState) {
+	base := L.Get(lua.GlobalsIndex).(*lua.LTable)
+	strs := L.GetGlobal(lua.StringLibName).(*lua.LTable)
+	tables := L.GetGlobal(lua.TabLibName).(*lua.LTable)
+
+	b.wrap(L, strs, "rep", repSize)
+	b.wrap(L, strs, "format", formatSize)
+	b.wrap(L, strs, "gsub", b.gsubSize)
+	b.wrap(L, strs, "upper", argSize)
+	b.wrap(L, strs, "lower", argSize)
+	b.wrap(L, strs, "reverse", argSize)
+	b.wrap(L, strs, "char", argCount)
+	b.wrap(L, tables, "concat", concatSize)
+	b.wrap(L, tables, "insert", func(*lua.LState) int { return slotSize })
+	b.wrap(L, base, "rawset", newFieldSize)
+}
+
+// wrap replaces the builtin name of lib with a function charging size(L)
+// bytes before calling it.
+func (b *budget) wrap(L *lua.LState, lib *lua.LTable, name string, size func(L *lua.LState) int) {
+	builtin := lib.RawGetString(name).(*lua.LFunction).GFunction
+	lib.RawSetString(name, L.NewFunction(func(L *lua.LState) int {
+		b.charge(L, size(L))
+		return builtin(L)
+	}))
+}
+
+// concat implements the concatenation operator, which scripts are compiled
+// to call instead of the VM instruction.
+func (b *budget) concat(L *lua.LState) int {
+	lhs, rhs := L.Get(1), L.Get(2)
+
+	if lua.LVCanConvToString(lhs) && lua.LVCanConvToString(rhs) {
+		l, r := lua.LVAsString(lhs), lua.LVAsString(rhs)
+		b.charge(L, len(l)+len(r))
+		L.Push(lua.LString(l + r))
+		return 1
+	}
+
+	op := L.GetMetaField(lhs, "__concat")
+	if op == lua.LNil {
+		op = L.GetMetaField(rhs, "__concat")
+	}
+	if op.Type() != lua.LTFunction {
+		L.RaiseError("cannot perform concat operation between %v and %v", lhs.Type(), rhs.Type())
+	}
+
+	L.Push(op)
+	L.Push(lhs)
+	L.Push(rhs)
+	L.Call(2, 1)
+	return 1
+}
+
+// setTable implements assignments to table fields, which scripts are compiled
+// to call instead of the VM instruction, charging the fields they add.
+func (b *budget) setTable(L *lua.LState) int {
+	b.charge(L, newFieldSize(L))
+	L.SetTable(L.Get(1), L.Get(2), L.Get(3))
+	return 0
+}
+
+// newTable charges the table built by a constructor, which scripts are
+// compiled to pass to it, and returns it.
+func (b *budget) newTable(L *lua.LState) int {
+	t := L.CheckTable(1)
+	size := tableSize
+	t.ForEach(func(lua.LValue, lua.LValue) {
+		size = add(size, slotSize)
+	})
+	b.charge(L, size)
+	return 1
+}
+
+// newFieldSize is the size of the field set by rawset(t, k, v) or t[k] = v:
+// one slot when the field is new, nothing otherwise.
+func newFieldSize(L *lua.LState) int {
+	t, ok := L.Get(1).(*lua.LTable)
+	if !ok || L.Get(3) == lua.LNil || t.RawGet(L.Get(2)) != lua.LNil {
+		return 0
+	}
+	return slotSize
+}
+
+// argSize is the length of the string argument of string.upper, string.lower
+// and string.reverse, and of their result.
+func argSize(L *lua.LState) int {
+	return len(L.CheckString(1))
+}
+
+// argCount is the number of arguments of string.char, and the length of its
+// result.
+func argCount(L *lua.LState) int {
+	return L.GetTop()
+}
+
+// gsubSize bounds the result of string.gsub(s, pattern, repl [, n]). Tables
+// and functions are replaced by a function charging each value it returns.
+func (b *budget) gsubSize(L *lua.LState) int {
+	s := L.CheckString(1)
+	matches := len(s) + 1
+	if n := L.OptInt(4, -1); n >= 0 {
+		matches = min(matches, n)
+	}
+
+	switch repl := L.Get(3).(type) {
+	case lua.LString:
+		// Captures do not overlap across matches: every %n adds at most s.
+		captures := strings.Count(string(repl), "%") - 2*strings.Count(string(repl), "%%")
+		return add(len(s), mul(matches, len(repl)), mul(max(captures, 0), len(s)))
+	case *lua.LTable:
+		L.Replace(3, L.NewFunction(func(L *lua.LState) int {
+			value := L.GetTable(repl, L.Get(1))
+			b.charge(L, stringSize(value))
+			L.Push(value)
+			return 1
+		}))
+	case *lua.LFunction:
+		L.Replace(3, L.NewFunction(func(L *lua.LState) int {
+			L.Insert(repl, 1)
+			L.Call(L.GetTop()-1, 1)
+			b.charge(L, stringSize(L.Get(-1)))
+			return 1
+		}))
+	}
+	return len(s)
+}
+
+// repSize is the size of the result of string.rep(s, n).
+func repSize(L *lua.LState) int {
+	return mul(len(L.CheckString(1)), max(L.CheckInt(2), 0))
+}
+
+// formatSize bounds the result of string.format(format, ...) by the format,
+// the widths and precisions of its verbs and the arguments.
+func formatSize(L *lua.LState) int {
+	format := L.CheckString(1)
+
+	size := len(format)
+	for i := 2; i <= L.GetTop(); i++ {
+		size = add(size, stringSize(L.Get(i)))
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			continue
+		}
+		for i < len(format) && strings.IndexByte("-+ #0", format[i]) >= 0 {
+			i++
+		}
+		for i < len(format) && (format[i] == '.' || format[i] >= '0' && format[i] <= '9') {
+			n := 0
+			for ; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+				n = add(mul(n, 10), int(format[i]-'0'))
+			}
+			size = add(size, n)
+			if i < len(format) && format[i] == '.' {
+				i++
+			}
+		}
+	}
+
+	return size
+}
+
+// concatSize is the size of the result of table.concat(t [, sep [, i [, j]]]).
+func concatSize(L *lua.LState) int {
+	t := L.CheckTable(1)
+	sep := len(L.OptString(2, ""))
+	i := max(L.OptInt(3, 1), 1)
+	j := min(L.OptInt(4, t.Len()), t.Len())
+
+	size := 0
+	for k := i; k <= j; k++ {
+		size = add(size, stringSize(t.RawGetInt(k)))
+		if k < j {
+			size = add(size, sep)
+		}
+	}
+	return size
+}
+
+// stringSize is the length of value converted to a string, 0 if it cannot be.
+func stringSize(value lua.LValue) int {
+	if !lua.LVCanConvToString(value) {
+		return 0
+	}
+	return len(lua.LVAsString(value))
+}
+
+// add returns a+b for non-negative numbers, saturating instead of overflowing.
+func add(values ...int) int {
+	sum := 0
+	for _, v := range values {
+		if v > math.MaxInt-sum {
+			return math.MaxInt
+		}
+		sum += v
+	}
+	return sum
+}
+
+// mul returns a*b for non-negative numbers, saturating instead of overflowing.
+func mul(a, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
+
+// compile compiles source with its concatenations, assignments to table
+// fields and table constructors rewritten into calls to the functions passed
+// as the arguments of the chunk, in this order.
+func compile(L *lua.LState, source string) (*lua.LFunction, error) {
+	chunk, err := parse.Parse(strings.NewReader(source), "<string>")
+	if err != nil {
+		return nil, err
+	}
+
+	rewriteStmts(chunk)
+	local := &ast.LocalAssignStmt{Names: []string{concatLocal, setTableLocal, newTableLocal}, Exprs: []ast.Expr{&ast.Comma3Expr{}}}
+	chunk = append([]ast.Stmt{local}, chunk...)
+
+	proto, err := lua.Compile(chunk, "<string>")
+	if err != nil {
+		return nil, err
+	}
+
+	return L.NewFunctionFromProto(proto), nil
+}
+
+// rewriteStmts rewrites the concatenations, assignments to table fields and
+// table constructors in stmts in place.
+func rewriteStmts(stmts []ast.Stmt) {
+	for i, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *ast.AssignStmt:
+			rewriteExprs(s.Lhs)
+			rewriteExprs(s.Rhs)
+			stmts[i] = rewriteAssign(s)
+		case *ast.LocalAssignStmt:
+			rewriteExprs(s.Exprs)
+		case *ast.FuncCallStmt:
+			s.Expr = rewriteExpr(s.Expr)
+		case *ast.DoBlockStmt:
+			rewriteStmts(s.Stmts)
+		case *ast.WhileStmt:
+			s.Condition = rewriteExpr(s.Condition)
+			rewriteStmts(s.Stmts)
+		case *ast.RepeatStmt:
+			s.Condition = rewriteExpr(s.Condition)
+			rewriteStmts(s.Stmts)
+		case *ast.IfStmt:
+			s.Condition = rewriteExpr(s.Condition)
+			rewriteStmts(s.Then)
+			rewriteStmts(s.Else)
+		case *ast.NumberForStmt:
+			s.Init = rewriteExpr(s.Init)
+			s.Limit = rewriteExpr(s.Limit)
+			s.Step = rewriteExpr(s.Step)
+			rewriteStmts(s.Stmts)
+		case *ast.GenericForStmt:
+			rewriteExprs(s.Exprs)
+			rewriteStmts(s.Stmts)
+		case *ast.FuncDefStmt:
+			rewriteStmts(s.Func.Stmts)
+		case *ast.ReturnStmt:
+			rewriteExprs(s.Exprs)
+		}
+	}
+}
+
+// rewriteAssign returns the assignment s with its assignments to table fields
+// replaced by calls to the setTableLocal function. Like the VM, it evaluates
+// the tables and keys assigned, then the values, before assigning them:
+//
+//	do
+//		local (t1), (k1) = t, k
+//		local (v1), (v2) = x, y
+//		(settable)((t1), (k1), (v1))
+//		a = (v2)
+//	end
+func rewriteAssign(s *ast.AssignStmt) ast.Stmt {
+	fields := false
+	for _, lhs := range s.Lhs {
+		_, field := lhs.(*ast.AttrGetExpr)
+		fields = fields || field
+	}
+	if !fields {
+		return s
+	}
+
+	targets := &ast.LocalAssignStmt{}
+	values := &ast.LocalAssignStmt{Exprs: s.Rhs}
+	var assigns []ast.Stmt
+	for n, lhs := range s.Lhs {
+		value := fmt.Sprintf("(v%d)", n+1)
+		values.Names = append(values.Names, value)
+
+		field, ok := lhs.(*ast.AttrGetExpr)
+		if !ok {
+			assigns = append(assigns, &ast.AssignStmt{Lhs: []ast.Expr{lhs}, Rhs: []ast.Expr{&ast.IdentExpr{Value: value}}})
+			continue
+		}
+
+		table, key := fmt.Sprintf("(t%d)", n+1), fmt.Sprintf("(k%d)", n+1)
+		targets.Names = append(targets.Names, table, key)
+		targets.Exprs = append(targets.Exprs, field.Object, field.Key)
+		call := &ast.FuncCallExpr{
+			Func: &ast.IdentExpr{Value: setTableLocal},
+			Args: []ast.Expr{&ast.IdentExpr{Value: table}, &ast.IdentExpr{Value: key}, &ast.IdentExpr{Value: value}},
+		}
+		call.SetLine(s.Line())
+		call.SetLastLine(s.LastLine())
+		assigns = append(assigns, &ast.FuncCallStmt{Expr: call})
+	}
+
+	block := &ast.DoBlockStmt{Stmts: append([]ast.Stmt{targets, values}, assigns...)}
+	block.SetLine(s.Line())
+	block.SetLastLine(s.LastLine())
+	return block
+}
+
+// rewriteExprs rewrites the concatenations in exprs in place.
+func rewriteExprs(exprs []ast.Expr) {
+	for i, expr := range exprs {
+		exprs[i] = rewriteExpr(expr)
+	}
+}
+
+// rewriteExpr returns expr with its concatenations replaced by calls to the
+// concatLocal function and its table constructors passed to the
+// newTableLocal function.
+func rewriteExpr(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.StringConcatOpExpr:
+		call := &ast.FuncCallExpr{
+			Func: &ast.IdentExpr{Value: concatLocal},
+			Args: []ast.Expr{rewriteExpr(e.Lhs), rewriteExpr(e.Rhs)},
+		}
+		call.SetLine(e.Line())
+		call.SetLastLine(e.LastLine())
+		return call
+	case *ast.AttrGetExpr:
+		e.Object = rewriteExpr(e.Object)
+		e.Key = rewriteExpr(e.Key)
+	case *ast.TableExpr:
+		for _, field := range e.Fields {
+			field.Key = rewriteExpr(field.Key)
+			field.Value = rewriteExpr(field.Value)
+		}
+		call := &ast.FuncCallExpr{
+			Func: &ast.IdentExpr{Value: newTableLocal},
+			Args: []ast.Expr{e},
+		}
+		call.SetLine(e.Line())
+		call.SetLastLine(e.LastLine())
+		return call
+	case *ast.FuncCallExpr:
+		e.Func = rewriteExpr(e.Func)
+		e.Receiver = rewriteExpr(e.Receiver)
+		rewriteExprs(e.Args)
+	case *ast.LogicalOpExpr:
+		e.Lhs = rewriteExpr(e.Lhs)
+		e.Rhs = rewriteExpr(e.Rhs)
+	case *ast.RelationalOpExpr:
+		e.Lhs = rewriteExpr(e.Lhs)
+		e.Rhs = rewriteExpr(e.Rhs)
+	case *ast.ArithmeticOpExpr:
+		e.Lhs = rewriteExpr(e.Lhs)
+		e.Rhs = rewriteExpr(e.Rhs)
+	case *ast.UnaryMinusOpExpr:
+		e.Expr = rewriteExpr(e.Expr)
+	case *ast.UnaryNotOpExpr:
+		e.Expr = rewriteExpr(e.Expr)
+	case *ast.UnaryLenOpExpr:
+		e.Expr = rewriteExpr(e.Expr)
+	case *ast.FunctionExpr:
+		rewriteStmts(e.Stmts)
+	}
+	return expr
+}
@@ -0,0 +1,161 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/infra/config"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+	"go.uber.org/fx"
+)
+
+const (
+	defaultTimeout     = 2 * time.Second
+	defaultMemoryLimit = 64 << 20 // 64 MiB
+	callStackSize      = 256
+	registrySize       = 1024
+	registryMaxSize    = 64 * 1024
+	maxDepth           = 32
+)
+
+var (
+	// ErrTimeout is returned when a script exceeds its CPU time limit.
+	ErrTimeout = errors.New("script exceeded its time limit")
+	// ErrMemoryLimit is returned when a script exceeds its memory limit.
+	ErrMemoryLimit = errors.New("script exceeded its memory limit")
+)
+
+// Runner runs Lua scripts in a sandbox with CPU-time and memory limits.
+//
+// The memory limit bounds the bytes of the strings and tables a script
+// builds, tables being charged an estimate of their size, and its call stack
+// and value stack have a fixed maximum size.
+//
+// Scripts only have access to the base, table, string and math libraries.
+// The execution context is exposed as the global table "ctx" and the script
+// must return a table with its outputs:
+//
+//	local amount = ctx.input.amount
+//	return { minor_units = math.floor(amount * 100) }
+type Runner struct {
+	timeout     time.Duration
+	memoryLimit uint64
+}
+
+// NewRunner creates a Runner with the limits set in the configuration.
+func NewRunner(cfg *config.Config) *Runner {
+	r := &Runner{
+		timeout:     cfg.Script.Timeout,
+		memoryLimit: cfg.Script.MemoryLimit,
+	}
+
+	if r.timeout <= 0 {
+		r.timeout = defaultTimeout
+	}
+	if r.memoryLimit == 0 {
+		r.memoryLimit = defaultMemoryLimit
+	}
+
+	return r
+}
+
+// Run executes source with data exposed as the "ctx" global and returns the
+// table returned by the script.
+func (r *Runner) Run(ctx context.Context, source string, data map[string]interface{}) (map[string]interface{}, error) {
+	ctx, cancelTimeout := context.WithTimeout(ctx, r.timeout)
+	defer cancelTimeout()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	L := newState()
+	defer L.Close()
+	L.SetContext(ctx)
+
+	memory := newBudget(r.memoryLimit, cancel)
+	memory.install(L)
+
+	value, err := toLua(L, data, 0)
+	if err != nil {
+		return nil, err
+	}
+	L.SetGlobal("ctx", value)
+
+	fn, err := compile(L, source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid script: %w", err)
+	}
+
+	L.Push(fn)
+	L.Push(L.NewFunction(memory.concat))
+	L.Push(L.NewFunction(memory.setTable))
+	L.Push(L.NewFunction(memory.newTable))
+	if err := L.PCall(3, 1, nil); err != nil {
+		if cause := context.Cause(ctx); cause != nil {
+			if errors.Is(cause, context.DeadlineExceeded) {
+				return nil, ErrTimeout
+			}
+			return nil, cause
+		}
+		var apiErr *lua.ApiError
+		if errors.As(err, &apiErr) && apiErr.Object != nil {
+			return nil, fmt.Errorf("script failed: %s", apiErr.Object.String())
+		}
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+
+	ret := L.Get(-1)
+	L.Pop(1)
+
+	switch ret.Type() {
+	case lua.LTNil:
+		return map[string]interface{}{}, nil
+	case lua.LTTable:
+		outputs, ok := fromLua(ret, 0).(map[string]interface{})
+		if !ok {
+			return map[string]interface{}{"result": fromLua(ret, 0)}, nil
+		}
+		return outputs, nil
+	default:
+		return nil, fmt.Errorf("script must return a table, got %s", ret.Type())
+	}
+}
+
+// newState creates a Lua state with only the safe standard libraries loaded.
+func newState() *lua.LState {
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:        true,
+		CallStackSize:       callStackSize,
+		RegistrySize:        registrySize,
+		RegistryMaxSize:     registryMaxSize,
+		MinimizeStackMemory: true,
+	})
+
+	for _, lib := range []struct {
+		name string
+		fn   lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	// Remove the base functions that reach the file system or load code.
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "collectgarbage", "print"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	return L
+}
+
+// Module provides the script runner for Uber Fx.
+var Module = fx.Options(
+	fx.Provide(NewRunner),
+)
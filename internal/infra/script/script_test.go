@@ -0,0 +1,150 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"generic-integration-platform/internal/infra/config"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRunner creates a Runner with a short timeout and a 1 MiB memory limit.
+func newTestRunner() *Runner {
+	return NewRunner(&config.Config{Script: config.ScriptConfig{
+		Timeout:     200 * time.Millisecond,
+		MemoryLimit: 1 << 20,
+	}})
+}
+
+func TestRun(t *testing.T) {
+	data := map[string]interface{}{
+		"input": map[string]interface{}{"amount": 12.5, "currency": "usd"},
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   map[string]interface{}
+	}{
+		{
+			name:   "outputs",
+			source: `return { minor_units = math.floor(ctx.input.amount * 100), currency = string.upper(ctx.input.currency) }`,
+			want:   map[string]interface{}{"minor_units": float64(1250), "currency": "USD"},
+		},
+		{
+			name:   "no outputs",
+			source: `local x = 1`,
+			want:   map[string]interface{}{},
+		},
+		{
+			name:   "concatenation",
+			source: `local s = "" for i = 1, 3 do s = s .. i .. "," end return { v = s }`,
+			want:   map[string]interface{}{"v": "1,2,3,"},
+		},
+		{
+			name:   "concat metamethod",
+			source: `local t = setmetatable({}, { __concat = function(a, b) return "meta" end }) return { l = t .. "x", r = "x" .. t }`,
+			want:   map[string]interface{}{"l": "meta", "r": "meta"},
+		},
+		{
+			name:   "string builtins",
+			source: `return { rep = ("ab"):rep(2), fmt = string.format("%05d", 42), gsub = (string.gsub("hello", "l", "%0%0")), cat = table.concat({1, 2, 3}, ",") }`,
+			want:   map[string]interface{}{"rep": "abab", "fmt": "00042", "gsub": "hellllo", "cat": "1,2,3"},
+		},
+		{
+			name:   "gsub with a function",
+			source: `return { v = (string.gsub("abc", "%w", function(c) return c:upper() end)) }`,
+			want:   map[string]interface{}{"v": "ABC"},
+		},
+		{
+			name:   "table fields",
+			source: `local t, n = {}, 0 t[1], n, t.x = "a", 2, {y = 1} t[n] = "b" rawset(t, 3, "c") table.insert(t, "d") return { v = table.concat(t, ","), y = t.x.y }`,
+			want:   map[string]interface{}{"v": "a,b,c,d", "y": float64(1)},
+		},
+		{
+			name:   "newindex metamethod",
+			source: `local seen = {} local t = setmetatable({}, { __newindex = function(t, k, v) seen[#seen + 1] = k end }) t.a = 1 return { v = seen[1], raw = rawget(t, "a") == nil }`,
+			want:   map[string]interface{}{"v": "a", "raw": true},
+		},
+		{
+			name:   "overwritten field",
+			source: `local t = {} for i = 1, 10000 do t[1] = i end return { v = t[1] }`,
+			want:   map[string]interface{}{"v": float64(10000)},
+		},
+	}
+
+	runner := newTestRunner()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runner.Run(context.Background(), tt.source, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Run() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr error
+		// wantMessage is checked instead of wantErr when set.
+		wantMessage string
+	}{
+		{name: "infinite loop", source: `while true do end`, wantErr: ErrTimeout},
+		{name: "string.rep", source: `return { v = string.rep("x", 1e9) }`, wantErr: ErrMemoryLimit},
+		{name: "doubling concatenation", source: `local s = "x" for i = 1, 40 do s = s .. s end return { v = #s }`, wantErr: ErrMemoryLimit},
+		{name: "recovered with pcall", source: `pcall(string.rep, "x", 1e9) while true do end`, wantErr: ErrMemoryLimit},
+		{name: "string.format width", source: `return { v = string.format("%99999999d", 1) }`, wantErr: ErrMemoryLimit},
+		{name: "table.concat", source: `local t = {} for i = 1, 100 do t[i] = ("y"):rep(100000) end return { v = table.concat(t) }`, wantErr: ErrMemoryLimit},
+		{name: "table growth", source: `local t = {} for i = 1, 1e8 do t[i] = {i, i, i, i} end return {}`, wantErr: ErrMemoryLimit},
+		{name: "table fields", source: `local t = {} for i = 1, 1e8 do t[i] = i end return {}`, wantErr: ErrMemoryLimit},
+		{name: "table.insert", source: `local t = {} for i = 1, 1e8 do table.insert(t, i) end return {}`, wantErr: ErrMemoryLimit},
+		{name: "string.upper", source: `local s, t = ("x"):rep(100000), {} for i = 1, 100 do t[i] = s:upper() end return {}`, wantErr: ErrMemoryLimit},
+		{name: "gsub replacement", source: `return { v = string.gsub(("a"):rep(1000), "a", function() return ("b"):rep(1000) end) }`, wantErr: ErrMemoryLimit},
+		{name: "deep recursion", source: `local function f(n) return 1 + f(n + 1) end return { v = f(1) }`, wantMessage: "stack overflow"},
+		{name: "removed function", source: `loadstring("return 1")`, wantMessage: "script failed"},
+		{name: "syntax error", source: `return {`, wantMessage: "invalid script"},
+		{name: "not a table", source: `return 1`, wantMessage: "script must return a table"},
+	}
+
+	runner := newTestRunner()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runner.Run(context.Background(), tt.source, nil)
+			if err == nil {
+				t.Fatal("Run() succeeded, want an error")
+			}
+			if tt.wantMessage != "" {
+				if !strings.Contains(err.Error(), tt.wantMessage) {
+					t.Errorf("Run() error = %v, want it to contain %q", err, tt.wantMessage)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunSandbox(t *testing.T) {
+	runner := newTestRunner()
+	for _, name := range []string{"io", "os", "debug", "package", "dofile", "loadfile", "load", "loadstring", "require", "collectgarbage", "print"} {
+		t.Run(name, func(t *testing.T) {
+			got, err := runner.Run(context.Background(), `return { v = type(`+name+`) }`, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got["v"] != "nil" {
+				t.Errorf("type(%s) = %v, want nil", name, got["v"])
+			}
+		})
+	}
+}
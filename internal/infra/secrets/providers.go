@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"generic-integration-platform/internal/domain/secret"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvProvider resolves env://NAME references from environment variables.
+type EnvProvider struct {
+	Allowlist secret.Allowlist // The variables that may be read
+}
+
+// Resolve returns the value of the environment variable name.
+func (p EnvProvider) Resolve(ctx context.Context, name string) (string, error) {
+	if !p.Allowlist.AllowsEnv(name) {
+		return "", fmt.Errorf("environment variable %s is not allowed", name)
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// FileProvider resolves file://PATH references from file contents, as mounted
+// by Docker or Kubernetes secrets.
+type FileProvider struct {
+	Allowlist secret.Allowlist // The directories that may be read
+}
+
+// Resolve returns the contents of the file at path without its trailing
+// newline. Symbolic links are followed only to files of the allowed
+// directories.
+func (p FileProvider) Resolve(ctx context.Context, path string) (string, error) {
+	if !p.Allowlist.AllowsFile(path) {
+		return "", fmt.Errorf("file %s is outside the allowed directories", path)
+	}
+
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	dirs := secret.Allowlist{FileDirs: make([]string, 0, len(p.Allowlist.FileDirs))}
+	for _, dir := range p.Allowlist.FileDirs {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		dirs.FileDirs = append(dirs.FileDirs, dir)
+	}
+	if !dirs.AllowsFile(target) {
+		return "", fmt.Errorf("file %s links outside the allowed directories", path)
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
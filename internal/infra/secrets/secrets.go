@@ -0,0 +1,143 @@
+// Package secrets resolves the secret references stored in integrations into
+// the credentials sent to providers.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"generic-integration-platform/internal/domain/secret"
+	"generic-integration-platform/internal/infra/config"
+
+	"go.uber.org/fx"
+)
+
+// SecretProvider returns the value of the secret with the given name, the part
+// of the reference following its scheme.
+type SecretProvider interface {
+	Resolve(ctx context.Context, name string) (string, error)
+}
+
+// Resolver dispatches secret references to the provider of their scheme.
+// Values that are not references are returned as they are.
+type Resolver struct {
+	providers map[string]SecretProvider
+	allowlist secret.Allowlist
+}
+
+// NewResolver creates a Resolver with the env, file and secret store backends.
+// The env and file backends only read the variables and files allowed by the
+// configuration.
+func NewResolver(cfg *config.Config, store *Store) *Resolver {
+	allowlist := Allowlist(cfg)
+	return &Resolver{
+		providers: map[string]SecretProvider{
+			secret.SchemeEnv:    EnvProvider{Allowlist: allowlist},
+			secret.SchemeFile:   FileProvider{Allowlist: allowlist},
+			secret.SchemeSecret: store,
+		},
+		allowlist: allowlist,
+	}
+}
+
+// Allowlist returns the secret references allowed by the configuration.
+func Allowlist(cfg *config.Config) secret.Allowlist {
+	return secret.Allowlist{
+		EnvPrefixes: cfg.Secrets.EnvPrefixes,
+		FileDirs:    cfg.Secrets.FileDirs,
+	}
+}
+
+// Check returns an error if the integration references environment variables
+// or files that the resolver does not allow, in its credentials or its TLS
+// settings. A nil Resolver allows none.
+func (r *Resolver) Check(i *integration.Integration) error {
+	var allowlist secret.Allowlist
+	if r != nil {
+		allowlist = r.allowlist
+	}
+	return i.CheckReferences(allowlist)
+}
+
+// Register adds a provider for scheme, replacing any previous one.
+func (r *Resolver) Register(scheme string, provider SecretProvider) {
+	r.providers[scheme] = provider
+}
+
+// Resolve returns the value referenced by value, or value itself if it is not
+// a reference. A nil Resolver only accepts literal values.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	scheme, name, ok := secret.Parse(value)
+	if !ok {
+		return value, nil
+	}
+
+	var provider SecretProvider
+	if r != nil {
+		provider = r.providers[scheme]
+	}
+	if provider == nil {
+		return "", fmt.Errorf("no secret provider for %s references", scheme)
+	}
+
+	resolved, err := provider.Resolve(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", value, err)
+	}
+
+	return resolved, nil
+}
+
+// ResolveIntegration returns a copy of i with its credentials resolved, for
+// extenders that cannot resolve them at execution time, such as plugins.
+func (r *Resolver) ResolveIntegration(ctx context.Context, i *integration.Integration) (*integration.Integration, error) {
+	resolved := *i
+
+	var err error
+	if resolved.AuthToken, err = r.Resolve(ctx, i.AuthToken); err != nil {
+		return nil, err
+	}
+	if i.Basic != nil {
+		basic := *i.Basic
+		if basic.Password, err = r.Resolve(ctx, basic.Password); err != nil {
+			return nil, err
+		}
+		resolved.Basic = &basic
+	}
+	if i.OAuth != nil {
+		oauth := *i.OAuth
+		if oauth.ClientSecret, err = r.Resolve(ctx, oauth.ClientSecret); err != nil {
+			return nil, err
+		}
+		resolved.OAuth = &oauth
+	}
+	if i.HMAC != nil {
+		hmac := *i.HMAC
+		if hmac.Secret, err = r.Resolve(ctx, hmac.Secret); err != nil {
+			return nil, err
+		}
+		resolved.HMAC = &hmac
+	}
+	if i.Credentials != nil {
+		credentials := i.Credentials.Copy()
+		for _, c := range []*integration.Credential{credentials.Primary, credentials.Secondary} {
+			if c == nil {
+				continue
+			}
+			if c.Value, err = r.Resolve(ctx, c.Value); err != nil {
+				return nil, err
+			}
+		}
+		resolved.Credentials = credentials
+	}
+
+	return &resolved, nil
+}
+
+// Module provides the secret store and resolver for Uber Fx.
+var Module = fx.Options(
+	fx.Provide(
+		NewStore,
+		NewResolver,
+	),
+)
@@ -0,0 +1,160 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/db"
+	"generic-integration-platform/internal/infra/encryption"
+	"sync"
+	"time"
+)
+
+const defaultCacheTTL = time.Minute
+
+// ErrStoreDisabled is returned when the secret store is used without a master key.
+var ErrStoreDisabled = errors.New("secret store is disabled: no encryption master key is configured")
+
+// Store keeps secrets envelope-encrypted in MongoDB and resolves secret://NAME
+// references. Resolved values are cached for a short time so that executions
+// do not query the database for every request.
+type Store struct {
+	repository db.SecretRepository
+	keyring    *encryption.Keyring
+	ttl        time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedSecret
+}
+
+// cachedSecret is a decrypted secret and when it must be read again.
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// NewStore creates a Store encrypting secrets with keyring. Without a keyring
+// the store is disabled.
+func NewStore(cfg *config.Config, repository db.SecretRepository, keyring *encryption.Keyring) *Store {
+	s := &Store{
+		repository: repository,
+		keyring:    keyring,
+		ttl:        cfg.Secrets.CacheTTL,
+		cache:      make(map[string]cachedSecret),
+	}
+	if s.ttl <= 0 {
+		s.ttl = defaultCacheTTL
+	}
+
+	return s
+}
+
+// Resolve returns the decrypted value of the secret name.
+func (s *Store) Resolve(ctx context.Context, name string) (string, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[name]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	if s.keyring == nil {
+		return "", ErrStoreDisabled
+	}
+
+	stored, err := s.repository.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	// The name is authenticated with the value so that values cannot be
+	// swapped between secrets.
+	value, err := s.keyring.Decrypt(stored.Value, stored.Name)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.cache[name] = cachedSecret{value: value, expires: time.Now().Add(s.ttl)}
+	s.mu.Unlock()
+
+	return value, nil
+}
+
+// Put encrypts and stores value under name.
+func (s *Store) Put(ctx context.Context, name, value string) (*db.Secret, error) {
+	if s.keyring == nil {
+		return nil, ErrStoreDisabled
+	}
+	if name == "" {
+		return nil, errors.New("secret name cannot be empty")
+	}
+
+	encrypted, err := s.keyring.Encrypt(value, name)
+	if err != nil {
+		return nil, err
+	}
+	stored := &db.Secret{Name: name, Value: encrypted, UpdatedAt: time.Now().UTC()}
+
+	if err := s.repository.Put(ctx, stored); err != nil {
+		return nil, err
+	}
+	s.forget(name)
+
+	return stored, nil
+}
+
+// Delete removes the secret name.
+func (s *Store) Delete(ctx context.Context, name string) error {
+	if err := s.repository.Delete(ctx, name); err != nil {
+		return err
+	}
+	s.forget(name)
+	return nil
+}
+
+// List returns the stored secrets, still encrypted.
+func (s *Store) List(ctx context.Context) ([]*db.Secret, error) {
+	return s.repository.GetAll(ctx)
+}
+
+// RotateKeys re-wraps the data keys of every stored secret with the active
+// master key. It returns the number of secrets updated.
+func (s *Store) RotateKeys(ctx context.Context) (int, error) {
+	if s.keyring == nil {
+		return 0, ErrStoreDisabled
+	}
+
+	stored, err := s.repository.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, sec := range stored {
+		value, changed, err := s.keyring.Rewrap(sec.Value, sec.Name)
+		if err != nil {
+			return updated, fmt.Errorf("secret %s: %w", sec.Name, err)
+		}
+		if !changed {
+			continue
+		}
+
+		sec.Value = value
+		if err := s.repository.Put(ctx, sec); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// forget drops name from the cache.
+func (s *Store) forget(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.cache, name)
+}
@@ -0,0 +1,139 @@
+// Package storage opens the repositories and the event log of the storage
+// backend selected by the STORAGE_BACKEND setting.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"generic-integration-platform/internal/infra/config"
+	"generic-integration-platform/internal/infra/db"
+	"generic-integration-platform/internal/infra/encryption"
+	"generic-integration-platform/internal/infra/eventstore"
+
+	"go.uber.org/fx"
+)
+
+// Stores are the repositories and the event log of a storage backend.
+type Stores struct {
+	Integrations db.IntegrationRepository
+	Flows        db.FlowRepository
+	Secrets      db.SecretRepository
+	Transactor   db.Transactor // Writes flows and integrations in one transaction
+	Events       eventstore.Log
+
+	close func(ctx context.Context) error // Releases the connections of the backend
+}
+
+// Open connects to the storage backend selected in cfg. Credentials are
+// encrypted with keyring, when it is not nil, by the backends storing them.
+func Open(cfg *config.Config, keyring *encryption.Keyring) (*Stores, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageMongo:
+		return openMongo(cfg, keyring)
+	case config.StorageMemory:
+		integrations, flows := db.NewMemoryIntegrationRepository(), db.NewMemoryFlowRepository()
+		return &Stores{
+			Integrations: integrations,
+			Flows:        flows,
+			Secrets:      db.NewMemorySecretRepository(),
+			Transactor:   db.NewMemoryTransactor(flows, integrations),
+			Events:       eventstore.NewMemoryLog(),
+			close:        func(context.Context) error { return nil },
+		}, nil
+	case config.StorageBolt:
+		return openBolt(cfg, keyring)
+	case config.StoragePostgres:
+		return openPostgres(cfg, keyring)
+	}
+	return nil, fmt.Errorf("unsupported storage backend %q", cfg.Storage.Backend)
+}
+
+// openMongo connects to MongoDB and EventStoreDB.
+func openMongo(cfg *config.Config, keyring *encryption.Keyring) (*Stores, error) {
+	mdb, err := db.NewMongoDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client := eventstore.NewEventStoreClient(cfg).Client()
+	integrations, flows := db.NewIntegrationRepository(mdb, keyring), db.NewFlowRepository(mdb)
+
+	return &Stores{
+		Integrations: integrations,
+		Flows:        flows,
+		Secrets:      db.NewSecretRepository(mdb),
+		Transactor:   db.NewMongoTransactor(mdb, flows, integrations),
+		Events:       eventstore.NewEventStoreDBLog(client),
+		close: func(ctx context.Context) error {
+			_ = client.Close()
+			return mdb.Close(ctx)
+		},
+	}, nil
+}
+
+// openBolt opens the bbolt file keeping the documents and the events.
+func openBolt(cfg *config.Config, keyring *encryption.Keyring) (*Stores, error) {
+	bdb, err := db.NewBoltDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stores{
+		Integrations: db.NewBoltIntegrationRepository(bdb, keyring),
+		Flows:        db.NewBoltFlowRepository(bdb),
+		Secrets:      db.NewBoltSecretRepository(bdb),
+		Transactor:   db.NewBoltTransactor(bdb, keyring),
+		Events:       eventstore.NewBoltLog(bdb.DB),
+		close:        func(context.Context) error { return bdb.Close() },
+	}, nil
+}
+
+// openPostgres connects to PostgreSQL, keeping both the documents and the
+// events, and migrates its schema.
+func openPostgres(cfg *config.Config, keyring *encryption.Keyring) (*Stores, error) {
+	pg, err := db.NewPostgres(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stores{
+		Integrations: db.NewPostgresIntegrationRepository(pg, keyring),
+		Flows:        db.NewPostgresFlowRepository(pg),
+		Secrets:      db.NewPostgresSecretRepository(pg),
+		Transactor:   db.NewPostgresTransactor(pg, keyring),
+		Events:       eventstore.NewPostgresLog(pg.Pool),
+		close: func(context.Context) error {
+			pg.Close()
+			return nil
+		},
+	}, nil
+}
+
+// Close releases the connections of the backend.
+func (s *Stores) Close(ctx context.Context) error {
+	return s.close(ctx)
+}
+
+// NewStores opens the storage backend for Uber Fx, closing it when the
+// application stops.
+func NewStores(lc fx.Lifecycle, cfg *config.Config, keyring *encryption.Keyring) (*Stores, error) {
+	stores, err := Open(cfg, keyring)
+	if err != nil {
+		return nil, err
+	}
+	lc.Append(fx.Hook{OnStop: stores.Close})
+	return stores, nil
+}
+
+// Module provides the repositories, the event log and the event stores of the
+// configured storage backend.
+var Module = fx.Options(
+	fx.Provide(
+		NewStores,
+		func(s *Stores) db.IntegrationRepository { return s.Integrations },
+		func(s *Stores) db.FlowRepository { return s.Flows },
+		func(s *Stores) db.SecretRepository { return s.Secrets },
+		func(s *Stores) db.Transactor { return s.Transactor },
+		func(s *Stores) eventstore.Log { return s.Events },
+	),
+	eventstore.Module,
+)
@@ -0,0 +1,184 @@
+// Package tlsconfig builds the HTTP transports used to reach providers that
+// require client certificates or private certificate authorities.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"generic-integration-platform/internal/domain/integration"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// reloadInterval is how often the certificate files are checked for changes.
+const reloadInterval = 30 * time.Second
+
+// Load reads the certificates referenced by cfg and builds a tls.Config.
+func Load(cfg *integration.TLS) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+	if cfg.MinVersion == "1.3" {
+		config.MinVersion = tls.VersionTLS13
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(cfg.CAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, file := range cfg.CAFiles {
+			pem, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA file %s", file)
+			}
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// Transport is an http.RoundTripper dedicated to one integration. It rebuilds
+// its underlying transport when the certificate or CA files change, so renewed
+// certificates are picked up without restarting the process.
+type Transport struct {
+	config *integration.TLS
+	now    func() time.Time
+
+	mu        sync.Mutex
+	transport *http.Transport
+	versions  map[string]time.Time
+	checkedAt time.Time
+}
+
+// NewTransport creates a Transport for cfg, failing if the certificates cannot
+// be loaded.
+func NewTransport(cfg *integration.TLS) (*Transport, error) {
+	if cfg == nil {
+		return nil, errors.New("tls configuration cannot be nil")
+	}
+
+	t := &Transport{config: cfg, now: time.Now}
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// RoundTrip sends the request with the current transport.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.current().RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the current transport.
+func (t *Transport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.transport.CloseIdleConnections()
+}
+
+// current returns the transport to use, reloading the certificates first when
+// their files changed since they were last read.
+func (t *Transport) current() *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if now.Sub(t.checkedAt) < reloadInterval {
+		return t.transport
+	}
+	t.checkedAt = now
+
+	if !t.changed() {
+		return t.transport
+	}
+
+	// Keep serving with the previous certificates if the new files are invalid,
+	// for instance because they are being rewritten.
+	if err := t.reloadLocked(); err != nil {
+		log.Printf("Failed to reload TLS certificates: %v", err)
+	}
+
+	return t.transport
+}
+
+// reload loads the certificates and replaces the underlying transport.
+func (t *Transport) reload() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.checkedAt = t.now()
+	return t.reloadLocked()
+}
+
+// reloadLocked does the work of reload; t.mu must be held.
+func (t *Transport) reloadLocked() error {
+	versions := t.stat()
+
+	config, err := Load(t.config)
+	if err != nil {
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+
+	if t.transport != nil {
+		t.transport.CloseIdleConnections()
+	}
+	t.transport = transport
+	t.versions = versions
+
+	return nil
+}
+
+// changed reports whether any certificate file was modified since it was loaded.
+func (t *Transport) changed() bool {
+	versions := t.stat()
+	if len(versions) != len(t.versions) {
+		return true
+	}
+	for file, modified := range versions {
+		if !t.versions[file].Equal(modified) {
+			return true
+		}
+	}
+	return false
+}
+
+// stat returns the modification time of every certificate file.
+func (t *Transport) stat() map[string]time.Time {
+	files := append([]string{t.config.CertFile, t.config.KeyFile}, t.config.CAFiles...)
+
+	versions := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil {
+			versions[file] = info.ModTime()
+		}
+	}
+
+	return versions
+}
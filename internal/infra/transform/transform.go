@@ -0,0 +1,86 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// Run evaluates each jq expression over data and returns the results keyed by
+// output name. An expression yielding a single value produces that value, one
+// yielding several values produces an array and one yielding nothing produces null.
+//
+//	amount_minor: .input.amount * 100 | floor
+//	item_ids:     [.steps.cart.items[].id]
+func Run(ctx context.Context, expressions map[string]string, data map[string]interface{}) (map[string]interface{}, error) {
+	input, err := normalize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make(map[string]interface{}, len(expressions))
+	for output, expression := range expressions {
+		value, err := evaluate(ctx, expression, input)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s failed: %w", output, err)
+		}
+		outputs[output] = value
+	}
+
+	return outputs, nil
+}
+
+// evaluate runs a single expression over input.
+func evaluate(ctx context.Context, expression string, input interface{}) (interface{}, error) {
+	query, err := gojq.Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []interface{}
+	iter := code.RunWithContext(ctx, input)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := value.(error); ok {
+			if haltErr, ok := err.(*gojq.HaltError); ok && haltErr.Value() == nil {
+				break
+			}
+			return nil, err
+		}
+		results = append(results, value)
+	}
+
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return results[0], nil
+	default:
+		return results, nil
+	}
+}
+
+// normalize converts data into the plain JSON types understood by gojq.
+func normalize(data map[string]interface{}) (interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode execution context: %w", err)
+	}
+
+	var input interface{}
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return nil, fmt.Errorf("failed to decode execution context: %w", err)
+	}
+
+	return input, nil
+}
@@ -0,0 +1,40 @@
+#!/bin/sh
+# Starts the API with config.toml on each storage backend given as argument,
+# e.g. "memory bolt", and fails unless /health answers before the timeout.
+set -eu
+
+backends=${*:-memory bolt}
+port=${SMOKE_PORT:-18080}
+dir=$(mktemp -d)
+trap 'rm -rf "$dir"' EXIT
+
+go build -o "$dir/api" ./cmd/api
+
+for backend in $backends; do
+	STORAGE_BACKEND=$backend STORAGE_PATH="$dir/platform.db" PORT=$port \
+		API_KEY=${API_KEY:-smoke} INTEGRATIONS_WATCH=false \
+		"$dir/api" >"$dir/$backend.log" 2>&1 &
+	pid=$!
+
+	ok=false
+	for _ in $(seq 1 50); do
+		if ! kill -0 "$pid" 2>/dev/null; then
+			break
+		fi
+		if curl -fs "http://localhost:$port/health" >/dev/null; then
+			ok=true
+			break
+		fi
+		sleep 0.2
+	done
+
+	kill "$pid" 2>/dev/null || true
+	wait "$pid" 2>/dev/null || true
+
+	if [ "$ok" != true ]; then
+		echo "smoke: $backend backend did not start:" >&2
+		cat "$dir/$backend.log" >&2
+		exit 1
+	fi
+	echo "smoke: $backend backend started"
+done